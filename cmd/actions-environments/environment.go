@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+// environment is a deployment environment.
+type environment struct {
+	Name      string `yaml:"name"`
+	WaitTimer int    `yaml:"wait_timer"` // minutes to wait before deploying.
+
+	// Reviewers must approve the deployments, up to 6 users or teams of the
+	// owner organization.
+	Reviewers struct {
+		Users []string `yaml:"users"`
+		Teams []string `yaml:"teams"`
+	} `yaml:"reviewers"`
+	PreventSelfReview bool `yaml:"prevent_self_review"`
+
+	// Branches allowed to deploy: the protected ones, the ones matching the
+	// patterns of BranchPolicies, or any when both are empty.
+	ProtectedBranches bool     `yaml:"protected_branches"`
+	BranchPolicies    []string `yaml:"branch_policies"`
+}
+
+// settings returns the settings compared to report the drift.
+func (e environment) settings() []fleet.Setting {
+	reviewers := make([]string, 0, len(e.Reviewers.Users)+len(e.Reviewers.Teams))
+	for _, v := range e.Reviewers.Users {
+		reviewers = append(reviewers, "user:"+strings.ToLower(v))
+	}
+	for _, v := range e.Reviewers.Teams {
+		reviewers = append(reviewers, "team:"+strings.ToLower(v))
+	}
+	sort.Strings(reviewers)
+
+	policies := append([]string(nil), e.BranchPolicies...)
+	sort.Strings(policies)
+
+	return []fleet.Setting{
+		{Name: e.Name + ".wait_timer", Value: strconv.Itoa(e.WaitTimer)},
+		{Name: e.Name + ".reviewers", Value: "[" + strings.Join(reviewers, ",") + "]"},
+		{Name: e.Name + ".prevent_self_review", Value: strconv.FormatBool(e.PreventSelfReview)},
+		{Name: e.Name + ".protected_branches", Value: strconv.FormatBool(e.ProtectedBranches)},
+		{Name: e.Name + ".branch_policies", Value: "[" + strings.Join(policies, ",") + "]"},
+	}
+}
+
+// environmentResponse is an environment as returned by the API.
+type environmentResponse struct {
+	ProtectionRules []struct {
+		Type              string `json:"type"`
+		WaitTimer         int    `json:"wait_timer"`
+		PreventSelfReview bool   `json:"prevent_self_review"`
+		Reviewers         []struct {
+			Type     string `json:"type"`
+			Reviewer struct {
+				Login string `json:"login"`
+				Slug  string `json:"slug"`
+			} `json:"reviewer"`
+		} `json:"reviewers"`
+	} `json:"protection_rules"`
+	DeploymentBranchPolicy *branchPolicy `json:"deployment_branch_policy"`
+}
+
+type branchPolicy struct {
+	ProtectedBranches    bool `json:"protected_branches"`
+	CustomBranchPolicies bool `json:"custom_branch_policies"`
+}
+
+type reviewer struct {
+	Type string `json:"type"` // User or Team.
+	ID   int64  `json:"id"`
+}
+
+// environmentRequest is an environment as set through the API.
+type environmentRequest struct {
+	WaitTimer              int           `json:"wait_timer"`
+	PreventSelfReview      bool          `json:"prevent_self_review"`
+	Reviewers              []reviewer    `json:"reviewers"`
+	DeploymentBranchPolicy *branchPolicy `json:"deployment_branch_policy"`
+}
+
+type policy struct {
+	ID   int64  `json:"id,omitempty"`
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"` // branch or tag.
+}
+
+// environments manages the environments of a repository.
+type environments struct {
+	client *github.Client
+	prefix string // "repos/<owner>/<repo>".
+	ids    *reviewerIDs
+}
+
+// get returns the current environment, nil when it does not exist.
+func (s environments) get(ctx context.Context, name string) (*environment, error) {
+	var out environmentResponse
+	path := s.prefix + "/environments/" + url.PathEscape(name)
+	if err := fleet.Send(ctx, s.client, http.MethodGet, path, nil, &out); err != nil {
+		if fleet.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get environment %s: %w", name, err)
+	}
+
+	e := environment{Name: name}
+	for _, rule := range out.ProtectionRules {
+		switch rule.Type {
+		case "wait_timer":
+			e.WaitTimer = rule.WaitTimer
+		case "required_reviewers":
+			e.PreventSelfReview = rule.PreventSelfReview
+			for _, v := range rule.Reviewers {
+				if v.Type == "Team" {
+					e.Reviewers.Teams = append(e.Reviewers.Teams, v.Reviewer.Slug)
+				} else {
+					e.Reviewers.Users = append(e.Reviewers.Users, v.Reviewer.Login)
+				}
+			}
+		}
+	}
+
+	if p := out.DeploymentBranchPolicy; p != nil {
+		e.ProtectedBranches = p.ProtectedBranches
+		if p.CustomBranchPolicies {
+			policies, err := s.policies(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range policies {
+				e.BranchPolicies = append(e.BranchPolicies, v.Name)
+			}
+		}
+	}
+
+	return &e, nil
+}
+
+// save creates or updates the environment, then syncs its branch policies.
+func (s environments) save(ctx context.Context, e environment) error {
+	body := environmentRequest{WaitTimer: e.WaitTimer, PreventSelfReview: e.PreventSelfReview, Reviewers: []reviewer{}}
+	for _, v := range e.Reviewers.Users {
+		id, err := s.ids.user(ctx, v)
+		if err != nil {
+			return err
+		}
+		body.Reviewers = append(body.Reviewers, reviewer{Type: "User", ID: id})
+	}
+	for _, v := range e.Reviewers.Teams {
+		id, err := s.ids.team(ctx, v)
+		if err != nil {
+			return err
+		}
+		body.Reviewers = append(body.Reviewers, reviewer{Type: "Team", ID: id})
+	}
+
+	if e.ProtectedBranches || len(e.BranchPolicies) > 0 {
+		body.DeploymentBranchPolicy = &branchPolicy{ProtectedBranches: e.ProtectedBranches, CustomBranchPolicies: !e.ProtectedBranches}
+	}
+
+	path := s.prefix + "/environments/" + url.PathEscape(e.Name)
+	if err := fleet.Send(ctx, s.client, http.MethodPut, path, body, nil); err != nil {
+		return fmt.Errorf("unable to save environment %s: %w", e.Name, err)
+	}
+
+	if body.DeploymentBranchPolicy == nil || e.ProtectedBranches {
+		return nil
+	}
+
+	return s.syncPolicies(ctx, e)
+}
+
+func (s environments) policies(ctx context.Context, name string) ([]policy, error) {
+	var out struct {
+		BranchPolicies []policy `json:"branch_policies"`
+	}
+	path := s.prefix + "/environments/" + url.PathEscape(name) + "/deployment-branch-policies?per_page=100"
+	if err := fleet.Send(ctx, s.client, http.MethodGet, path, nil, &out); err != nil {
+		return nil, fmt.Errorf("unable to list the branch policies of %s: %w", name, err)
+	}
+
+	return out.BranchPolicies, nil
+}
+
+// syncPolicies creates the missing branch policies of the environment and
+// deletes the ones it does not list.
+func (s environments) syncPolicies(ctx context.Context, e environment) error {
+	current, err := s.policies(ctx, e.Name)
+	if err != nil {
+		return err
+	}
+
+	path := s.prefix + "/environments/" + url.PathEscape(e.Name) + "/deployment-branch-policies"
+	wanted := make(map[string]bool, len(e.BranchPolicies))
+	for _, v := range e.BranchPolicies {
+		wanted[v] = true
+	}
+
+	existing := make(map[string]bool, len(current))
+	for _, v := range current {
+		existing[v.Name] = true
+		if wanted[v.Name] {
+			continue
+		}
+		if err := fleet.Send(ctx, s.client, http.MethodDelete, path+"/"+strconv.FormatInt(v.ID, 10), nil, nil); err != nil {
+			return fmt.Errorf("unable to delete the branch policy %s of %s: %w", v.Name, e.Name, err)
+		}
+	}
+
+	for _, v := range e.BranchPolicies {
+		if existing[v] {
+			continue
+		}
+		if err := fleet.Send(ctx, s.client, http.MethodPost, path, policy{Name: v, Type: "branch"}, nil); err != nil {
+			return fmt.Errorf("unable to create the branch policy %s of %s: %w", v, e.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// reviewerIDs looks up the IDs of the reviewers once for all the
+// destinations.
+type reviewerIDs struct {
+	client *github.Client
+	org    string
+	users  map[string]int64
+	teams  map[string]int64
+}
+
+func (r *reviewerIDs) user(ctx context.Context, login string) (int64, error) {
+	if id, ok := r.users[login]; ok {
+		return id, nil
+	}
+
+	user, _, err := r.client.Users.Get(ctx, login)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get user %s: %w", login, err)
+	}
+
+	r.users[login] = user.GetID()
+	return user.GetID(), nil
+}
+
+func (r *reviewerIDs) team(ctx context.Context, slug string) (int64, error) {
+	if id, ok := r.teams[slug]; ok {
+		return id, nil
+	}
+
+	var team struct {
+		ID int64 `json:"id"`
+	}
+	if err := fleet.Send(ctx, r.client, http.MethodGet, "orgs/"+r.org+"/teams/"+slug, nil, &team); err != nil {
+		return 0, fmt.Errorf("unable to get team %s: %w", slug, err)
+	}
+
+	r.teams[slug] = team.ID
+	return team.ID, nil
+}
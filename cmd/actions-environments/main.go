@@ -0,0 +1,109 @@
+// Command actions-environments creates or updates the same deployment
+// environments on a batch of repositories.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and their
+// environments.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Environments []environment `yaml:"environments"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	ids := &reviewerIDs{client: client, org: owner, users: make(map[string]int64), teams: make(map[string]int64)}
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		s := environments{client: client, prefix: "repos/" + owner + "/" + d.Repository, ids: ids}
+
+		var changes []string
+		for _, v := range c.Environments {
+			current, err := s.get(ctx, v.Name)
+			if err != nil {
+				return "", err
+			}
+
+			if current == nil {
+				changes = append(changes, "create "+v.Name)
+			} else {
+				diff := fleet.Changes(current.settings(), v.settings())
+				if len(diff) == 0 {
+					continue
+				}
+				changes = append(changes, diff...)
+			}
+
+			if !_flags.DryRun {
+				if err := s.save(ctx, v); err != nil {
+					return "", err
+				}
+			}
+		}
+
+		return fleet.Summary(changes, _flags.DryRun), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() error {
+	if len(c.Environments) == 0 {
+		return errors.New("environments are required")
+	}
+
+	for _, v := range c.Environments {
+		switch {
+		case v.Name == "":
+			return errors.New("environments need a name")
+		case v.WaitTimer < 0 || v.WaitTimer > 43200:
+			return fmt.Errorf("the wait_timer of %s must be between 0 and 43200 minutes", v.Name)
+		case len(v.Reviewers.Users)+len(v.Reviewers.Teams) > 6:
+			return fmt.Errorf("%s has more than 6 reviewers", v.Name)
+		case v.ProtectedBranches && len(v.BranchPolicies) > 0:
+			return fmt.Errorf("%s cannot have both protected_branches and branch_policies", v.Name)
+		}
+	}
+
+	return c.Validate()
+}
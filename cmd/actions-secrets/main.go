@@ -0,0 +1,192 @@
+// Command actions-secrets sets, rotates or deletes the GitHub Actions secrets
+// of a batch of repositories.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and their
+// secrets.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Secrets []secret `yaml:"secrets"`
+}
+
+// secret is an Actions secret. Its value is read from the environment or from
+// a file, never from the config file.
+type secret struct {
+	Name string `yaml:"name"`
+
+	ValueEnv  string `yaml:"value_env"`  // environment variable holding the value.
+	ValueFile string `yaml:"value_file"` // file holding the value, rendered for each destination.
+
+	OnlyMissing bool `yaml:"only_missing"` // leaves the existing secret as is instead of rotating it.
+	Delete      bool `yaml:"delete"`       // deletes the secret instead.
+
+	value string
+}
+
+// _secretName is the format GitHub accepts for secret names.
+var _secretName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		prefix := "repos/" + owner + "/" + d.Repository + "/actions"
+
+		var key *publicKey
+		changes := make([]string, 0, len(c.Secrets))
+		for _, v := range c.Secrets {
+			exists, err := secretExists(ctx, client, prefix, v.Name)
+			if err != nil {
+				return "", err
+			}
+
+			switch {
+			case v.Delete && !exists, v.OnlyMissing && exists:
+				continue
+			case v.Delete:
+				changes = append(changes, "delete "+v.Name)
+				if !_flags.DryRun {
+					err = fleet.Send(ctx, client, http.MethodDelete, prefix+"/secrets/"+v.Name, nil, nil)
+				}
+			default:
+				changes = append(changes, "set "+v.Name)
+				if _flags.DryRun {
+					break
+				}
+
+				if key == nil {
+					k, err := getPublicKey(ctx, client, prefix)
+					if err != nil {
+						return "", err
+					}
+					key = &k
+				}
+
+				err = v.set(ctx, client, prefix, *key, c.Data(d))
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+
+		return fleet.Summary(changes, _flags.DryRun), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if len(c.Secrets) == 0 {
+		return errors.New("secrets are required")
+	}
+
+	for i, v := range c.Secrets {
+		if !_secretName.MatchString(v.Name) || strings.HasPrefix(strings.ToUpper(v.Name), "GITHUB_") {
+			return fmt.Errorf("invalid secret name %q", v.Name)
+		}
+
+		switch {
+		case v.Delete:
+		case v.ValueEnv != "" && v.ValueFile != "":
+			return fmt.Errorf("secret %s has both value_env and value_file", v.Name)
+		case v.ValueEnv != "":
+			if c.Secrets[i].value = os.Getenv(v.ValueEnv); c.Secrets[i].value == "" {
+				return fmt.Errorf("the value of the secret %s is not set in %s", v.Name, v.ValueEnv)
+			}
+			redact.Secret(c.Secrets[i].value)
+		case v.ValueFile == "":
+			return fmt.Errorf("secret %s needs a value_env or a value_file", v.Name)
+		}
+	}
+
+	return c.Validate()
+}
+
+// set encrypts the value of the secret with the public key of the repository
+// and sets it.
+func (s secret) set(ctx context.Context, client *github.Client, prefix string, key publicKey, data mkpr.TemplateData) error {
+	value := s.value
+	if s.ValueFile != "" {
+		path, err := mkpr.Render("value_file", s.ValueFile, data)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimSuffix(string(content), "\n")
+		redact.Secret(value)
+	}
+
+	body, err := key.seal(value)
+	if err != nil {
+		return err
+	}
+
+	if err := fleet.Send(ctx, client, http.MethodPut, prefix+"/secrets/"+s.Name, body, nil); err != nil {
+		return fmt.Errorf("unable to set secret %s: %w", s.Name, err)
+	}
+
+	return nil
+}
+
+// secretExists reports whether the secret is set.
+func secretExists(ctx context.Context, client *github.Client, prefix, name string) (bool, error) {
+	err := fleet.Send(ctx, client, http.MethodGet, prefix+"/secrets/"+name, nil, nil)
+	switch {
+	case fleet.IsNotFound(err):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("unable to get secret %s: %w", name, err)
+	default:
+		return true, nil
+	}
+}
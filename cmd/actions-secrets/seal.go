@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// publicKey is the key the secrets of a repository are encrypted with.
+type publicKey struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"` // base64 encoded.
+}
+
+// encryptedSecret is the body setting a secret.
+type encryptedSecret struct {
+	EncryptedValue string `json:"encrypted_value"`
+	KeyID          string `json:"key_id"`
+}
+
+func getPublicKey(ctx context.Context, client *github.Client, prefix string) (publicKey, error) {
+	var key publicKey
+	if err := fleet.Send(ctx, client, http.MethodGet, prefix+"/secrets/public-key", nil, &key); err != nil {
+		return publicKey{}, fmt.Errorf("unable to get the public key: %w", err)
+	}
+
+	return key, nil
+}
+
+// seal encrypts value with the public key in a libsodium sealed box, the
+// format GitHub expects.
+func (k publicKey) seal(value string) (encryptedSecret, error) {
+	raw, err := base64.StdEncoding.DecodeString(k.Key)
+	if err != nil || len(raw) != 32 {
+		return encryptedSecret{}, fmt.Errorf("invalid public key %s", k.KeyID)
+	}
+
+	var recipient [32]byte
+	copy(recipient[:], raw)
+	sealed, err := box.SealAnonymous(nil, []byte(value), &recipient, rand.Reader)
+	if err != nil {
+		return encryptedSecret{}, err
+	}
+
+	return encryptedSecret{EncryptedValue: base64.StdEncoding.EncodeToString(sealed), KeyID: k.KeyID}, nil
+}
@@ -0,0 +1,157 @@
+// Command actions-variables sets or deletes the GitHub Actions variables of a
+// batch of repositories and of their environments.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and their
+// variables.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Variables []variable `yaml:"variables"`
+}
+
+// variable is an Actions variable of the repositories, or of one of their
+// environments.
+type variable struct {
+	Name        string `yaml:"name"`
+	Value       string `yaml:"value"`       // rendered for each destination.
+	Environment string `yaml:"environment"` // environment of the variable, the repository when empty.
+	Delete      bool   `yaml:"delete"`      // deletes the variable instead.
+}
+
+// _variableName is the format GitHub accepts for variable names.
+var _variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		var changes []string
+		for _, v := range c.Variables {
+			change, err := v.apply(ctx, client, "repos/"+owner+"/"+d.Repository, c.Data(d), _flags.DryRun)
+			if err != nil {
+				return "", err
+			}
+			if change != "" {
+				changes = append(changes, change)
+			}
+		}
+
+		return fleet.Summary(changes, _flags.DryRun), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() error {
+	if len(c.Variables) == 0 {
+		return errors.New("variables are required")
+	}
+
+	for _, v := range c.Variables {
+		if !_variableName.MatchString(v.Name) || strings.HasPrefix(strings.ToUpper(v.Name), "GITHUB_") {
+			return fmt.Errorf("invalid variable name %q", v.Name)
+		}
+	}
+
+	return c.Validate()
+}
+
+// apply sets or deletes the variable of the repository at prefix, returning
+// the change made, or that would be on a dry run, empty when there is none.
+func (v variable) apply(ctx context.Context, client *github.Client, prefix string, data mkpr.TemplateData, dryRun bool) (string, error) {
+	name := v.Name
+	if v.Environment != "" {
+		prefix += "/environments/" + url.PathEscape(v.Environment)
+		name = v.Environment + "/" + v.Name
+	} else {
+		prefix += "/actions"
+	}
+
+	value, err := mkpr.Render(v.Name, v.Value, data)
+	if err != nil {
+		return "", err
+	}
+
+	var current struct {
+		Value string `json:"value"`
+	}
+	err = fleet.Send(ctx, client, http.MethodGet, prefix+"/variables/"+v.Name, nil, &current)
+	exists := err == nil
+	if err != nil && !fleet.IsNotFound(err) {
+		return "", fmt.Errorf("unable to get variable %s: %w", name, err)
+	}
+
+	method, path, change := "", prefix+"/variables/"+v.Name, ""
+	switch {
+	case v.Delete && exists:
+		method, change = http.MethodDelete, "delete "+name
+	case v.Delete:
+		return "", nil
+	case !exists:
+		method, path, change = http.MethodPost, prefix+"/variables", "create "+name
+	case current.Value != value:
+		method, change = http.MethodPatch, "update "+name
+	default:
+		return "", nil
+	}
+
+	if dryRun {
+		return change, nil
+	}
+
+	var body interface{}
+	if !v.Delete {
+		body = map[string]string{"name": v.Name, "value": value}
+	}
+	if err := fleet.Send(ctx, client, method, path, body, nil); err != nil {
+		return "", fmt.Errorf("unable to %s: %w", change, err)
+	}
+
+	return change, nil
+}
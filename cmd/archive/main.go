@@ -0,0 +1,127 @@
+// Command archive archives, or unarchives, a batch of repositories, such as
+// the ones left behind by an organization cleanup.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var (
+	_flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_yes   *bool        = flag.Bool("yes", false, "Skips the confirmation, for unattended runs")
+)
+
+// config is the content of a config file: the repositories to archive.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Unarchive bool `yaml:"unarchive"` // unarchives the destinations instead.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	owner := c.OwnerOrDefault()
+	action, done, skipped := "archive", "archived", "already archived"
+	if c.Unarchive {
+		action, done, skipped = "unarchive", "unarchived", "not archived"
+	}
+
+	if !_flags.DryRun && !*_yes {
+		if err := confirm(action, owner, len(c.Destinations)); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("hold ...")
+	counts := make(map[string]int)
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		repo, _, err := client.Repositories.Get(ctx, owner, d.Repository)
+		if err != nil {
+			return "", fmt.Errorf("unable to get repository: %w", err)
+		}
+
+		if repo.GetArchived() != c.Unarchive {
+			counts[skipped]++
+			return skipped, nil
+		}
+
+		if _flags.DryRun {
+			counts["would "+action]++
+			return "would " + action, nil
+		}
+
+		archived := !c.Unarchive
+		if _, _, err := client.Repositories.Edit(ctx, owner, d.Repository, &github.Repository{Archived: &archived}); err != nil {
+			return "", fmt.Errorf("unable to %s: %w", action, err)
+		}
+
+		counts[done]++
+		return done, nil
+	})
+
+	var report []string
+	for _, v := range []string{done, "would " + action, skipped} {
+		if counts[v] > 0 {
+			report = append(report, fmt.Sprintf("%s %d", v, counts[v]))
+		}
+	}
+	if len(report) > 0 {
+		fmt.Printf("%s of %d repositories.\n", strings.Join(report, ", "), len(c.Destinations))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+// confirm asks the user to type the owner before changing the repositories,
+// so a wrong config or owner is not applied by accident.
+func confirm(action, owner string, count int) error {
+	fmt.Printf("about to %s %d repositories of %s, type %s to confirm: ", action, count, owner, owner)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return fmt.Errorf("unable to read the confirmation: %w", err)
+	}
+
+	if strings.TrimSpace(answer) != owner {
+		return errors.New("not confirmed, nothing was changed")
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+// errConflict is returned when the change does not apply cleanly on the
+// branch, so it must be backported by hand.
+var errConflict = errors.New("conflict")
+
+// errApplied is returned when the branch already has the change.
+var errApplied = errors.New("already applied")
+
+// cherryPick creates the head branch from branch with the changes of commit
+// since parent on top, using the API only. GitHub cannot cherry-pick, so a
+// sibling of the branch with parent as its parent is merged with commit, which
+// brings the changes of commit onto the tree of the branch, and the merged tree
+// is committed on top of the branch.
+func cherryPick(ctx context.Context, client *github.Client, owner, repo, branch, head string, commit *github.Commit, parent string) (string, error) {
+	ref, _, err := client.Git.GetRef(ctx, owner, repo, "heads/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("unable to get branch %s: %w", branch, err)
+	}
+
+	tip, _, err := client.Git.GetCommit(ctx, owner, repo, ref.GetObject().GetSHA())
+	if err != nil {
+		return "", fmt.Errorf("unable to get the last commit of %s: %w", branch, err)
+	}
+
+	message := "sibling of " + tip.GetSHA()
+	sibling, _, err := client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+		Message: &message,
+		Tree:    tip.Tree,
+		Parents: []github.Commit{{SHA: &parent}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to create commit: %w", err)
+	}
+
+	headRef := "refs/heads/" + head
+	if _, _, err := client.Git.CreateRef(ctx, owner, repo, &github.Reference{Ref: &headRef, Object: &github.GitObject{SHA: sibling.SHA}}); err != nil {
+		return "", fmt.Errorf("unable to create branch %s: %w", head, err)
+	}
+
+	sha, err := pick(ctx, client, owner, repo, head, tip, commit)
+	if err != nil {
+		// the branch is useless, leave the repository as it was.
+		if _, derr := client.Git.DeleteRef(ctx, owner, repo, headRef); derr != nil && !fleet.IsNotFound(derr) {
+			return "", fmt.Errorf("%v, and unable to delete branch %s: %w", err, head, derr)
+		}
+		return "", err
+	}
+
+	return sha, nil
+}
+
+// pick merges commit into the head branch, holding the sibling of tip, and
+// moves the head branch to a commit on top of tip with the merged tree.
+func pick(ctx context.Context, client *github.Client, owner, repo, head string, tip, commit *github.Commit) (string, error) {
+	merged, _, err := client.Repositories.Merge(ctx, owner, repo, &github.RepositoryMergeRequest{Base: &head, Head: commit.SHA})
+	if err != nil {
+		var resp *github.ErrorResponse
+		if errors.As(err, &resp) && resp.Response != nil && resp.Response.StatusCode == http.StatusConflict {
+			return "", errConflict
+		}
+		return "", fmt.Errorf("unable to merge: %w", err)
+	}
+
+	tree := merged.GetCommit().GetTree()
+	if merged.GetSHA() == "" || tree.GetSHA() == tip.GetTree().GetSHA() {
+		return "", errApplied
+	}
+
+	message := commit.GetMessage() + "\n\n(cherry picked from commit " + commit.GetSHA() + ")"
+	picked, _, err := client.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+		Author:  commit.Author, // the committer is the user of the token, as git does.
+		Message: &message,
+		Tree:    &github.Tree{SHA: tree.SHA},
+		Parents: []github.Commit{{SHA: tip.SHA}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to create commit: %w", err)
+	}
+
+	ref := "refs/heads/" + head
+	if _, _, err := client.Git.UpdateRef(ctx, owner, repo, &github.Reference{Ref: &ref, Object: &github.GitObject{SHA: picked.SHA}}, true); err != nil {
+		return "", fmt.Errorf("unable to update branch %s: %w", head, err)
+	}
+
+	return picked.GetSHA(), nil
+}
@@ -0,0 +1,250 @@
+// Command backport opens pull requests cherry-picking a change onto the
+// release branches of a batch of repositories.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the change to backport, found in
+// every destination by exactly one of commit, pull request or head, and the
+// branches to backport it onto.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Commit      string `yaml:"commit"`       // SHA of the commit.
+	PullRequest int    `yaml:"pull_request"` // number of the merged pull request.
+	Head        string `yaml:"head"`         // head of the merged pull request, for instance, the head of a mkpr batch.
+
+	// Branches to backport onto, in path.Match syntax, for instance,
+	// "release/*". The base of a destination takes precedence.
+	Branches []string `yaml:"branches"`
+}
+
+// change is the change to backport in a destination.
+type change struct {
+	commit *github.Commit
+	parent string // the changes are the ones of commit since parent.
+	title  string
+	ref    string // "#<number>" or the SHA of the commit, for the pull requests.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		ch, err := c.change(ctx, client, owner, d.Repository)
+		if err != nil {
+			return "", err
+		}
+
+		branches := []string{d.Base}
+		if d.Base == "" {
+			if branches, err = matchingBranches(ctx, client, owner, d.Repository, c.Branches); err != nil {
+				return "", err
+			}
+		}
+		if len(branches) == 0 {
+			return "no branches to backport onto", nil
+		}
+
+		results := make([]string, 0, len(branches))
+		for _, v := range branches {
+			result, err := backport(ctx, client, owner, d.Repository, v, ch, _flags.DryRun)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", v, err)
+			}
+			results = append(results, v+" "+result)
+		}
+
+		return strings.Join(results, ", "), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() error {
+	var given int
+	for _, v := range []bool{c.Commit != "", c.PullRequest != 0, c.Head != ""} {
+		if v {
+			given++
+		}
+	}
+	if given != 1 {
+		return errors.New("exactly one of commit, pull_request or head is required")
+	}
+
+	for _, v := range c.Branches {
+		if _, err := path.Match(v, ""); err != nil {
+			return fmt.Errorf("invalid branch pattern %q: %w", v, err)
+		}
+	}
+
+	return c.Validate()
+}
+
+// change returns the change to backport in the repository. The change of a
+// pull request is its merge commit since its first parent, so pull requests
+// rebased with several commits only backport their last one.
+func (c config) change(ctx context.Context, client *github.Client, owner, repo string) (change, error) {
+	sha, title, ref := c.Commit, "", c.Commit
+	if sha == "" {
+		pr, err := c.pullRequest(ctx, client, owner, repo)
+		if err != nil {
+			return change{}, err
+		}
+		sha, title, ref = pr.GetMergeCommitSHA(), pr.GetTitle(), fmt.Sprintf("#%d", pr.GetNumber())
+	}
+
+	commit, _, err := client.Git.GetCommit(ctx, owner, repo, sha)
+	if err != nil {
+		return change{}, fmt.Errorf("unable to get commit %s: %w", sha, err)
+	}
+	if len(commit.Parents) == 0 {
+		return change{}, fmt.Errorf("commit %s has no parent", sha)
+	}
+
+	if title == "" {
+		title = strings.SplitN(commit.GetMessage(), "\n", 2)[0]
+	}
+
+	return change{commit: commit, parent: commit.Parents[0].GetSHA(), title: title, ref: ref}, nil
+}
+
+// pullRequest returns the merged pull request of the config in the repository.
+func (c config) pullRequest(ctx context.Context, client *github.Client, owner, repo string) (*github.PullRequest, error) {
+	if c.PullRequest != 0 {
+		pr, _, err := client.PullRequests.Get(ctx, owner, repo, c.PullRequest)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get pull request #%d: %w", c.PullRequest, err)
+		}
+		if !pr.GetMerged() {
+			return nil, fmt.Errorf("pull request #%d is not merged", c.PullRequest)
+		}
+		return pr, nil
+	}
+
+	opt := &github.PullRequestListOptions{State: "closed", Head: owner + ":" + c.Head, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		pulls, resp, err := client.PullRequests.List(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list pull requests: %w", err)
+		}
+
+		for _, v := range pulls {
+			if v.MergedAt != nil {
+				return v, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, fmt.Errorf("no merged pull request from %s", c.Head)
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// matchingBranches returns the branches of the repository matching any of the
+// patterns.
+func matchingBranches(ctx context.Context, client *github.Client, owner, repo string, patterns []string) ([]string, error) {
+	var out []string
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		branches, resp, err := client.Repositories.ListBranches(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list branches: %w", err)
+		}
+
+		for _, v := range branches {
+			for _, p := range patterns {
+				if ok, _ := path.Match(p, v.GetName()); ok {
+					out = append(out, v.GetName())
+					break
+				}
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// backport opens the pull request backporting the change onto the branch,
+// returning what was done, or would be on a dry run.
+func backport(ctx context.Context, client *github.Client, owner, repo, branch string, ch change, dryRun bool) (string, error) {
+	head := "backport/" + ch.commit.GetSHA()[:7] + "/" + branch
+	if _, _, err := client.Git.GetRef(ctx, owner, repo, "heads/"+head); err == nil {
+		return "already backported in " + head, nil
+	} else if !fleet.IsNotFound(err) {
+		return "", fmt.Errorf("unable to get branch %s: %w", head, err)
+	}
+
+	if dryRun {
+		return "would backport", nil
+	}
+
+	_, err := cherryPick(ctx, client, owner, repo, branch, head, ch.commit, ch.parent)
+	switch {
+	case errors.Is(err, errConflict):
+		return "conflicts, backport by hand", nil
+	case errors.Is(err, errApplied):
+		return "already has the change", nil
+	case err != nil:
+		return "", err
+	}
+
+	title := "[" + branch + "] " + ch.title
+	body := fmt.Sprintf("Backport of %s onto %s.", ch.ref, branch)
+	pr, _, err := client.PullRequests.Create(ctx, owner, repo, &github.NewPullRequest{
+		Title: &title,
+		Head:  &head,
+		Base:  &branch,
+		Body:  &body,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to create pull request: %w", err)
+	}
+
+	return pr.GetHTMLURL(), nil
+}
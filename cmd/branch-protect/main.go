@@ -0,0 +1,86 @@
+// Command branch-protect applies the same branch protection to a batch of
+// repositories, reporting the settings that differ from the current ones.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/protection"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the protection
+// of their branch.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Branch     string                `yaml:"branch"` // branch to protect, overridden by the base of the destinations, the default branch when both are empty.
+	Protection protection.Protection `yaml:"protection"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		branch := c.Branch
+		if d.Base != "" {
+			branch = d.Base
+		}
+		if branch == "" {
+			repository, _, err := client.Repositories.Get(ctx, owner, d.Repository)
+			if err != nil {
+				return "", fmt.Errorf("unable to get repository: %w", err)
+			}
+			branch = repository.GetDefaultBranch()
+		}
+
+		current, err := protection.Get(ctx, client, owner, d.Repository, branch)
+		if err != nil {
+			return "", err
+		}
+
+		changes := fleet.Changes(current.Settings(), c.Protection.Settings())
+		if len(changes) > 0 && !_flags.DryRun {
+			if err := protection.Set(ctx, client, owner, d.Repository, branch, c.Protection, current); err != nil {
+				return "", err
+			}
+		}
+
+		return branch + " " + fleet.Summary(changes, _flags.DryRun), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
@@ -0,0 +1,159 @@
+// Command changelog collects the pull requests merged in a batch of
+// repositories, between two dates or two tags, and renders them as a combined
+// changelog, for the newsletters following a coordinated release.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// _layout is the layout of the dates of the config.
+const _layout = "2006-01-02"
+
+var (
+	_flags  *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_output *string      = flag.String("output", "", "Writes the changelog to the given file instead of printing it")
+)
+
+// config is the content of a config file: the destinations and the range of
+// the changelog, either dates or tags.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	// Since and Until are the dates, as 2006-01-02, the pull requests were
+	// merged between, both included, into the base of the destinations or their
+	// default branch. Until is today when empty.
+	Since string `yaml:"since"`
+	Until string `yaml:"until"`
+
+	// From and To are the tags the pull requests were merged between, in every
+	// destination. To is the default branch when empty.
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+
+	// Template is rendered as text/template with the changelog, see changelog.
+	// A Markdown list of the pull requests by repository when empty.
+	Template string `yaml:"template"`
+
+	since, until time.Time
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(time.Now()); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	log := changelog{Since: c.Since, Until: c.Until, From: c.From, To: c.To}
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		r, _, err := client.Repositories.Get(ctx, owner, d.Repository)
+		if err != nil {
+			return "", fmt.Errorf("unable to get repository: %w", err)
+		}
+
+		repo := repository{Owner: owner, Name: d.Repository, URL: r.GetHTMLURL()}
+		if c.From != "" {
+			to := c.To
+			if to == "" {
+				to = r.GetDefaultBranch()
+			}
+			repo.PullRequests, err = mergedBetweenTags(ctx, client, owner, d.Repository, c.From, to)
+		} else {
+			base := d.Base
+			if base == "" {
+				base = r.GetDefaultBranch()
+			}
+			repo.PullRequests, err = mergedBetweenDates(ctx, client, owner, d.Repository, base, c.since, c.until)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		log.Repositories = append(log.Repositories, repo)
+		return fmt.Sprintf("%d pull requests", len(repo.PullRequests)), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	text, err := log.render(c.Template)
+	if err != nil {
+		return err
+	}
+
+	if *_output == "" {
+		fmt.Print("\n" + text)
+	} else {
+		if err := os.WriteFile(*_output, []byte(text), 0o644); err != nil {
+			return fmt.Errorf("unable to write %s: %w", *_output, err)
+		}
+		fmt.Printf("changelog written to %s\n", *_output)
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate(now time.Time) error {
+	switch {
+	case c.Since == "" && c.From == "":
+		return errors.New("since or from is required")
+	case c.Since != "" && c.From != "":
+		return errors.New("since and from are mutually exclusive")
+	case c.Until != "" && c.Since == "":
+		return errors.New("until requires since")
+	case c.To != "" && c.From == "":
+		return errors.New("to requires from")
+	}
+
+	if c.Since != "" {
+		var err error
+		if c.since, err = time.ParseInLocation(_layout, c.Since, time.Local); err != nil {
+			return fmt.Errorf("invalid since %q, expected %s", c.Since, _layout)
+		}
+
+		if c.Until == "" {
+			c.Until = now.Format(_layout)
+		}
+		until, err := time.ParseInLocation(_layout, c.Until, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid until %q, expected %s", c.Until, _layout)
+		}
+		// until is included.
+		c.until = until.AddDate(0, 0, 1)
+
+		if !c.since.Before(c.until) {
+			return errors.New("since must not be after until")
+		}
+	}
+
+	return c.Validate()
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+// mergedBetweenDates returns the pull requests merged into base in
+// [since, until).
+func mergedBetweenDates(ctx context.Context, client *github.Client, owner, repo, base string, since, until time.Time) ([]pullRequest, error) {
+	return merged(ctx, client, owner, repo, base, since, func(pr *github.PullRequest) bool {
+		return !pr.GetMergedAt().Before(since) && pr.GetMergedAt().Before(until)
+	})
+}
+
+// mergedBetweenTags returns the pull requests whose merge commit is reachable
+// from to but not from from.
+func mergedBetweenTags(ctx context.Context, client *github.Client, owner, repo, from, to string) ([]pullRequest, error) {
+	commits, oldest, err := compare(ctx, client, owner, repo, from, to)
+	if err != nil || len(commits) == 0 {
+		return nil, err
+	}
+
+	// a pull request is updated when merged, so the ones updated before the
+	// oldest commit, with some slack for clock skew, are not in the range.
+	return merged(ctx, client, owner, repo, "", oldest.Add(-time.Hour), func(pr *github.PullRequest) bool {
+		return commits[pr.GetMergeCommitSHA()]
+	})
+}
+
+// compare returns the commits reachable from head but not from base, and the
+// date of the oldest one.
+func compare(ctx context.Context, client *github.Client, owner, repo, base, head string) (map[string]bool, time.Time, error) {
+	type comparison struct {
+		TotalCommits int `json:"total_commits"`
+		Commits      []struct {
+			SHA    string `json:"sha"`
+			Commit struct {
+				Committer struct {
+					Date time.Time `json:"date"`
+				} `json:"committer"`
+			} `json:"commit"`
+		} `json:"commits"`
+	}
+
+	commits := make(map[string]bool)
+	var oldest time.Time
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("repos/%s/%s/compare/%s...%s?per_page=100&page=%d", owner, repo, url.PathEscape(base), url.PathEscape(head), page)
+		var out comparison
+		if err := fleet.Send(ctx, client, http.MethodGet, path, nil, &out); err != nil {
+			return nil, time.Time{}, fmt.Errorf("unable to compare %s...%s: %w", base, head, err)
+		}
+
+		for _, v := range out.Commits {
+			commits[v.SHA] = true
+			if date := v.Commit.Committer.Date; oldest.IsZero() || date.Before(oldest) {
+				oldest = date
+			}
+		}
+
+		if len(out.Commits) == 0 || len(commits) >= out.TotalCommits {
+			return commits, oldest, nil
+		}
+	}
+}
+
+// merged returns the merged pull requests into base, any branch when empty,
+// updated after since that are kept by keep, sorted by merge date.
+func merged(ctx context.Context, client *github.Client, owner, repo, base string, since time.Time, keep func(*github.PullRequest) bool) ([]pullRequest, error) {
+	opt := &github.PullRequestListOptions{
+		State:       "closed",
+		Base:        base,
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var out []pullRequest
+	for {
+		pulls, resp, err := client.PullRequests.List(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list pull requests: %w", err)
+		}
+
+		done := false
+		for _, v := range pulls {
+			if v.GetUpdatedAt().Before(since) {
+				// sorted by update, the rest are older.
+				done = true
+				break
+			}
+
+			if v.MergedAt == nil || !keep(v) {
+				continue
+			}
+
+			labels := make([]string, 0, len(v.Labels))
+			for _, l := range v.Labels {
+				labels = append(labels, l.GetName())
+			}
+			out = append(out, pullRequest{
+				Number:   v.GetNumber(),
+				Title:    strings.TrimSpace(v.GetTitle()),
+				URL:      v.GetHTMLURL(),
+				Author:   v.GetUser().GetLogin(),
+				MergedAt: v.GetMergedAt(),
+				Labels:   labels,
+			})
+		}
+
+		if done || resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].MergedAt.Before(out[j].MergedAt)
+	})
+
+	return out, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// _template is the changelog rendered when the config has no template.
+const _template = `# Changelog {{if .From}}{{.From}}...{{or .To "HEAD"}}{{else}}{{.Since}} to {{.Until}}{{end}}
+{{range .Repositories}}{{if .PullRequests}}
+## [{{.Owner}}/{{.Name}}]({{.URL}})
+
+{{range .PullRequests}}- {{.Title}} ([#{{.Number}}]({{.URL}}) by @{{.Author}})
+{{end}}{{end}}{{end}}`
+
+// changelog is the data available to the template.
+type changelog struct {
+	Since, Until string // dates of the range, empty when it is by tags.
+	From, To     string // tags of the range, empty when it is by dates.
+	Repositories []repository
+}
+
+// repository is a destination with its merged pull requests, oldest first.
+type repository struct {
+	Owner        string
+	Name         string
+	URL          string
+	PullRequests []pullRequest
+}
+
+type pullRequest struct {
+	Number   int
+	Title    string
+	URL      string
+	Author   string
+	MergedAt time.Time
+	Labels   []string
+}
+
+// render executes text, or the default template when empty, with the
+// changelog.
+func (c changelog) render(text string) (string, error) {
+	if text == "" {
+		text = _template
+	}
+
+	t, err := template.New("changelog").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse template: %w", err)
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, c); err != nil {
+		return "", fmt.Errorf("unable to render changelog: %w", err)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,236 @@
+// Command code-scanning-alerts aggregates the open code scanning alerts of a
+// batch of repositories, for security review campaigns.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// _severities ranks the severities of the alerts, the most severe first: the
+// security severities of the security rules followed by the severities of the
+// other rules.
+var _severities = fleet.Ranking{"critical", "high", "medium", "low", "error", "warning", "note"}
+
+var (
+	_flags  *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_report *string      = flag.String("report", "", "Writes every alert to the given file, as CSV or, ending in .json, as JSON")
+)
+
+// config is the content of a config file: the destinations and the alerts
+// listed.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Severities []string `yaml:"severities"` // any of _severities, every severity when empty.
+	Tool       string   `yaml:"tool"`       // name of the tool of the alerts, for instance, CodeQL, every tool when empty.
+}
+
+// alert is an open code scanning alert, in the schema of the API.
+type alert struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Rule    struct {
+		ID                    string `json:"id"`
+		Severity              string `json:"severity"`
+		SecuritySeverityLevel string `json:"security_severity_level"`
+		Description           string `json:"description"`
+	} `json:"rule"`
+	Tool struct {
+		Name string `json:"name"`
+	} `json:"tool"`
+	MostRecentInstance struct {
+		Ref      string `json:"ref"`
+		Location struct {
+			Path      string `json:"path"`
+			StartLine int    `json:"start_line"`
+		} `json:"location"`
+	} `json:"most_recent_instance"`
+
+	repository string
+}
+
+// severity returns the security severity of the alert, or the severity of its
+// rule when it is not a security one.
+func (a alert) severity() string {
+	if a.Rule.SecuritySeverityLevel != "" {
+		return a.Rule.SecuritySeverityLevel
+	}
+
+	return a.Rule.Severity
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	query := url.Values{"state": {"open"}, "per_page": {"100"}}
+	if c.Tool != "" {
+		query.Set("tool_name", c.Tool)
+	}
+
+	var alerts []alert
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		counts := make(map[string]int)
+		path := "repos/" + owner + "/" + d.Repository + "/code-scanning/alerts?" + query.Encode()
+		err := fleet.SendAll(ctx, client, path, func(item json.RawMessage) error {
+			var a alert
+			if err := json.Unmarshal(item, &a); err != nil {
+				return err
+			}
+			if !c.selected(a.severity()) {
+				return nil
+			}
+
+			a.repository = owner + "/" + d.Repository
+			alerts = append(alerts, a)
+			counts[a.severity()]++
+			return nil
+		})
+		switch {
+		case fleet.IsNotFound(err):
+			// repositories without analyses.
+			return "code scanning not set up", nil
+		case err != nil:
+			return "", fmt.Errorf("unable to list alerts: %w", err)
+		}
+
+		return _severities.Summary(counts), nil
+	})
+
+	printRules(alerts)
+	if err := fleet.WriteTable(*_report, table(alerts)); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() error {
+	if err := _severities.Check("severity", c.Severities); err != nil {
+		return err
+	}
+
+	return c.Validate()
+}
+
+// selected reports whether the alerts of the severity are listed.
+func (c config) selected(severity string) bool {
+	if len(c.Severities) == 0 {
+		return true
+	}
+
+	for _, v := range c.Severities {
+		if v == severity {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rule is the alerts of a rule.
+type rule struct {
+	tool, id, severity, description string
+	alerts                          int
+	repositories                    map[string]bool
+}
+
+// printRules prints the alerts grouped by rule, the most severe and
+// widespread first.
+func printRules(alerts []alert) {
+	rules := make(map[string]*rule)
+	for _, v := range alerts {
+		key := v.Tool.Name + " " + v.Rule.ID
+		r := rules[key]
+		if r == nil {
+			r = &rule{tool: v.Tool.Name, id: v.Rule.ID, severity: v.severity(), description: v.Rule.Description, repositories: make(map[string]bool)}
+			rules[key] = r
+		}
+
+		r.alerts++
+		r.repositories[v.repository] = true
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	sorted := make([]*rule, 0, len(rules))
+	for _, v := range rules {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch {
+		case _severities.Rank(a.severity) != _severities.Rank(b.severity):
+			return _severities.Rank(a.severity) < _severities.Rank(b.severity)
+		case len(a.repositories) != len(b.repositories):
+			return len(a.repositories) > len(b.repositories)
+		default:
+			return a.tool+a.id < b.tool+b.id
+		}
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "rule\tseverity\talerts\trepositories\tdescription")
+	for _, v := range sorted {
+		fmt.Fprintf(w, "%s/%s\t%s\t%d\t%d\t%s\n", v.tool, v.id, v.severity, v.alerts, len(v.repositories), v.description)
+	}
+	w.Flush()
+}
+
+func table(alerts []alert) fleet.Table {
+	t := fleet.Table{Header: []string{"repository", "number", "tool", "rule", "severity", "description", "path", "line", "ref", "url"}}
+	for _, v := range alerts {
+		t.Add(
+			v.repository,
+			strconv.Itoa(v.Number),
+			v.Tool.Name,
+			v.Rule.ID,
+			v.severity(),
+			v.Rule.Description,
+			v.MostRecentInstance.Location.Path,
+			strconv.Itoa(v.MostRecentInstance.Location.StartLine),
+			v.MostRecentInstance.Ref,
+			v.HTMLURL,
+		)
+	}
+
+	return t
+}
@@ -0,0 +1,236 @@
+// Command dependabot-alerts lists the open Dependabot alerts of a batch of
+// repositories, grouped by package and severity, as a fleet view of the
+// vulnerable dependencies.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// _severities ranks the severities of the advisories, the most severe first.
+var _severities = fleet.Ranking{"critical", "high", "medium", "low"}
+
+var (
+	_flags  *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_report *string      = flag.String("report", "", "Writes every alert to the given file, as CSV or, ending in .json, as JSON")
+)
+
+// config is the content of a config file: the destinations and the alerts
+// listed.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Severities []string `yaml:"severities"` // critical, high, medium or low, every severity when empty.
+	Ecosystems []string `yaml:"ecosystems"` // for instance, go or npm, every ecosystem when empty.
+}
+
+// alert is an open Dependabot alert, in the schema of the API.
+type alert struct {
+	Number     int    `json:"number"`
+	HTMLURL    string `json:"html_url"`
+	Dependency struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		ManifestPath string `json:"manifest_path"`
+	} `json:"dependency"`
+	SecurityAdvisory struct {
+		GHSAID   string `json:"ghsa_id"`
+		Summary  string `json:"summary"`
+		Severity string `json:"severity"`
+	} `json:"security_advisory"`
+	SecurityVulnerability struct {
+		VulnerableVersionRange string `json:"vulnerable_version_range"`
+		FirstPatchedVersion    *struct {
+			Identifier string `json:"identifier"`
+		} `json:"first_patched_version"`
+	} `json:"security_vulnerability"`
+
+	repository string
+}
+
+func (a alert) pkg() string {
+	return a.Dependency.Package.Ecosystem + "/" + a.Dependency.Package.Name
+}
+
+func (a alert) patched() string {
+	if v := a.SecurityVulnerability.FirstPatchedVersion; v != nil {
+		return v.Identifier
+	}
+
+	return ""
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	query := url.Values{"state": {"open"}, "per_page": {"100"}}
+	if len(c.Severities) > 0 {
+		query.Set("severity", strings.Join(c.Severities, ","))
+	}
+	if len(c.Ecosystems) > 0 {
+		query.Set("ecosystem", strings.Join(c.Ecosystems, ","))
+	}
+
+	var alerts []alert
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		counts := make(map[string]int)
+		path := "repos/" + owner + "/" + d.Repository + "/dependabot/alerts?" + query.Encode()
+		err := fleet.SendAll(ctx, client, path, func(item json.RawMessage) error {
+			var a alert
+			if err := json.Unmarshal(item, &a); err != nil {
+				return err
+			}
+
+			a.repository = owner + "/" + d.Repository
+			alerts = append(alerts, a)
+			counts[a.SecurityAdvisory.Severity]++
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("unable to list alerts: %w", err)
+		}
+
+		return _severities.Summary(counts), nil
+	})
+
+	printPackages(alerts)
+	if err := fleet.WriteTable(*_report, table(alerts)); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() error {
+	if err := _severities.Check("severity", c.Severities); err != nil {
+		return err
+	}
+
+	return c.Validate()
+}
+
+// group is the alerts of a package with the same severity.
+type group struct {
+	pkg          string
+	severity     string
+	alerts       int
+	repositories map[string]bool
+	patched      map[string]bool // first patched versions of the advisories.
+}
+
+// printPackages prints the alerts grouped by package and severity, the most
+// severe and widespread first.
+func printPackages(alerts []alert) {
+	groups := make(map[string]*group)
+	for _, v := range alerts {
+		key := v.pkg() + " " + v.SecurityAdvisory.Severity
+		g := groups[key]
+		if g == nil {
+			g = &group{pkg: v.pkg(), severity: v.SecurityAdvisory.Severity, repositories: make(map[string]bool), patched: make(map[string]bool)}
+			groups[key] = g
+		}
+
+		g.alerts++
+		g.repositories[v.repository] = true
+		if p := v.patched(); p != "" {
+			g.patched[p] = true
+		}
+	}
+	if len(groups) == 0 {
+		return
+	}
+
+	sorted := make([]*group, 0, len(groups))
+	for _, v := range groups {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch {
+		case _severities.Rank(a.severity) != _severities.Rank(b.severity):
+			return _severities.Rank(a.severity) < _severities.Rank(b.severity)
+		case len(a.repositories) != len(b.repositories):
+			return len(a.repositories) > len(b.repositories)
+		default:
+			return a.pkg < b.pkg
+		}
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "package\tseverity\talerts\trepositories\tpatched in")
+	for _, v := range sorted {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", v.pkg, v.severity, v.alerts, len(v.repositories), strings.Join(keys(v.patched), ", "))
+	}
+	w.Flush()
+}
+
+func table(alerts []alert) fleet.Table {
+	t := fleet.Table{Header: []string{"repository", "number", "package", "manifest", "severity", "advisory", "summary", "vulnerable", "patched", "url"}}
+	for _, v := range alerts {
+		t.Add(
+			v.repository,
+			strconv.Itoa(v.Number),
+			v.pkg(),
+			v.Dependency.ManifestPath,
+			v.SecurityAdvisory.Severity,
+			v.SecurityAdvisory.GHSAID,
+			v.SecurityAdvisory.Summary,
+			v.SecurityVulnerability.VulnerableVersionRange,
+			v.patched(),
+			v.HTMLURL,
+		)
+	}
+
+	return t
+}
+
+func keys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+
+	return out
+}
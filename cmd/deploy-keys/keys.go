@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// deployKey is a deploy key to install. GitHub does not accept the same key on
+// several repositories, so KeyFile is rendered for each destination, for
+// instance, "keys/{{ .Repository }}.pub".
+type deployKey struct {
+	Title    string `yaml:"title"`
+	KeyFile  string `yaml:"key_file"`  // public key, in the authorized_keys format.
+	ReadOnly *bool  `yaml:"read_only"` // true by default.
+
+	// Replace removes the other keys with the same title once the key is
+	// installed, rotating them.
+	Replace bool `yaml:"replace"`
+}
+
+// repository manages the deploy keys of a repository.
+type repository struct {
+	client *github.Client
+	owner  string
+	name   string
+	dryRun bool
+}
+
+func (r repository) keys(ctx context.Context) ([]*github.Key, error) {
+	var keys []*github.Key
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := r.client.Repositories.ListKeys(ctx, r.owner, r.name, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list deploy keys: %w", err)
+		}
+		keys = append(keys, page...)
+
+		if resp.NextPage == 0 {
+			return keys, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// install adds the key unless the repository has it already, removing the
+// other keys of the same title when it replaces them.
+func (r repository) install(ctx context.Context, k deployKey, data mkpr.TemplateData, keys []*github.Key) ([]string, error) {
+	path, err := mkpr.Render("key_file", k.KeyFile, data)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	public := strings.TrimSpace(string(content))
+	want, err := fingerprint(public)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %s: %w", path, err)
+	}
+
+	var (
+		changes   []string
+		installed bool
+		replaced  []*github.Key
+	)
+	for _, v := range keys {
+		fp, _ := fingerprint(v.GetKey())
+		switch {
+		case fp == want:
+			installed = true
+		case k.Replace && v.GetTitle() == k.Title:
+			replaced = append(replaced, v)
+		}
+	}
+
+	if !installed {
+		changes = append(changes, "install "+k.Title+" "+want)
+		if !r.dryRun {
+			readOnly := k.ReadOnly == nil || *k.ReadOnly
+			if _, _, err := r.client.Repositories.CreateKey(ctx, r.owner, r.name, &github.Key{Title: &k.Title, Key: &public, ReadOnly: &readOnly}); err != nil {
+				return changes, fmt.Errorf("unable to install deploy key %s: %w", k.Title, err)
+			}
+		}
+	}
+
+	for _, v := range replaced {
+		change, err := r.delete(ctx, v)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// remove deletes the keys with any of the fingerprints.
+func (r repository) remove(ctx context.Context, fingerprints []string, keys []*github.Key) ([]string, error) {
+	remove := make(map[string]bool, len(fingerprints))
+	for _, v := range fingerprints {
+		remove[v] = true
+	}
+
+	var changes []string
+	for _, v := range keys {
+		if fp, _ := fingerprint(v.GetKey()); !remove[fp] {
+			continue
+		}
+
+		change, err := r.delete(ctx, v)
+		if err != nil {
+			return changes, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func (r repository) delete(ctx context.Context, k *github.Key) (string, error) {
+	fp, _ := fingerprint(k.GetKey())
+	if !r.dryRun {
+		if _, err := r.client.Repositories.DeleteKey(ctx, r.owner, r.name, k.GetID()); err != nil {
+			return "", fmt.Errorf("unable to remove deploy key %s: %w", k.GetTitle(), err)
+		}
+	}
+
+	return "remove " + k.GetTitle() + " " + fp, nil
+}
+
+// fingerprint returns the SHA256 fingerprint of a public key in the
+// authorized_keys format, as printed by ssh-keygen -l.
+func fingerprint(key string) (string, error) {
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("not a public key")
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// describe lists the keys with their fingerprints and access.
+func describe(keys []*github.Key) string {
+	if len(keys) == 0 {
+		return "no deploy keys"
+	}
+
+	list := make([]string, 0, len(keys))
+	for _, v := range keys {
+		fp, _ := fingerprint(v.GetKey())
+		access := "read-write"
+		if v.GetReadOnly() {
+			access = "read-only"
+		}
+		list = append(list, fmt.Sprintf("%s %s (%s)", v.GetTitle(), fp, access))
+	}
+
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,113 @@
+// Command deploy-keys installs, rotates, lists or removes the deploy keys of a
+// batch of repositories.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var (
+	_flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_list  *bool        = flag.Bool("list", false, "Lists the deploy keys of the destinations, with their fingerprints, without changing them")
+)
+
+// config is the content of a config file: the destinations and the changes
+// to their deploy keys.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Install []deployKey `yaml:"install"`
+
+	// RemoveFingerprints are the SHA256 fingerprints of the keys to remove,
+	// for instance, the leaked or rotated ones.
+	RemoveFingerprints []string `yaml:"remove_fingerprints"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		r := repository{client: client, owner: owner, name: d.Repository, dryRun: _flags.DryRun}
+		keys, err := r.keys(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		if *_list {
+			return describe(keys), nil
+		}
+
+		var changes []string
+		for _, v := range c.Install {
+			change, err := r.install(ctx, v, c.Data(d), keys)
+			if err != nil {
+				return "", err
+			}
+			changes = append(changes, change...)
+		}
+
+		change, err := r.remove(ctx, c.RemoveFingerprints, keys)
+		if err != nil {
+			return "", err
+		}
+
+		return fleet.Summary(append(changes, change...), _flags.DryRun), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() error {
+	if !*_list && len(c.Install) == 0 && len(c.RemoveFingerprints) == 0 {
+		return errors.New("install or remove_fingerprints are required, or -list")
+	}
+
+	for _, v := range c.Install {
+		if v.Title == "" || v.KeyFile == "" {
+			return errors.New("deploy keys to install need a title and a key_file")
+		}
+	}
+
+	for _, v := range c.RemoveFingerprints {
+		if !strings.HasPrefix(v, "SHA256:") {
+			return fmt.Errorf("invalid fingerprint %q, SHA256 fingerprints are expected (ssh-keygen -lf key.pub)", v)
+		}
+	}
+
+	return c.Validate()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+// _poll is the interval between the checks of the statuses of the created
+// deployments.
+const _poll = 15 * time.Second
+
+// request is the deployment as created through the API, which takes the
+// payload as an object rather than the string of the client.
+type request struct {
+	Ref                   string                 `json:"ref"`
+	Task                  string                 `json:"task"`
+	Environment           string                 `json:"environment"`
+	Description           string                 `json:"description,omitempty"`
+	Payload               map[string]interface{} `json:"payload,omitempty"`
+	AutoMerge             bool                   `json:"auto_merge"`
+	RequiredContexts      *[]string              `json:"required_contexts,omitempty"`
+	ProductionEnvironment *bool                  `json:"production_environment,omitempty"`
+	TransientEnvironment  *bool                  `json:"transient_environment,omitempty"`
+}
+
+func (d deployment) request(ref, description string) request {
+	return request{
+		Ref:                   ref,
+		Task:                  d.Task,
+		Environment:           d.Environment,
+		Description:           description,
+		Payload:               d.Payload,
+		AutoMerge:             d.AutoMerge,
+		RequiredContexts:      d.RequiredContexts,
+		ProductionEnvironment: d.ProductionEnvironment,
+		TransientEnvironment:  d.TransientEnvironment,
+	}
+}
+
+// create creates the deployment, returning its ID.
+func create(ctx context.Context, client *github.Client, owner, repo string, r request) (int64, error) {
+	var out struct {
+		ID      int64  `json:"id"`
+		Message string `json:"message"`
+	}
+	if err := fleet.Send(ctx, client, http.MethodPost, "repos/"+owner+"/"+repo+"/deployments", r, &out); err != nil {
+		return 0, fmt.Errorf("unable to create deployment: %w", err)
+	}
+
+	// the API answers 202 with a message, instead of the deployment, when it
+	// merged the default branch into ref.
+	if out.ID == 0 {
+		return 0, fmt.Errorf("deployment not created: %s", out.Message)
+	}
+
+	return out.ID, nil
+}
+
+// state returns the state of the latest status of the deployment, pending
+// when it has none yet.
+func state(ctx context.Context, client *github.Client, owner, repo string, id int64) (*github.DeploymentStatus, error) {
+	statuses, _, err := client.Repositories.ListDeploymentStatuses(ctx, owner, repo, id, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list deployment statuses: %w", err)
+	}
+	if len(statuses) == 0 {
+		return &github.DeploymentStatus{State: github.String("pending")}, nil
+	}
+
+	return statuses[0], nil
+}
+
+// describe returns the state of the status with its description and target
+// URL, if any.
+func describe(s *github.DeploymentStatus) string {
+	out := s.GetState()
+	if v := s.GetDescription(); v != "" {
+		out += ": " + v
+	}
+	if v := s.GetTargetURL(); v != "" {
+		out += " (" + v + ")"
+	}
+
+	return out
+}
+
+// latest describes the latest deployment to the environment.
+func latest(ctx context.Context, client *github.Client, owner, repo, environment string) (string, error) {
+	deployments, _, err := client.Repositories.ListDeployments(ctx, owner, repo, &github.DeploymentsListOptions{
+		Environment: environment,
+		ListOptions: github.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to list deployments: %w", err)
+	}
+	if len(deployments) == 0 {
+		return "never deployed to " + environment, nil
+	}
+
+	d := deployments[0]
+	s, err := state(ctx, client, owner, repo, d.GetID())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("deployment %d of %s, %s ago, %s", d.GetID(), d.GetRef(), time.Since(d.GetCreatedAt().Time).Round(time.Minute), describe(s)), nil
+}
+
+// finished reports whether the state of a deployment is a final one.
+func finished(state string) bool {
+	switch state {
+	case "success", "failure", "error", "inactive":
+		return true
+	default:
+		return false
+	}
+}
+
+// await polls the statuses of the deployments, by repository, until all of
+// them finish or the timeout expires, returning the last state of each one.
+func await(ctx context.Context, client *github.Client, owner string, deployments map[string]int64, timeout time.Duration) map[string]string {
+	states := make(map[string]string, len(deployments))
+	done := make(map[string]bool, len(deployments))
+	deadline := time.Now().Add(timeout)
+	for {
+		pending := 0
+		for repo, id := range deployments {
+			if done[repo] {
+				continue
+			}
+
+			s, err := state(ctx, client, owner, repo, id)
+			if err != nil {
+				states[repo] = err.Error()
+				pending++
+				continue
+			}
+
+			states[repo] = describe(s)
+			if done[repo] = finished(s.GetState()); !done[repo] {
+				pending++
+			}
+		}
+
+		if pending == 0 || time.Now().Add(_poll).After(deadline) {
+			return states
+		}
+		time.Sleep(_poll)
+	}
+}
@@ -0,0 +1,163 @@
+// Command deployments creates the same GitHub deployment in a batch of
+// repositories and reports their statuses, for the organizations deploying
+// through the Deployments API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var (
+	_flags  *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_status *bool        = flag.Bool("status", false, "Reports the status of the latest deployment of the environment instead of creating one")
+	_wait   *string      = flag.String("wait", "", "Waits up to the given duration, for instance 10m, for the created deployments to finish")
+)
+
+// config is the content of a config file: the destinations and the deployment
+// to create in them.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Deployment deployment `yaml:"deployment"`
+}
+
+// deployment is the deployment created in every destination.
+type deployment struct {
+	// Ref to deploy, a branch, tag or SHA, overridden by the base of the
+	// destinations, the default branch when both are empty.
+	Ref         string `yaml:"ref"`
+	Environment string `yaml:"environment"` // production by default.
+	Task        string `yaml:"task"`        // deploy by default.
+
+	// Description is rendered as text/template for each destination, see
+	// mkpr.TemplateData.
+	Description string `yaml:"description"`
+
+	// Payload is extra information for the deployment systems, sent as JSON.
+	Payload map[string]interface{} `yaml:"payload"`
+
+	// AutoMerge merges the default branch into ref before deploying, false
+	// by default unlike the API.
+	AutoMerge bool `yaml:"auto_merge"`
+
+	// RequiredContexts are the statuses verified before deploying, every one
+	// when nil, none when empty.
+	RequiredContexts *[]string `yaml:"required_contexts"`
+
+	ProductionEnvironment *bool `yaml:"production_environment"`
+	TransientEnvironment  *bool `yaml:"transient_environment"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	var wait time.Duration
+	if *_wait != "" {
+		var err error
+		if wait, err = time.ParseDuration(*_wait); err != nil {
+			return fmt.Errorf("invalid wait: %w", err)
+		}
+		if *_status || _flags.DryRun {
+			return errors.New("-wait only applies to the created deployments")
+		}
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	created := make(map[string]int64) // deployments by repository.
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		if *_status {
+			return latest(ctx, client, owner, d.Repository, c.Deployment.Environment)
+		}
+
+		ref := c.Deployment.Ref
+		if d.Base != "" {
+			ref = d.Base
+		}
+		if ref == "" {
+			repository, _, err := client.Repositories.Get(ctx, owner, d.Repository)
+			if err != nil {
+				return "", fmt.Errorf("unable to get repository: %w", err)
+			}
+			ref = repository.GetDefaultBranch()
+		}
+
+		description, err := mkpr.Render("description", c.Deployment.Description, c.Data(d))
+		if err != nil {
+			return "", err
+		}
+
+		if _flags.DryRun {
+			return fmt.Sprintf("would deploy %s to %s", ref, c.Deployment.Environment), nil
+		}
+
+		id, err := create(ctx, client, owner, d.Repository, c.Deployment.request(ref, description))
+		if err != nil {
+			return "", err
+		}
+
+		created[d.Repository] = id
+		return fmt.Sprintf("deployment %d of %s to %s created", id, ref, c.Deployment.Environment), nil
+	})
+
+	if wait > 0 && len(created) > 0 {
+		fmt.Printf("waiting up to %s for %d deployments ...\n", wait, len(created))
+		states := await(context.Background(), client, owner, created, wait)
+
+		repositories := make([]string, 0, len(states))
+		for k := range states {
+			repositories = append(repositories, k)
+		}
+		sort.Strings(repositories)
+		for _, v := range repositories {
+			fmt.Printf("%s/%s: %s\n", owner, v, states[v])
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if c.Deployment.Environment == "" {
+		c.Deployment.Environment = "production"
+	}
+	if c.Deployment.Task == "" {
+		c.Deployment.Task = "deploy"
+	}
+
+	return c.Validate()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// _context is the number of unchanged lines around the changes of a diff.
+const _context = 3
+
+// _maxDiffLines bounds the lines of the files diffed, as the diff takes their
+// product in memory.
+const _maxDiffLines = 5000
+
+// edit is a line of a diff: ' ' kept, '-' removed or '+' added.
+type edit struct {
+	op   byte
+	line string
+}
+
+// unifiedDiff returns the unified diff turning a into b, empty when they have
+// the same lines.
+func unifiedDiff(nameA, nameB, a, b string) string {
+	linesA, linesB := splitLines(a), splitLines(b)
+	if len(linesA) > _maxDiffLines || len(linesB) > _maxDiffLines {
+		return fmt.Sprintf("--- %s\n+++ %s\n(too large to diff, %d and %d lines)\n", nameA, nameB, len(linesA), len(linesB))
+	}
+
+	edits := diffLines(linesA, linesB)
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// the next change, and the hunk around it.
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		from := start - _context
+		if from < 0 {
+			from = 0
+		}
+
+		end, kept := start, 0
+		for end < len(edits) && kept <= 2*_context {
+			if edits[end].op == ' ' {
+				kept++
+			} else {
+				kept = 0
+			}
+			end++
+		}
+		if kept > _context {
+			end -= kept - _context
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		writeHunk(&out, edits, from, end)
+		start = end
+	}
+
+	return out.String()
+}
+
+// writeHunk writes the edits from start to end as a hunk.
+func writeHunk(out *strings.Builder, edits []edit, start, end int) {
+	// line numbers of the hunk start, counting the edits before it.
+	lineA, lineB := 1, 1
+	for _, v := range edits[:start] {
+		if v.op != '+' {
+			lineA++
+		}
+		if v.op != '-' {
+			lineB++
+		}
+	}
+
+	var countA, countB int
+	for _, v := range edits[start:end] {
+		if v.op != '+' {
+			countA++
+		}
+		if v.op != '-' {
+			countB++
+		}
+	}
+
+	// empty ranges start at the line before them.
+	if countA == 0 {
+		lineA--
+	}
+	if countB == 0 {
+		lineB--
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", lineA, countA, lineB, countB)
+	for _, v := range edits[start:end] {
+		out.WriteByte(v.op)
+		out.WriteString(v.line)
+		out.WriteByte('\n')
+	}
+}
+
+// diffLines returns the edits turning a into b, from their longest common
+// subsequence.
+func diffLines(a, b []string) []edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{'+', b[j]})
+	}
+
+	return edits
+}
+
+// splitLines returns the lines of s, without the last empty one.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,180 @@
+// Command file-audit compares canonical files with their counterparts in a
+// batch of repositories, reporting the ones missing or diverged, to decide
+// which repositories need a new mkpr batch.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// statuses of a file in a destination.
+const (
+	_identical = "identical"
+	_missing   = "missing"
+	_diverged  = "diverged"
+)
+
+var (
+	_flags  *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_report *string      = flag.String("report", "", "Writes the status of every file to the given file, as CSV or, ending in .json, as JSON")
+	_quiet  *bool        = flag.Bool("quiet", false, "Leaves the diffs of the diverged files out")
+)
+
+// config is the content of a config file: the destinations and the canonical
+// files.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Files  []file `yaml:"files"`
+	Render bool   `yaml:"render"` // renders the files as text/template for each destination, see mkpr.TemplateData.
+}
+
+// file is a canonical file and its location in the destinations, as in the
+// files of mkpr.
+type file struct {
+	Source string `yaml:"source"` // path of the local file.
+	Target string `yaml:"target"` // path in the destinations, defaults to Source.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	canonical := make(map[string]string, len(c.Files))
+	for _, v := range c.Files {
+		content, err := os.ReadFile(v.Source)
+		if err != nil {
+			return err
+		}
+		canonical[v.Source] = string(content)
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	report := fleet.Table{Header: []string{"repository", "file", "status"}}
+	counts := make(map[string]int)
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		statuses := make(map[string][]string)
+		var diffs strings.Builder
+		for _, v := range c.Files {
+			want := canonical[v.Source]
+			if c.Render {
+				rendered, err := mkpr.Render(v.Source, want, c.Data(d))
+				if err != nil {
+					return "", err
+				}
+				want = rendered
+			}
+
+			current, found, err := getFile(ctx, client, owner, d, v.Target)
+			if err != nil {
+				return "", err
+			}
+
+			status := _identical
+			switch {
+			case !found:
+				status = _missing
+			case current != want:
+				status = _diverged
+				if !*_quiet {
+					diffs.WriteString(unifiedDiff(d.Repository+"/"+v.Target, v.Source, current, want))
+				}
+			}
+
+			statuses[status] = append(statuses[status], v.Target)
+			counts[status]++
+			report.Add(owner+"/"+d.Repository, v.Target, status)
+		}
+
+		var parts []string
+		for _, v := range []string{_identical, _missing, _diverged} {
+			if len(statuses[v]) > 0 {
+				parts = append(parts, v+" "+strings.Join(statuses[v], ", "))
+			}
+		}
+
+		summary := strings.Join(parts, ", ")
+		if diffs.Len() > 0 {
+			summary += "\n" + strings.TrimSuffix(diffs.String(), "\n")
+		}
+
+		return summary, nil
+	})
+
+	fmt.Printf("%d identical, %d missing and %d diverged files.\n", counts[_identical], counts[_missing], counts[_diverged])
+	if err := fleet.WriteTable(*_report, report); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if len(c.Files) == 0 {
+		return errors.New("files are required")
+	}
+
+	for i, v := range c.Files {
+		if v.Source == "" {
+			return errors.New("files need a source")
+		}
+		if v.Target == "" {
+			c.Files[i].Target = v.Source
+		}
+	}
+
+	return c.Validate()
+}
+
+// getFile returns the content of the file of the destination, reporting
+// whether it exists.
+func getFile(ctx context.Context, client *github.Client, owner string, d mkpr.Destination, path string) (string, bool, error) {
+	content, _, _, err := client.Repositories.GetContents(ctx, owner, d.Repository, path, &github.RepositoryContentGetOptions{Ref: d.Base})
+	if fleet.IsNotFound(err) || (err == nil && content == nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("unable to get %s: %w", path, err)
+	}
+
+	text, err := content.GetContent()
+	if err != nil {
+		return "", false, fmt.Errorf("unable to decode %s: %w", path, err)
+	}
+
+	return text, true, nil
+}
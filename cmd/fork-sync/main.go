@@ -0,0 +1,93 @@
+// Command fork-sync fast-forwards the branches of a batch of forks to the ones
+// of their upstream repositories.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the forks to sync and which of
+// their branches.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	// AllBranches syncs every branch of the fork that also exists upstream,
+	// instead of only the default branch, or the base of the destination.
+	AllBranches bool `yaml:"all_branches"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		f, err := newFork(ctx, client, owner, d.Repository)
+		if err != nil {
+			return "", err
+		}
+
+		branches := []string{d.Base}
+		if c.AllBranches {
+			if branches, err = f.branches(ctx); err != nil {
+				return "", err
+			}
+		} else if d.Base == "" {
+			branches = []string{f.repo.GetDefaultBranch()}
+		}
+
+		results := make(map[string][]string)
+		for _, v := range branches {
+			result, err := f.sync(ctx, v, _flags.DryRun)
+			if err != nil {
+				return "", err
+			}
+			results[result] = append(results[result], v)
+		}
+
+		var parts []string
+		for _, v := range []string{_upToDate, _fastForwarded, _wouldFastForward, _ahead, _diverged} {
+			if len(results[v]) > 0 {
+				parts = append(parts, v+" "+strings.Join(results[v], ", "))
+			}
+		}
+
+		return f.repo.GetParent().GetFullName() + ": " + strings.Join(parts, ", "), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+// results of syncing a branch.
+const (
+	_upToDate         = "up to date"
+	_fastForwarded    = "fast-forwarded"
+	_wouldFastForward = "would fast-forward"
+	_ahead            = "ahead of upstream"
+	_diverged         = "diverged from upstream"
+)
+
+// fork syncs the branches of a fork with the ones of its parent. Forks share
+// the objects of their network, so the branches are fast-forwarded by moving
+// their refs to the commits of the parent.
+type fork struct {
+	client *github.Client
+	owner  string
+	repo   *github.Repository
+}
+
+func newFork(ctx context.Context, client *github.Client, owner, name string) (*fork, error) {
+	repo, _, err := client.Repositories.Get(ctx, owner, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get repository: %w", err)
+	}
+	if !repo.GetFork() || repo.Parent == nil {
+		return nil, fmt.Errorf("%s/%s is not a fork", owner, name)
+	}
+
+	return &fork{client: client, owner: owner, repo: repo}, nil
+}
+
+// branches returns the branches of the fork that also exist in the parent.
+func (f *fork) branches(ctx context.Context) ([]string, error) {
+	parent, err := f.list(ctx, f.repo.GetParent().GetOwner().GetLogin(), f.repo.GetParent().GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	own, err := f.list(ctx, f.owner, f.repo.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	upstream := make(map[string]bool, len(parent))
+	for _, v := range parent {
+		upstream[v] = true
+	}
+
+	var out []string
+	for _, v := range own {
+		if upstream[v] {
+			out = append(out, v)
+		}
+	}
+
+	return out, nil
+}
+
+// list returns the names of the branches of a repository.
+func (f *fork) list(ctx context.Context, owner, repo string) ([]string, error) {
+	var out []string
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		branches, resp, err := f.client.Repositories.ListBranches(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the branches of %s/%s: %w", owner, repo, err)
+		}
+
+		for _, v := range branches {
+			out = append(out, v.GetName())
+		}
+
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// sync fast-forwards the branch to the one of the parent, when possible,
+// returning the result.
+func (f *fork) sync(ctx context.Context, branch string, dryRun bool) (string, error) {
+	parent := f.repo.GetParent()
+	upstream, _, err := f.client.Repositories.GetBranch(ctx, parent.GetOwner().GetLogin(), parent.GetName(), branch)
+	if err != nil {
+		return "", fmt.Errorf("unable to get %s of %s: %w", branch, parent.GetFullName(), err)
+	}
+
+	ref, _, err := f.client.Git.GetRef(ctx, f.owner, f.repo.GetName(), "heads/"+branch)
+	if err != nil {
+		if fleet.IsNotFound(err) {
+			return "", fmt.Errorf("branch %s not found", branch)
+		}
+		return "", fmt.Errorf("unable to get branch %s: %w", branch, err)
+	}
+
+	want := upstream.GetCommit().GetSHA()
+	comparison, _, err := f.client.Repositories.CompareCommits(ctx, f.owner, f.repo.GetName(), ref.GetObject().GetSHA(), want)
+	if err != nil {
+		return "", fmt.Errorf("unable to compare %s with upstream: %w", branch, err)
+	}
+
+	switch comparison.GetStatus() {
+	case "identical":
+		return _upToDate, nil
+	case "behind":
+		return _ahead, nil
+	case "diverged":
+		return _diverged, nil
+	}
+
+	if dryRun {
+		return _wouldFastForward, nil
+	}
+
+	ref.Object.SHA = &want
+	if _, _, err := f.client.Git.UpdateRef(ctx, f.owner, f.repo.GetName(), ref, false); err != nil {
+		return "", fmt.Errorf("unable to fast-forward %s: %w", branch, err)
+	}
+
+	return _fastForwarded, nil
+}
@@ -0,0 +1,231 @@
+// Command gomod-audit inventories the module versions required by the go.mod
+// files of a batch of repositories, flagging the ones below a minimum version.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+var (
+	_flags  *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_report *string      = flag.String("report", "", "Writes every required module to the given file, as CSV or, ending in .json, as JSON")
+)
+
+// config is the content of a config file: the destinations, their go.mod
+// files and the modules to inventory.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Paths    []string          `yaml:"paths"`    // go.mod files of the destinations, "go.mod" by default.
+	Modules  []string          `yaml:"modules"`  // module paths to inventory, in path.Match syntax, every module when empty.
+	Minimums map[string]string `yaml:"minimums"` // minimum version of the modules, the ones below are reported as outdated.
+	Indirect bool              `yaml:"indirect"` // includes the indirect requirements.
+}
+
+// requirement is a module required by a go.mod file of a destination.
+type requirement struct {
+	repository string
+	file       string
+	module     string
+	version    string
+	indirect   bool
+	outdated   bool
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	var inventory []requirement
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		var found, outdated []string
+		for _, file := range c.Paths {
+			requirements, err := c.requirements(ctx, client, owner, d, file)
+			if err != nil {
+				return "", err
+			}
+			if requirements == nil {
+				continue
+			}
+
+			found = append(found, file)
+			for _, v := range requirements {
+				if v.outdated {
+					outdated = append(outdated, fmt.Sprintf("%s %s < %s", v.module, v.version, c.Minimums[v.module]))
+				}
+			}
+			inventory = append(inventory, requirements...)
+		}
+
+		switch {
+		case len(found) == 0:
+			return "no go.mod", nil
+		case len(outdated) == 0:
+			return "up to date", nil
+		default:
+			return "outdated " + strings.Join(outdated, ", "), nil
+		}
+	})
+
+	printInventory(inventory)
+	if err := writeReport(inventory); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if len(c.Paths) == 0 {
+		c.Paths = []string{"go.mod"}
+	}
+
+	for _, v := range c.Modules {
+		if _, err := path.Match(v, ""); err != nil {
+			return fmt.Errorf("invalid module pattern %q: %w", v, err)
+		}
+	}
+
+	for k, v := range c.Minimums {
+		if !semver.IsValid(v) {
+			return fmt.Errorf("invalid minimum version %q of %s", v, k)
+		}
+	}
+
+	return c.Validate()
+}
+
+// requirements returns the requirements of the go.mod file of the destination
+// selected by the config, nil when the file does not exist.
+func (c config) requirements(ctx context.Context, client *github.Client, owner string, d mkpr.Destination, file string) ([]requirement, error) {
+	content, _, _, err := client.Repositories.GetContents(ctx, owner, d.Repository, file, &github.RepositoryContentGetOptions{Ref: d.Base})
+	if fleet.IsNotFound(err) || (err == nil && content == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s: %w", file, err)
+	}
+
+	text, err := content.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", file, err)
+	}
+
+	mod, err := modfile.ParseLax(file, []byte(text), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", file, err)
+	}
+
+	out := make([]requirement, 0, len(mod.Require))
+	for _, v := range mod.Require {
+		if (v.Indirect && !c.Indirect) || !c.selected(v.Mod.Path) {
+			continue
+		}
+
+		minimum, ok := c.Minimums[v.Mod.Path]
+		out = append(out, requirement{
+			repository: owner + "/" + d.Repository,
+			file:       file,
+			module:     v.Mod.Path,
+			version:    v.Mod.Version,
+			indirect:   v.Indirect,
+			outdated:   ok && semver.Compare(v.Mod.Version, minimum) < 0,
+		})
+	}
+
+	return out, nil
+}
+
+// selected reports whether the module is inventoried.
+func (c config) selected(module string) bool {
+	if len(c.Modules) == 0 {
+		return true
+	}
+
+	for _, v := range c.Modules {
+		if ok, _ := path.Match(v, module); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printInventory prints the versions of every module along with the number of
+// repositories requiring them, oldest first.
+func printInventory(inventory []requirement) {
+	versions := make(map[string]map[string]int)
+	for _, v := range inventory {
+		if versions[v.module] == nil {
+			versions[v.module] = make(map[string]int)
+		}
+		versions[v.module][v.version]++
+	}
+
+	modules := make([]string, 0, len(versions))
+	for k := range versions {
+		modules = append(modules, k)
+	}
+	sort.Strings(modules)
+
+	for _, module := range modules {
+		list := make([]string, 0, len(versions[module]))
+		for k := range versions[module] {
+			list = append(list, k)
+		}
+		sort.Slice(list, func(i, j int) bool { return semver.Compare(list[i], list[j]) < 0 })
+
+		counts := make([]string, 0, len(list))
+		for _, v := range list {
+			counts = append(counts, fmt.Sprintf("%s (%d)", v, versions[module][v]))
+		}
+		fmt.Printf("%s: %s\n", module, strings.Join(counts, ", "))
+	}
+}
+
+func writeReport(inventory []requirement) error {
+	report := fleet.Table{Header: []string{"repository", "file", "module", "version", "indirect", "outdated"}}
+	for _, v := range inventory {
+		report.Add(v.repository, v.file, v.module, v.version, fmt.Sprint(v.indirect), fmt.Sprint(v.outdated))
+	}
+
+	return fleet.WriteTable(*_report, report)
+}
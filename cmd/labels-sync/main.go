@@ -0,0 +1,108 @@
+// Command labels-sync syncs a canonical label set to a batch of repositories.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and their labels.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Labels []label `yaml:"labels"`
+
+	// OnlyMissing creates the missing labels without updating the color nor
+	// the description of the existing ones.
+	OnlyMissing bool `yaml:"only_missing"`
+
+	// Prune deletes the labels that are not in the config.
+	Prune bool `yaml:"prune"`
+}
+
+// label is a label of the canonical set.
+type label struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color"` // hexadecimal, for instance, "d73a4a".
+	Description string `yaml:"description"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		s := syncer{client: client, owner: owner, repo: d.Repository, dryRun: _flags.DryRun}
+		changes, err := s.sync(ctx, c.Labels, !c.OnlyMissing, c.Prune)
+		if err != nil {
+			return "", err
+		}
+
+		return fleet.Summary(changes, _flags.DryRun), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if c.OnlyMissing && c.Prune {
+		return errors.New("only_missing and prune cannot be combined")
+	}
+
+	if len(c.Labels) == 0 && !c.Prune {
+		return errors.New("labels are required")
+	}
+
+	seen := make(map[string]bool)
+	for i, v := range c.Labels {
+		if v.Name == "" || v.Color == "" {
+			return errors.New("labels need a name and a color")
+		}
+
+		// names are case insensitive.
+		key := strings.ToLower(v.Name)
+		if seen[key] {
+			return fmt.Errorf("label %q is duplicated", v.Name)
+		}
+		seen[key] = true
+
+		c.Labels[i].Color = strings.ToLower(strings.TrimPrefix(v.Color, "#"))
+	}
+
+	return c.Validate()
+}
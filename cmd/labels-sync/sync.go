@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// syncer syncs the labels of a repository.
+type syncer struct {
+	client *github.Client
+	owner  string
+	repo   string
+	dryRun bool
+}
+
+// sync creates the missing labels, updates the ones that differ when update
+// is set and deletes the unknown ones when prune is set, returning the
+// changes made, or that would be on a dry run.
+func (s syncer) sync(ctx context.Context, labels []label, update, prune bool) ([]string, error) {
+	existing, err := s.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		changes []string
+		wanted  = make(map[string]bool, len(labels))
+	)
+	for _, v := range labels {
+		key := strings.ToLower(v.Name)
+		wanted[key] = true
+
+		current, ok := existing[key]
+		switch {
+		case !ok:
+			changes = append(changes, "create "+v.Name)
+			if !s.dryRun {
+				_, _, err = s.client.Issues.CreateLabel(ctx, s.owner, s.repo, v.request())
+			}
+		case update && (strings.ToLower(current.GetColor()) != v.Color || current.GetDescription() != v.Description):
+			changes = append(changes, "update "+v.Name)
+			if !s.dryRun {
+				_, _, err = s.client.Issues.EditLabel(ctx, s.owner, s.repo, url.PathEscape(current.GetName()), v.request())
+			}
+		}
+		if err != nil {
+			return changes, fmt.Errorf("unable to save label %q: %w", v.Name, err)
+		}
+	}
+
+	if !prune {
+		return changes, nil
+	}
+
+	unknown := make([]string, 0, len(existing))
+	for key := range existing {
+		if !wanted[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, key := range unknown {
+		v := existing[key]
+
+		changes = append(changes, "delete "+v.GetName())
+		if s.dryRun {
+			continue
+		}
+		if _, err := s.client.Issues.DeleteLabel(ctx, s.owner, s.repo, url.PathEscape(v.GetName())); err != nil {
+			return changes, fmt.Errorf("unable to delete label %q: %w", v.GetName(), err)
+		}
+	}
+
+	return changes, nil
+}
+
+// list returns the labels of the repository by lower case name.
+func (s syncer) list(ctx context.Context) (map[string]*github.Label, error) {
+	labels := make(map[string]*github.Label)
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := s.client.Issues.ListLabels(ctx, s.owner, s.repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list labels: %w", err)
+		}
+
+		for _, v := range page {
+			labels[strings.ToLower(v.GetName())] = v
+		}
+
+		if resp.NextPage == 0 {
+			return labels, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func (l label) request() *github.Label {
+	return &github.Label{Name: &l.Name, Color: &l.Color, Description: &l.Description}
+}
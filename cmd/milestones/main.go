@@ -0,0 +1,187 @@
+// Command milestones creates, updates or closes the same milestone on a batch
+// of repositories, such as the milestone of a coordinated release.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// _dateLayout is the layout of the due dates.
+const _dateLayout = "2006-01-02"
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the milestone
+// of each of them, matched by title with the existing ones.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Milestone milestone `yaml:"milestone"`
+}
+
+// milestone is the desired state of the milestone of every destination.
+type milestone struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	DueOn       string `yaml:"due_on"` // due date, as 2006-01-02, left as is when empty.
+	State       string `yaml:"state"`  // open (default) or closed.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		current, err := findMilestone(ctx, client, owner, d.Repository, c.Milestone.Title)
+		if err != nil {
+			return "", err
+		}
+
+		if current == nil {
+			if _flags.DryRun {
+				return fmt.Sprintf("would create %q", c.Milestone.Title), nil
+			}
+
+			created, _, err := client.Issues.CreateMilestone(ctx, owner, d.Repository, c.Milestone.request())
+			if err != nil {
+				return "", fmt.Errorf("unable to create milestone: %w", err)
+			}
+
+			return "created " + created.GetHTMLURL(), nil
+		}
+
+		changes := fleet.Changes(settings(current), c.Milestone.settings())
+		if len(changes) > 0 && !_flags.DryRun {
+			if _, _, err := client.Issues.EditMilestone(ctx, owner, d.Repository, current.GetNumber(), c.Milestone.request()); err != nil {
+				return "", fmt.Errorf("unable to update milestone: %w", err)
+			}
+		}
+
+		return fleet.Summary(changes, _flags.DryRun), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if c.Milestone.Title == "" {
+		return errors.New("milestone title is required")
+	}
+
+	if c.Milestone.DueOn != "" {
+		if _, err := time.Parse(_dateLayout, c.Milestone.DueOn); err != nil {
+			return fmt.Errorf("invalid due_on, expected %s: %w", _dateLayout, err)
+		}
+	}
+
+	switch c.Milestone.State {
+	case "":
+		c.Milestone.State = "open"
+	case "open", "closed":
+	default:
+		return fmt.Errorf("invalid state %q, expected open or closed", c.Milestone.State)
+	}
+
+	return c.Validate()
+}
+
+// request returns the milestone to create or update.
+func (m milestone) request() *github.Milestone {
+	out := &github.Milestone{
+		Title:       &m.Title,
+		Description: &m.Description,
+		State:       &m.State,
+	}
+
+	if m.DueOn != "" {
+		due, _ := time.Parse(_dateLayout, m.DueOn)
+		out.DueOn = &due
+	}
+
+	return out
+}
+
+func (m milestone) settings() []fleet.Setting {
+	out := []fleet.Setting{
+		{Name: "description", Value: m.Description},
+		{Name: "state", Value: m.State},
+	}
+	if m.DueOn != "" {
+		out = append(out, fleet.Setting{Name: "due_on", Value: m.DueOn})
+	}
+
+	return out
+}
+
+// settings returns the settings of an existing milestone. Due dates are
+// compared by day, as GitHub stores them at a time of its own.
+func settings(m *github.Milestone) []fleet.Setting {
+	var due string
+	if m.DueOn != nil {
+		due = m.GetDueOn().UTC().Format(_dateLayout)
+	}
+
+	return []fleet.Setting{
+		{Name: "description", Value: m.GetDescription()},
+		{Name: "due_on", Value: due},
+		{Name: "state", Value: m.GetState()},
+	}
+}
+
+// findMilestone returns the milestone of the repository with the given title,
+// open or closed, nil if there is none.
+func findMilestone(ctx context.Context, client *github.Client, owner, repo, title string) (*github.Milestone, error) {
+	opt := &github.MilestoneListOptions{State: "all", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		milestones, resp, err := client.Issues.ListMilestones(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list milestones: %w", err)
+		}
+
+		for _, v := range milestones {
+			if v.GetTitle() == title {
+				return v, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+)
+
+// remote is a git remote and the token authenticating against it, if any.
+type remote struct {
+	url   string
+	token string
+}
+
+// at returns the remote with the given URL.
+func (r remote) at(url string) remote {
+	r.url = url
+	return r
+}
+
+// git runs the local git executable with args in dir, returning its standard
+// output. The tokens of the remotes are sent as headers of their URLs through
+// the environment, so they neither show in the arguments nor are saved in the
+// clones.
+func git(ctx context.Context, dir string, remotes []remote, args ...string) (string, error) {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	var count int
+	for _, v := range remotes {
+		if v.token == "" {
+			continue
+		}
+
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + v.token))
+		redact.Secret(v.token, credentials)
+		env = append(env,
+			"GIT_CONFIG_KEY_"+strconv.Itoa(count)+"=http."+v.url+".extraHeader",
+			"GIT_CONFIG_VALUE_"+strconv.Itoa(count)+"=Authorization: Basic "+credentials,
+		)
+		count++
+	}
+	env = append(env, "GIT_CONFIG_COUNT="+strconv.Itoa(count))
+
+	command := args[0]
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,244 @@
+// Command mirror mirrors the branches and tags of a batch of repositories to
+// the destination ones, usually in another organization or host, with the
+// local git executable.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var (
+	_flags *fleet.Flags   = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_every *time.Duration = flag.Duration("every", 0, "Mirrors again after the given interval, until stopped, for instance, 1h")
+)
+
+// config is the content of a config file: where the repositories are mirrored
+// from, the destinations receiving them and where they are kept in between.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Source source `yaml:"source"`
+	Create bool   `yaml:"create"` // creates the missing destinations, as private repositories.
+	Cache  string `yaml:"cache"`  // directory keeping the clones between runs, in the user cache by default.
+}
+
+// source is where the repositories are mirrored from: the destination
+// repositories of the same name under URL.
+type source struct {
+	URL      string `yaml:"url"`       // for instance, "https://github.com/golang".
+	TokenEnv string `yaml:"token_env"` // environment variable holding the token of private sources.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	// the token of the API pushes to the destinations.
+	tokens, err := _flags.Credentials.Resolve(context.Background())
+	if err != nil {
+		return err
+	}
+
+	m := mirror{
+		client: client,
+		owner:  c.OwnerOrDefault(),
+		source: remote{token: os.Getenv(c.Source.TokenEnv)},
+		target: remote{token: tokens[0]},
+		cache:  c.Cache,
+		create: c.Create,
+	}
+
+	for {
+		fmt.Println("hold ...")
+		err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+			return m.mirror(ctx, strings.TrimSuffix(c.Source.URL, "/")+"/"+d.Repository+".git", d.Repository, _flags.DryRun)
+		})
+		if *_every == 0 {
+			break
+		}
+
+		// the next run may succeed where this one failed.
+		if err != nil {
+			fleet.Report(err)
+		}
+		fmt.Printf("next run at %s.\n", time.Now().Add(*_every).Format(time.RFC3339))
+		time.Sleep(*_every)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if c.Source.URL == "" {
+		return errors.New("source url is required")
+	}
+	if u, err := url.Parse(c.Source.URL); err != nil || u.Host == "" {
+		return fmt.Errorf("invalid source url %q", c.Source.URL)
+	}
+
+	if c.Cache == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return fmt.Errorf("cache is required: %w", err)
+		}
+		c.Cache = filepath.Join(dir, "go-toolkit-cmd", "mirror")
+	}
+
+	return c.Validate()
+}
+
+// mirror pushes the branches and tags of the sources to the destinations,
+// keeping a bare clone of every source in its cache so later runs only fetch
+// what changed.
+type mirror struct {
+	client *github.Client
+	owner  string
+	source remote
+	target remote
+	cache  string
+	create bool
+}
+
+// mirror updates the destination with the source at from, returning what was
+// done, or would be on a dry run.
+func (m mirror) mirror(ctx context.Context, from, name string, dryRun bool) (string, error) {
+	to, created, err := m.destination(ctx, name, dryRun)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(m.cache, m.owner, name+".git")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if _, err := git(ctx, "", nil, "init", "--bare", "--quiet", dir); err != nil {
+			return "", err
+		}
+	}
+
+	// only branches and tags, GitHub rejects the pushes to the pull request refs.
+	refspecs := []string{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"}
+	if _, err := git(ctx, dir, []remote{m.source.at(from)}, append([]string{"fetch", "--prune", "--quiet", from}, refspecs...)...); err != nil {
+		return "", err
+	}
+
+	if to == "" {
+		// a dry run of a destination to create.
+		return "would create and push every branch and tag", nil
+	}
+
+	args := []string{"push", "--porcelain", "--prune"}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+	args = append(append(args, to), refspecs...)
+
+	out, err := git(ctx, dir, []remote{m.target.at(to)}, args...)
+	if err != nil {
+		return "", err
+	}
+
+	summary := pushSummary(out, dryRun)
+	if created {
+		summary = "created, " + summary
+	}
+
+	return summary, nil
+}
+
+// destination returns the clone URL of the destination repository, creating
+// it when missing and allowed. The URL is empty on a dry run of a destination
+// to create.
+func (m mirror) destination(ctx context.Context, name string, dryRun bool) (string, bool, error) {
+	repo, _, err := m.client.Repositories.Get(ctx, m.owner, name)
+	if err == nil {
+		return repo.GetCloneURL(), false, nil
+	}
+	if !fleet.IsNotFound(err) || !m.create {
+		return "", false, fmt.Errorf("unable to get destination: %w", err)
+	}
+	if dryRun {
+		return "", false, nil
+	}
+
+	owner, _, err := m.client.Users.Get(ctx, m.owner)
+	if err != nil {
+		return "", false, fmt.Errorf("unable to get owner: %w", err)
+	}
+
+	org := m.owner
+	if owner.GetType() != "Organization" {
+		// repositories of the authenticated user are created without owner.
+		org = ""
+	}
+
+	private := true
+	repo, _, err = m.client.Repositories.Create(ctx, org, &github.Repository{Name: &name, Private: &private})
+	if err != nil {
+		return "", false, fmt.Errorf("unable to create destination: %w", err)
+	}
+
+	return repo.GetCloneURL(), true, nil
+}
+
+// pushSummary describes the refs updated by a push from its porcelain output.
+func pushSummary(out string, dryRun bool) string {
+	var updated []string
+	for _, line := range strings.Split(out, "\n") {
+		// "<flag>\t<from>:<to>\t<summary>", "=" being the refs up to date.
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 || fields[0] == "=" || fields[0] == "!" {
+			continue
+		}
+
+		ref := fields[1][strings.LastIndex(fields[1], ":")+1:]
+		ref = strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/")
+		if fields[0] == "-" {
+			ref += " (deleted)"
+		}
+		updated = append(updated, ref)
+	}
+
+	switch {
+	case len(updated) == 0:
+		return "up to date"
+	case dryRun:
+		return "would push " + strings.Join(updated, ", ")
+	default:
+		return "pushed " + strings.Join(updated, ", ")
+	}
+}
@@ -0,0 +1,136 @@
+// Command mkissue opens the same issue on a batch of repositories, such as
+// deprecation notices or action required announcements.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the issue to
+// open on each of them.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Issue issue `yaml:"issue"`
+}
+
+// issue is opened on every destination. Title and body are rendered as
+// text/template for each destination, see mkpr.TemplateData.
+type issue struct {
+	Title     string   `yaml:"title"`
+	Body      string   `yaml:"body"`
+	Labels    []string `yaml:"labels"`
+	Assignees []string `yaml:"assignees"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if c.Issue.Title == "" {
+		return errors.New("issue title is required")
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		data := c.Data(d)
+		title, err := mkpr.Render("title", c.Issue.Title, data)
+		if err != nil {
+			return "", err
+		}
+
+		body, err := mkpr.Render("body", c.Issue.Body, data)
+		if err != nil {
+			return "", err
+		}
+
+		// re-runs do not duplicate the issues already opened.
+		existing, err := findOpenIssue(ctx, client, owner, d.Repository, title, c.Issue.Labels)
+		if err != nil {
+			return "", err
+		}
+		if existing != nil {
+			return "already open " + existing.GetHTMLURL(), nil
+		}
+
+		if _flags.DryRun {
+			return fmt.Sprintf("would open %q", title), nil
+		}
+
+		created, _, err := client.Issues.Create(ctx, owner, d.Repository, &github.IssueRequest{
+			Title:     &title,
+			Body:      &body,
+			Labels:    &c.Issue.Labels,
+			Assignees: &c.Issue.Assignees,
+		})
+		if err != nil {
+			return "", fmt.Errorf("unable to create issue: %w", err)
+		}
+
+		return created.GetHTMLURL(), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+// findOpenIssue returns the open issue of the repository with the given title
+// and labels, nil if there is none.
+func findOpenIssue(ctx context.Context, client *github.Client, owner, repo, title string, labels []string) (*github.Issue, error) {
+	opt := &github.IssueListByRepoOptions{
+		State:       "open",
+		Labels:      labels,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		issues, resp, err := client.Issues.ListByRepo(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list issues: %w", err)
+		}
+
+		for _, v := range issues {
+			if !v.IsPullRequest() && v.GetTitle() == title {
+				return v, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
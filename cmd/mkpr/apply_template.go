@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/templates"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// applyTemplate runs "mkpr apply-template [flags] <name>", which creates the
+// pull requests defined by a named template against the destinations given by
+// the user.
+func applyTemplate(args []string) error {
+	fs := newFlagSet("apply-template", "[flags] <name>", "Creates the pull requests defined by a named template, from a local directory or a repository, against the destinations given by the flags or a config file.")
+	dir := fs.String("templates", "templates", "Directory holding the templates")
+	repo := fs.String("templates-repo", "", "Repository to fetch the templates from (owner/repo[@ref]), instead of a local directory")
+	location := fs.String("config", "", "Config file with the destinations, vars, delay, owner, GitHub URLs and HTTP settings to apply the template with")
+	list := fs.Bool("list", false, "Lists the available templates")
+	githubURL := fs.String("github-url", "", "GitHub Enterprise Server API URL")
+	uploadURL := fs.String("upload-url", "", "GitHub Enterprise Server uploads URL")
+	var destinations, vars options.StringList
+	source := fleet.RegisterCredentialFlags(fs)
+	httpFlags := fleet.RegisterHTTPFlags(fs)
+	run := registerRunFlags(fs)
+	fs.Var(&destinations, "destination", "Destination repository (repository:base), can be repeated")
+	fs.Var(&vars, "var", "Template variable (key=value), can be repeated")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var user options.Config
+	if *location != "" {
+		var err error
+		if user, err = options.ParseFile(*location); err != nil {
+			return err
+		}
+	}
+
+	options.Override{
+		GitHubURL:          *githubURL,
+		UploadURL:          *uploadURL,
+		Proxy:              httpFlags.Proxy,
+		CABundle:           httpFlags.CABundle,
+		InsecureSkipVerify: httpFlags.InsecureSkipVerify,
+		Timeout:            httpFlags.Timeout,
+		CacheDir:           httpFlags.CacheDir,
+		MaxRPS:             httpFlags.MaxRPS,
+		UserAgent:          httpFlags.UserAgent,
+	}.Apply(&user)
+
+	id, err := newRunID()
+	if err != nil {
+		return err
+	}
+	run.ID = id
+
+	source.Host = user.Host()
+	tc, save, err := newRunHTTPClient(source, user.HTTP, user.BasicAuth(), *run)
+	if err != nil {
+		return err
+	}
+
+	if *repo != "" {
+		client, err := provider.NewGitHubClient(tc, user.GitHubURL, user.UploadURL)
+		if err != nil {
+			return err
+		}
+
+		tmp, err := fetchTemplates(client, *repo, *dir)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		*dir = tmp
+	}
+
+	if *list {
+		names, err := templates.List(*dir)
+		for _, v := range names {
+			fmt.Println(v)
+		}
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a single template name is required")
+	}
+
+	config, err := templates.Load(*dir, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	option := config.BatchPullRequestOption
+	option.Destinations = append(option.Destinations, user.Destinations...)
+	if user.Delay != "" {
+		option.Delay = user.Delay
+	}
+
+	if user.Owner != "" {
+		option.Owner = user.Owner
+	}
+	option.GitHubURL, option.UploadURL = user.GitHubURL, user.UploadURL
+	option.Vars = mergeVars(option.Vars, user.Vars)
+
+	for _, v := range destinations {
+		parts := strings.SplitN(v, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid destination %q, expected repository:base", v)
+		}
+		option.Destinations = append(option.Destinations, mkpr.Destination{Repository: parts[0], Base: parts[1]})
+	}
+
+	for _, v := range vars {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid variable %q, expected key=value", v)
+		}
+		option.Vars = mergeVars(option.Vars, map[string]string{parts[0]: parts[1]})
+	}
+
+	if len(option.Destinations) == 0 {
+		return errors.New("no destinations given")
+	}
+
+	_, err = execute(tc, options.Config{BatchPullRequestOption: option, Hooks: user.Hooks, Notify: user.Notify}, *run)
+	if saveErr := save(); err == nil {
+		err = saveErr
+	}
+
+	return err
+}
+
+// fetchTemplates downloads the directory dir of the given owner/repo[@ref]
+// templates repository.
+func fetchTemplates(client *github.Client, repo, dir string) (string, error) {
+	var ref string
+	if i := strings.Index(repo, "@"); i >= 0 {
+		repo, ref = repo[:i], repo[i+1:]
+	}
+
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid templates repository %q, expected owner/repo[@ref]", repo)
+	}
+
+	return templates.Fetch(context.Background(), client, parts[0], parts[1], ref, dir)
+}
+
+// mergeVars returns the variables of base overridden by the ones of override.
+func mergeVars(base, override map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, v := range override {
+		merged[k] = v
+	}
+
+	return merged
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// cleanup runs "mkpr cleanup", which deletes the head branches left behind by
+// a config once its pull requests are merged or closed.
+func cleanup(args []string) error {
+	fs := newFlagSet("cleanup", "[flags]", "Deletes the head branch of the config, or of the run given with -run, on the destinations whose pull requests from it are all merged or closed.")
+	cf := registerConfigFlags(fs)
+	sel := registerSelectionFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "Prints the branches that would be deleted, without deleting them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	p, err := cf.provider(&config)
+	if err != nil {
+		return err
+	}
+
+	if err := sel.apply(&config); err != nil {
+		return err
+	}
+
+	deleter, ok := p.(provider.BranchDeleter)
+	if !ok {
+		return errors.New("the provider cannot delete branches")
+	}
+
+	fmt.Println("hold ...")
+	owner := config.OwnerOrDefault()
+	err = fleet.Run(context.Background(), fleetConfig(config), func(ctx context.Context, d mkpr.Destination) (string, error) {
+		var summaries []string
+		for _, head := range sel.headsOf(config, d) {
+			pulls, err := sel.pullRequestsFrom(ctx, p, config, d, head)
+			if err != nil {
+				return "", err
+			}
+
+			switch {
+			case len(pulls) == 0:
+				summaries = append(summaries, "no pull request, kept "+head)
+				continue
+			case hasOpen(pulls):
+				summaries = append(summaries, "pull request still open, kept "+head)
+				continue
+			case *dryRun:
+				summaries = append(summaries, "would delete "+head)
+				continue
+			}
+
+			if err := deleter.DeleteRef(ctx, owner, d.Repository, head); err != nil {
+				return "", fmt.Errorf("unable to delete %s: %w", head, err)
+			}
+			summaries = append(summaries, "deleted "+head)
+		}
+
+		return strings.Join(summaries, ", "), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+// hasOpen reports whether one of the pull requests is open.
+func hasOpen(pulls []provider.PullRequest) bool {
+	for _, v := range pulls {
+		if v.State == provider.StateOpen {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// closePullRequests runs "mkpr close", which closes the open pull requests of
+// a config, or of a run, without merging them, to abandon a rollout.
+func closePullRequests(args []string) error {
+	fs := newFlagSet("close", "[flags]", "Closes the open pull requests from the head branch of the config, or created by the run given with -run, without merging them.")
+	cf := registerConfigFlags(fs)
+	sel := registerSelectionFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "Prints the pull requests that would be closed, without closing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	p, err := cf.provider(&config)
+	if err != nil {
+		return err
+	}
+
+	if err := sel.apply(&config); err != nil {
+		return err
+	}
+
+	closer, ok := p.(provider.PullRequestCloser)
+	if !ok {
+		return errors.New("the provider cannot close pull requests")
+	}
+
+	fmt.Println("hold ...")
+	owner := config.OwnerOrDefault()
+	err = fleet.Run(context.Background(), fleetConfig(config), func(ctx context.Context, d mkpr.Destination) (string, error) {
+		pulls, err := sel.pullRequests(ctx, p, config, d)
+		if err != nil {
+			return "", err
+		}
+
+		var closed []string
+		for _, v := range pulls {
+			if v.State != provider.StateOpen {
+				continue
+			}
+
+			number := fmt.Sprintf("#%d", v.Number)
+			if !*dryRun {
+				if err := closer.ClosePullRequest(ctx, owner, d.Repository, v.Number); err != nil {
+					return "", fmt.Errorf("unable to close %s: %w", number, err)
+				}
+			}
+			closed = append(closed, number)
+		}
+
+		switch {
+		case len(closed) == 0:
+			return "no open pull request", nil
+		case *dryRun:
+			return "would close " + strings.Join(closed, ", "), nil
+		default:
+			return "closed " + strings.Join(closed, ", "), nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/internal/credentials"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/internal/schedule"
+	"github.com/sorfino/go-toolkit-cmd/internal/state"
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+	"gopkg.in/yaml.v3"
+)
+
+// jobs is the content of a jobs file: the configs run on a schedule.
+type jobs struct {
+	Jobs []job `yaml:"jobs"`
+}
+
+// job is a config run on a schedule, by mkpr daemon, or on the pushes to a
+// repository, by mkpr webhook.
+type job struct {
+	Name     string `yaml:"name"`
+	Config   string `yaml:"config"`   // mkpr config file, relative to the jobs file.
+	Schedule string `yaml:"schedule"` // cron expression, such as "0 9 * * mon".
+	Push     *Push  `yaml:"push"`
+
+	// Incremental only touches the destinations whose change differs from the
+	// last run, true by default so recurring jobs do not open the same pull
+	// requests again.
+	Incremental *bool `yaml:"incremental"`
+
+	schedule schedule.Schedule
+	next     time.Time
+}
+
+// daemon runs "mkpr daemon", which runs the jobs of a jobs file on their
+// schedule until interrupted, recording and notifying every run.
+func daemon(args []string) error {
+	fs := newFlagSet("daemon", "[flags]", "Runs the jobs of a jobs file on their schedule until interrupted, recording and notifying every run.")
+	location := fs.String("jobs", "jobs.yml", "Location of the jobs file")
+	statePath := fs.String("state", state.DefaultPath, "State file recording the changes pushed by the runs, locked while a job runs")
+	verbose := fs.Bool("verbose", false, "Logs every step of the batches")
+	source := fleet.RegisterCredentialFlags(fs)
+	httpFlags := fleet.RegisterHTTPFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	all, err := parseJobs(*location, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var js []job
+	for _, j := range all {
+		if j.Schedule != "" {
+			js = append(js, j)
+		}
+	}
+	if len(js) == 0 {
+		return errors.New("no job has a schedule")
+	}
+
+	// a signal stops the daemon once the running job, if any, is done.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("running %d jobs from %s\n", len(js), *location)
+	for {
+		j := &js[0]
+		for i := range js {
+			if js[i].next.Before(j.next) {
+				j = &js[i]
+			}
+		}
+		fmt.Printf("next job: %s at %s\n", j.Name, j.next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(j.next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			fmt.Println("stopped.")
+			return nil
+		case <-timer.C:
+		}
+
+		fmt.Printf("%s: running job %s\n", time.Now().Format(time.RFC3339), j.Name)
+		run := runFlags{Verbose: *verbose, Incremental: *j.Incremental, State: *statePath, Job: j.Name}
+		if err := runJob(filepath.Join(filepath.Dir(*location), j.Config), *source, *httpFlags, run); err != nil {
+			fmt.Printf("%s: job %s failed: %s\n", time.Now().Format(time.RFC3339), j.Name, redact.String(err.Error()))
+		}
+
+		if j.next, err = j.schedule.Next(time.Now()); err != nil {
+			return fmt.Errorf("job %s: %w", j.Name, err)
+		}
+	}
+}
+
+// parseJobs parses the jobs file at path, scheduling the jobs after now.
+func parseJobs(path string, now time.Time) ([]job, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file jobs
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Jobs) == 0 {
+		return nil, errors.New("jobs are required")
+	}
+
+	names := make(map[string]bool, len(file.Jobs))
+	for i := range file.Jobs {
+		j := &file.Jobs[i]
+		switch {
+		case j.Name == "":
+			return nil, errors.New("jobs need a name")
+		case names[j.Name]:
+			return nil, fmt.Errorf("job %s is duplicated", j.Name)
+		case j.Config == "":
+			return nil, fmt.Errorf("job %s needs a config", j.Name)
+		case j.Schedule == "" && j.Push == nil:
+			return nil, fmt.Errorf("job %s needs a schedule or a push trigger", j.Name)
+		}
+		names[j.Name] = true
+
+		if j.Incremental == nil {
+			incremental := true
+			j.Incremental = &incremental
+		}
+
+		if j.Push != nil {
+			if err := j.Push.validate(); err != nil {
+				return nil, fmt.Errorf("job %s: %w", j.Name, err)
+			}
+		}
+
+		if j.Schedule == "" {
+			continue
+		}
+		if j.schedule, err = schedule.Parse(j.Schedule); err != nil {
+			return nil, fmt.Errorf("job %s: %w", j.Name, err)
+		}
+		if j.next, err = j.schedule.Next(now); err != nil {
+			return nil, fmt.Errorf("job %s: %w", j.Name, err)
+		}
+	}
+
+	return file.Jobs, nil
+}
+
+// runJob runs the config at path like mkpr does, holding the lock of the
+// state file.
+func runJob(path string, source credentials.Source, httpFlags transport.Options, run runFlags) error {
+	config, err := options.ParseFile(path)
+	if err != nil {
+		return err
+	}
+
+	options.Override{
+		Proxy:              httpFlags.Proxy,
+		CABundle:           httpFlags.CABundle,
+		InsecureSkipVerify: httpFlags.InsecureSkipVerify,
+		Timeout:            httpFlags.Timeout,
+		CacheDir:           httpFlags.CacheDir,
+		MaxRPS:             httpFlags.MaxRPS,
+		UserAgent:          httpFlags.UserAgent,
+	}.Apply(&config)
+
+	if run.ID, err = newRunID(); err != nil {
+		return err
+	}
+
+	source.Host = config.Host()
+	tc, _, err := newRunHTTPClient(&source, config.HTTP, config.BasicAuth(), run)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := state.Lock(run.State)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = execute(tc, config, run)
+	return err
+}
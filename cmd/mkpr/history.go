@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/state"
+)
+
+// history runs "mkpr history", which lists the runs recorded in the state
+// file or shows the destinations of one of them.
+func history(args []string) error {
+	fs := newFlagSet("history", "[flags] [run id]", "Lists the runs recorded in the state file, newest first, with their job, destinations and success rate. Given a run ID, or \"last\", shows the outcome of each of its destinations.")
+	path := fs.String("state", state.DefaultPath, "State file recording the runs")
+	repository := fs.String("repository", "", "Only the runs that pushed to the given repository, name or owner/name")
+	job := fs.String("job", "", "Only the runs of the given job, \"-\" for the manual runs")
+	since := fs.Duration("since", 0, "Only the runs started in the given period, for instance, 720h")
+	report := fs.String("report", "", "Writes the runs, or the destinations of the run, to the given file, as CSV or JSON by extension")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return fmt.Errorf("expected at most one run ID, got %d", fs.NArg())
+	}
+
+	db, err := state.Open(*path)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 1 {
+		run, err := db.Run(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		return printRun(run, *repository, *report)
+	}
+
+	now := time.Now()
+	runs := db.Runs()
+	table := fleet.Table{Header: []string{"id", "started", "job", "owner", "head", "destinations", "succeeded", "subject"}}
+	for i := len(runs) - 1; i >= 0; i-- {
+		v := runs[i]
+		switch {
+		case *since > 0 && now.Sub(v.StartedAt) > *since:
+			continue
+		case *job == "-" && v.Job != "":
+			continue
+		case *job != "" && *job != "-" && v.Job != *job:
+			continue
+		case *repository != "" && len(resultsOf(v, *repository)) == 0:
+			continue
+		}
+
+		table.Add(runID(v), v.StartedAt.Local().Format("2006-01-02 15:04"), v.Job, v.Owner, v.Head, fmt.Sprint(len(v.Results)), successRate(v.Results), v.Subject)
+	}
+
+	if len(table.Rows) == 0 {
+		fmt.Println("no run recorded.")
+	} else {
+		printTable(table)
+	}
+
+	return fleet.WriteTable(*report, table)
+}
+
+// printRun prints the outcome of every destination of the run, or of the
+// given repository only.
+func printRun(run state.Run, repository, report string) error {
+	fmt.Printf("run %s\n", runID(run))
+	if run.Job != "" {
+		fmt.Printf("job:      %s\n", run.Job)
+	}
+	fmt.Printf("started:  %s\n", run.StartedAt.Local().Format(time.RFC3339))
+	fmt.Printf("duration: %s\n", run.FinishedAt.Sub(run.StartedAt).Round(time.Second))
+	fmt.Printf("owner:    %s\n", run.Owner)
+	fmt.Printf("head:     %s\n", run.Head)
+	fmt.Printf("subject:  %s\n", run.Subject)
+	fmt.Printf("success:  %s\n\n", successRate(run.Results))
+
+	results := run.Results
+	if repository != "" {
+		results = resultsOf(run, repository)
+	}
+
+	table := fleet.Table{Header: []string{"repository", "head", "outcome", "url"}}
+	for _, v := range results {
+		head := v.Head
+		if head == "" {
+			head = run.Head
+		}
+
+		switch {
+		case v.Error != "":
+			table.Add(run.Owner+"/"+v.Repository, head, "failed: "+v.Error, "")
+		case v.Unchanged:
+			table.Add(run.Owner+"/"+v.Repository, head, "unchanged", "")
+		default:
+			table.Add(run.Owner+"/"+v.Repository, head, "created", v.URL)
+		}
+	}
+
+	printTable(table)
+	return fleet.WriteTable(report, table)
+}
+
+// resultsOf returns the results of the run for the repository, given by name
+// or as owner/name.
+func resultsOf(run state.Run, repository string) []state.Result {
+	var out []state.Result
+	for _, v := range run.Results {
+		if strings.EqualFold(v.Repository, repository) || strings.EqualFold(run.Owner+"/"+v.Repository, repository) {
+			out = append(out, v)
+		}
+	}
+
+	return out
+}
+
+// successRate describes the share of the results without error, such as
+// "3/4 (75%)". Unchanged destinations succeeded.
+func successRate(results []state.Result) string {
+	if len(results) == 0 {
+		return "-"
+	}
+
+	var succeeded int
+	for _, v := range results {
+		if v.Error == "" {
+			succeeded++
+		}
+	}
+
+	return fmt.Sprintf("%d/%d (%d%%)", succeeded, len(results), succeeded*100/len(results))
+}
+
+// runID returns the ID of the run, "-" for the runs recorded before they had
+// one.
+func runID(run state.Run) string {
+	if run.ID == "" {
+		return "-"
+	}
+
+	return run.ID
+}
+
+// printTable prints the table aligned in columns.
+func printTable(t fleet.Table) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(t.Header, "\t"))
+	for _, v := range t.Rows {
+		fmt.Fprintln(w, strings.Join(v, "\t"))
+	}
+	w.Flush()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// runHook runs the shell command of a hook, if any, with the given environment
+// variables on top of the current ones.
+func runHook(ctx context.Context, name, command string, env []string) error {
+	if command == "" {
+		return nil
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // the command is given by the user on purpose.
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// warnHook runs a hook whose failure cannot stop the batch, reporting it.
+func warnHook(ctx context.Context, name, command string, env []string) {
+	if err := runHook(ctx, name, command, env); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", redact.String(err.Error()))
+	}
+}
+
+// destinationEnv returns the environment of the destination hooks.
+func destinationEnv(option mkpr.BatchPullRequestOption, d mkpr.Destination) []string {
+	return []string{
+		"MKPR_OWNER=" + option.OwnerOrDefault(),
+		"MKPR_REPOSITORY=" + d.Repository,
+		"MKPR_BASE=" + d.Base,
+		"MKPR_HEAD=" + option.Head,
+	}
+}
+
+// commandHooks returns the batch hooks running the destination hooks of the
+// config.
+func commandHooks(hooks options.Hooks, option mkpr.BatchPullRequestOption) mkpr.Hooks {
+	return mkpr.Hooks{
+		BeforeDestination: func(ctx context.Context, d mkpr.Destination) error {
+			return runHook(ctx, "pre_destination", hooks.PreDestination, destinationEnv(option, d))
+		},
+		OnPRCreated: func(d mkpr.Destination, pr provider.PullRequest) {
+			env := append(destinationEnv(option, d), "MKPR_STATUS=success", "MKPR_PR_URL="+pr.URL)
+			warnHook(context.Background(), "post_destination", hooks.PostDestination, env)
+		},
+		OnError: func(d mkpr.Destination, err error) {
+			env := append(destinationEnv(option, d), "MKPR_STATUS=failure", "MKPR_ERROR="+redact.String(err.Error()))
+			warnHook(context.Background(), "post_destination", hooks.PostDestination, env)
+		},
+		OnUnchanged: func(d mkpr.Destination) {
+			env := append(destinationEnv(option, d), "MKPR_STATUS=skipped")
+			warnHook(context.Background(), "post_destination", hooks.PostDestination, env)
+		},
+	}
+}
+
+// runEnv returns the environment of the post_run hook.
+func runEnv(urls []string, failed []*mkpr.DestinationError) []string {
+	return []string{
+		"MKPR_CREATED=" + strconv.Itoa(len(urls)),
+		"MKPR_FAILED=" + strconv.Itoa(len(failed)),
+		"MKPR_PR_URLS=" + strings.Join(urls, "\n"),
+	}
+}
@@ -0,0 +1,109 @@
+package options
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultCentralPath is the path of the org defaults in the central
+// repository.
+const DefaultCentralPath = "mkpr.yml"
+
+// _centralRepositories are looked up in the owner when the central
+// repository is not given.
+var _centralRepositories = []string{".github", "platform-config"}
+
+// Central locates the defaults of the org, shared by its configs, in a
+// repository read at run time, so the org policy is kept in one place.
+type Central struct {
+	// Repository holding the defaults, as owner/name. When empty, the .github
+	// repository of the owner of the config is looked up, then its
+	// platform-config one.
+	Repository string `yaml:"repository"`
+	Path       string `yaml:"path"` // DefaultCentralPath when empty.
+	Ref        string `yaml:"ref"`  // branch or tag, the default branch when empty.
+}
+
+// Defaults is the content of the central file. The config owner replaces the
+// central one, the greater of the minimum approvals applies and the rest adds
+// to the settings of the config.
+type Defaults struct {
+	Owner         string           `yaml:"owner"`
+	Reviewers     []string         `yaml:"reviewers"`
+	LabelRules    []mkpr.LabelRule `yaml:"label_rules"`
+	FreezeWindows []FreezeWindow   `yaml:"freeze_windows"`
+	MinApprovals  int              `yaml:"min_approvals"`
+}
+
+// Load reads the defaults of the central repository with r, returning them
+// along with where they were found, as owner/name:path.
+func (c Central) Load(ctx context.Context, r provider.FileReader, owner string) (Defaults, string, error) {
+	path := c.Path
+	if path == "" {
+		path = DefaultCentralPath
+	}
+
+	repositories := []string{c.Repository}
+	if c.Repository == "" {
+		repositories = nil
+		for _, v := range _centralRepositories {
+			repositories = append(repositories, owner+"/"+v)
+		}
+	}
+
+	for _, v := range repositories {
+		parts := strings.SplitN(v, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return Defaults{}, "", fmt.Errorf("invalid central repository %q, expected owner/name", v)
+		}
+
+		content, err := r.GetFile(ctx, parts[0], parts[1], c.Ref, path)
+		switch {
+		case errors.Is(err, provider.ErrFileNotFound):
+			continue
+		case err != nil:
+			return Defaults{}, "", fmt.Errorf("unable to read the central config %s:%s: %w", v, path, err)
+		}
+
+		var d Defaults
+		if err := yaml.Unmarshal(content, &d); err != nil {
+			return Defaults{}, "", fmt.Errorf("invalid central config %s:%s: %w", v, path, err)
+		}
+
+		return d, v + ":" + path, nil
+	}
+
+	return Defaults{}, "", fmt.Errorf("no central config %s in %s", path, strings.Join(repositories, " nor "))
+}
+
+// Apply completes the config with the defaults.
+func (d Defaults) Apply(config *Config) {
+	if config.Owner == "" {
+		config.Owner = d.Owner
+	}
+
+	seen := make(map[string]bool, len(config.Reviewers))
+	for _, v := range config.Reviewers {
+		seen[strings.ToLower(v)] = true
+	}
+	for _, v := range d.Reviewers {
+		if !seen[strings.ToLower(v)] {
+			seen[strings.ToLower(v)] = true
+			config.Reviewers = append(config.Reviewers, v)
+		}
+	}
+
+	config.LabelRules = append(config.LabelRules, d.LabelRules...)
+	config.FreezeWindows = append(config.FreezeWindows, d.FreezeWindows...)
+
+	// a config can require more approvals than the org, not fewer.
+	if d.MinApprovals > config.MinApprovals {
+		config.MinApprovals = d.MinApprovals
+	}
+}
@@ -1,19 +1,103 @@
 package options
 
 import (
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
 	"os"
 
-	"github.com/sorfino/go-toolkit-cmd/internal/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
 	"gopkg.in/yaml.v3"
 )
 
-func ParseFile(path string) (mkpr.BatchPullRequestOption, error) {
-	var options mkpr.BatchPullRequestOption
+// Config is the content of a config file: the options of the batch and the
+// settings of the command running it.
+type Config struct {
+	mkpr.BatchPullRequestOption `yaml:",inline"`
+
+	HTTP   transport.Options `yaml:"http"`   // proxy, TLS and timeout settings.
+	Hooks  Hooks             `yaml:"hooks"`  // commands run around the batch.
+	Notify Notify            `yaml:"notify"` // where the summary of the run is sent.
+
+	// FreezeWindows are the periods the runs push nothing in.
+	FreezeWindows []FreezeWindow `yaml:"freeze_windows"`
+
+	// MinApprovals is the number of approvals "mkpr merge" requires of each
+	// pull request, none requesting changes, whatever the branch protection.
+	MinApprovals int `yaml:"min_approvals"`
+
+	// Central, when set, completes the config with the defaults of the org
+	// read from a central repository at run time.
+	Central *Central `yaml:"central"`
+}
+
+// Validate verifies the options of the batch and the settings of the command.
+func (c Config) Validate() error {
+	if c.MinApprovals < 0 {
+		return errors.New("min_approvals cannot be negative")
+	}
+
+	for _, v := range c.FreezeWindows {
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+
+	return c.BatchPullRequestOption.Validate()
+}
+
+// ParseFile parses the config file at path.
+func ParseFile(path string) (Config, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
-		return options, err
+		return Config{}, err
+	}
+
+	return parse(content)
+}
+
+// ParseFS parses the config file at path in fsys, for instance, an embedded
+// config or a test fixture.
+func ParseFS(fsys fs.FS, path string) (Config, error) {
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return parse(content)
+}
+
+// Parse parses the config read from r.
+func Parse(r io.Reader) (Config, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return parse(content)
+}
+
+// parse migrates the config to the current schema before decoding it.
+func parse(content []byte) (Config, error) {
+	var config Config
+	document := make(map[string]interface{})
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return config, err
+	}
+
+	if err := migrate(document); err != nil {
+		return config, err
+	}
+
+	// the migrated document is encoded back so the decoding rules of the
+	// option types apply regardless of the original version.
+	content, err := yaml.Marshal(document)
+	if err != nil {
+		return config, err
 	}
 
-	err = yaml.Unmarshal(content, &options)
-	return options, err
+	err = yaml.Unmarshal(content, &config)
+	return config, err
 }
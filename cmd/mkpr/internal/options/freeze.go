@@ -0,0 +1,37 @@
+package options
+
+import (
+	"errors"
+	"time"
+)
+
+// FreezeWindow is a period no change is pushed in, for instance, a release or
+// holiday freeze.
+type FreezeWindow struct {
+	Start  time.Time `yaml:"start"` // RFC 3339, for instance, 2026-12-20T00:00:00Z.
+	End    time.Time `yaml:"end"`
+	Reason string    `yaml:"reason"`
+}
+
+func (w FreezeWindow) validate() error {
+	if w.Start.IsZero() || w.End.IsZero() {
+		return errors.New("freeze windows need a start and an end")
+	}
+
+	if !w.End.After(w.Start) {
+		return errors.New("freeze windows must end after they start")
+	}
+
+	return nil
+}
+
+// Frozen returns the freeze window of the config now falls in, nil when none.
+func (c Config) Frozen(now time.Time) *FreezeWindow {
+	for i, v := range c.FreezeWindows {
+		if !now.Before(v.Start) && now.Before(v.End) {
+			return &c.FreezeWindows[i]
+		}
+	}
+
+	return nil
+}
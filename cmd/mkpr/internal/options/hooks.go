@@ -0,0 +1,10 @@
+package options
+
+// Hooks are shell commands run around the batch. The destination and the
+// outcome are given as environment variables, see the README.
+type Hooks struct {
+	PreRun          string `yaml:"pre_run"`          // the run is aborted when it fails.
+	PreDestination  string `yaml:"pre_destination"`  // the destination is skipped when it fails.
+	PostDestination string `yaml:"post_destination"` // run after each destination, succeeded or not.
+	PostRun         string `yaml:"post_run"`
+}
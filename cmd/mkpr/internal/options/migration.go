@@ -0,0 +1,90 @@
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// SchemaVersion is the version of the config file schema understood by this
+// release. Config files without a version field are considered version 1.
+const SchemaVersion = 2
+
+// migration upgrades a decoded config document from one version to the next.
+type migration func(document map[string]interface{}) error
+
+// _migrations is indexed by the version each migration upgrades from.
+var _migrations = map[int]migration{
+	1: migrateFilesToMapForm,
+}
+
+// migrate upgrades the given document in place up to SchemaVersion.
+func migrate(document map[string]interface{}) error {
+	version := 1
+	if v, ok := document["version"]; ok {
+		n, ok := v.(int)
+		if !ok {
+			return fmt.Errorf("invalid config version %v", v)
+		}
+		version = n
+	}
+
+	if version > SchemaVersion {
+		return fmt.Errorf("config version %d is not supported, the latest known version is %d", version, SchemaVersion)
+	}
+
+	for ; version < SchemaVersion; version++ {
+		m, ok := _migrations[version]
+		if !ok {
+			return fmt.Errorf("no migration found for config version %d", version)
+		}
+
+		if err := m(document); err != nil {
+			return fmt.Errorf("unable to migrate config from version %d: %w", version, err)
+		}
+	}
+
+	document["version"] = SchemaVersion
+	return nil
+}
+
+// migrateFilesToMapForm replaces the "local:target" strings of version 1 by
+// the source/target maps of version 2.
+func migrateFilesToMapForm(document map[string]interface{}) error {
+	v, ok := document["files"]
+	if !ok || v == nil {
+		return nil
+	}
+
+	files, ok := v.([]interface{})
+	if !ok {
+		return fmt.Errorf("files must be a list, got %T", v)
+	}
+
+	for i := range files {
+		arg, ok := files[i].(string)
+		if !ok {
+			return fmt.Errorf("file at position %d must be a string, got %T", i, files[i])
+		}
+
+		file := parseFileArg(arg)
+		files[i] = map[string]interface{}{
+			"source": file.Source,
+			"target": file.Target,
+		}
+	}
+
+	return nil
+}
+
+// parseFileArg parses the "local:target" syntax. If the file should be in the
+// same location with the same name, the target can be omitted.
+func parseFileArg(arg string) mkpr.File {
+	files := strings.SplitN(arg, ":", 2)
+	if len(files) == 1 {
+		return mkpr.File{Source: files[0], Target: files[0]}
+	}
+
+	return mkpr.File{Source: files[0], Target: files[1]}
+}
@@ -0,0 +1,69 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readDocument decodes the config document of the fixture.
+func readDocument(t *testing.T, name string) map[string]interface{} {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join("testdata", "migration", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		t.Fatal(err)
+	}
+
+	return document
+}
+
+// TestMigrate upgrades each testdata/migration/<name>.yml fixture, comparing
+// it with <name>.want.yml.
+func TestMigrate(t *testing.T) {
+	for _, name := range []string{"v1", "v1-no-files", "v2"} {
+		t.Run(name, func(t *testing.T) {
+			document := readDocument(t, name+".yml")
+			if err := migrate(document); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := readDocument(t, name+".want.yml"); !reflect.DeepEqual(document, want) {
+				t.Errorf("got %v, want %v", document, want)
+			}
+		})
+	}
+}
+
+func TestMigrateInvalid(t *testing.T) {
+	tests := []struct {
+		name, content, wantErr string
+	}{
+		{name: "newer version", content: "version: 3", wantErr: "config version 3 is not supported"},
+		{name: "version not a number", content: "version: two", wantErr: "invalid config version two"},
+		{name: "files not a list", content: "files: ci.yml", wantErr: "files must be a list"},
+		{name: "file not a string", content: "files: [{source: ci.yml}]", wantErr: "file at position 0 must be a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document map[string]interface{}
+			if err := yaml.Unmarshal([]byte(tt.content), &document); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := migrate(document); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package options
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/notify"
+)
+
+// Notify is where the summary of the runs, other than dry runs, is sent.
+type Notify struct {
+	OnlyFailures bool     `yaml:"only_failures"` // skips the runs without failures.
+	Slack        *Webhook `yaml:"slack"`         // Slack incoming webhook.
+	Teams        *Webhook `yaml:"teams"`         // Microsoft Teams incoming webhook or workflow.
+	Email        *Email   `yaml:"email"`         // sent through an SMTP server.
+	Issue        *Issue   `yaml:"issue"`         // commented on, with the provider of the config.
+}
+
+// Issue is an issue or pull request the summary is commented on, such as the
+// tracking issue of a migration.
+type Issue struct {
+	Repository string `yaml:"repository"` // name, in the owner of the config, or owner/name.
+	Number     int    `yaml:"number"`
+}
+
+func (i Issue) notifier(c notify.Commenter) (notify.Comment, error) {
+	n := notify.Comment{Commenter: c, Repository: i.Repository, Number: i.Number}
+	if parts := strings.SplitN(i.Repository, "/", 2); len(parts) == 2 {
+		n.Owner, n.Repository = parts[0], parts[1]
+	}
+
+	switch {
+	case n.Repository == "" || i.Number <= 0:
+		return n, errors.New("repository and number are required")
+	case c == nil:
+		return n, errors.New("the provider cannot comment on issues")
+	}
+
+	return n, nil
+}
+
+// Email is sent through an SMTP server, with the summary attached as HTML and
+// CSV. The password, a secret, is given through an environment variable.
+type Email struct {
+	SMTP        string   `yaml:"smtp"` // host:port of the server.
+	Username    string   `yaml:"username"`
+	PasswordEnv string   `yaml:"password_env"` // variable holding the password.
+	From        string   `yaml:"from"`
+	To          []string `yaml:"to"`
+	Subject     string   `yaml:"subject"` // text/template of the summary, its title by default.
+}
+
+func (e Email) notifier() (notify.Email, error) {
+	n := notify.Email{Addr: e.SMTP, Username: e.Username, From: e.From, To: e.To, Subject: e.Subject}
+	if e.PasswordEnv != "" {
+		n.Password = os.Getenv(e.PasswordEnv)
+		if n.Password == "" {
+			return n, fmt.Errorf("%s is not set", e.PasswordEnv)
+		}
+	}
+
+	return n, n.Validate()
+}
+
+// Webhook is the URL of an incoming webhook, given directly or, as it is a
+// secret, through an environment variable.
+type Webhook struct {
+	URL    string `yaml:"url"`
+	URLEnv string `yaml:"url_env"` // variable holding the URL.
+}
+
+func (w Webhook) url() (string, error) {
+	if w.URLEnv == "" {
+		if w.URL == "" {
+			return "", errors.New("url or url_env is required")
+		}
+		return w.URL, nil
+	}
+
+	v := os.Getenv(w.URLEnv)
+	if v == "" {
+		return "", fmt.Errorf("%s is not set", w.URLEnv)
+	}
+
+	return v, nil
+}
+
+// Notifiers returns the notifiers of the config, issue comments being posted
+// with c.
+func (n Notify) Notifiers(c notify.Commenter) ([]notify.Notifier, error) {
+	var out []notify.Notifier
+	if n.Slack != nil {
+		url, err := n.Slack.url()
+		if err != nil {
+			return nil, fmt.Errorf("invalid slack notification: %w", err)
+		}
+		out = append(out, notify.Slack{WebhookURL: url})
+	}
+
+	if n.Teams != nil {
+		url, err := n.Teams.url()
+		if err != nil {
+			return nil, fmt.Errorf("invalid teams notification: %w", err)
+		}
+		out = append(out, notify.Teams{WebhookURL: url})
+	}
+
+	if n.Email != nil {
+		email, err := n.Email.notifier()
+		if err != nil {
+			return nil, fmt.Errorf("invalid email notification: %w", err)
+		}
+		out = append(out, email)
+	}
+
+	if n.Issue != nil {
+		comment, err := n.Issue.notifier(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue notification: %w", err)
+		}
+		out = append(out, comment)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,108 @@
+package options
+
+import (
+	"strings"
+	"time"
+)
+
+// Override holds the values given through command line flags that take
+// precedence over the ones parsed from the config file.
+type Override struct {
+	Head          string
+	Owner         string
+	Delay         time.Duration
+	Subject       string
+	Body          string
+	CommitMessage string
+	Files         []string // "local:target" files appended to the ones of the config file.
+	GitHubURL     string
+	UploadURL     string
+
+	Proxy              string
+	CABundle           string
+	InsecureSkipVerify bool
+	Timeout            string
+	CacheDir           string
+	MaxRPS             float64
+	UserAgent          string
+}
+
+// Apply replaces every non empty field of the config with the overridden value.
+func (o Override) Apply(config *Config) {
+	option := &config.BatchPullRequestOption
+	if o.Head != "" {
+		option.Head = o.Head
+	}
+
+	if o.Owner != "" {
+		option.Owner = o.Owner
+	}
+
+	if o.Delay > 0 {
+		option.Delay = o.Delay.String()
+	}
+
+	if o.Subject != "" {
+		option.Subject = o.Subject
+	}
+
+	if o.Body != "" {
+		option.Body = o.Body
+	}
+
+	if o.CommitMessage != "" {
+		option.CommitMessage = o.CommitMessage
+	}
+
+	if o.GitHubURL != "" {
+		option.GitHubURL = o.GitHubURL
+	}
+
+	if o.UploadURL != "" {
+		option.UploadURL = o.UploadURL
+	}
+
+	if o.Proxy != "" {
+		config.HTTP.Proxy = o.Proxy
+	}
+
+	if o.CABundle != "" {
+		config.HTTP.CABundle = o.CABundle
+	}
+
+	if o.InsecureSkipVerify {
+		config.HTTP.InsecureSkipVerify = true
+	}
+
+	if o.Timeout != "" {
+		config.HTTP.Timeout = o.Timeout
+	}
+
+	if o.CacheDir != "" {
+		config.HTTP.CacheDir = o.CacheDir
+	}
+
+	if o.MaxRPS > 0 {
+		config.HTTP.MaxRPS = o.MaxRPS
+	}
+
+	if o.UserAgent != "" {
+		config.HTTP.UserAgent = o.UserAgent
+	}
+
+	for _, v := range o.Files {
+		option.Files = append(option.Files, parseFileArg(v))
+	}
+}
+
+// StringList is a flag.Value that can be set several times.
+type StringList []string
+
+func (s *StringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *StringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
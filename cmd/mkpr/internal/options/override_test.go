@@ -0,0 +1,20 @@
+package options
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverrideApply(t *testing.T) {
+	var config Config
+	config.Head, config.Owner, config.Delay, config.Subject = "lsc", "acme", "1s", "Large scale change"
+
+	Override{Owner: "example", Delay: 5 * time.Second, Head: "lsc-2"}.Apply(&config)
+
+	if config.Owner != "example" || config.Delay != "5s" || config.Head != "lsc-2" {
+		t.Errorf("got owner %q, delay %q and head %q, want the overridden ones", config.Owner, config.Delay, config.Head)
+	}
+	if config.Subject != "Large scale change" {
+		t.Errorf("got subject %q, want the one of the config file kept", config.Subject)
+	}
+}
@@ -1,3 +1,48 @@
 package options
 
-const Version = "0.2.0"
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version, Commit and Date describe the build of mkpr, and PublicKey is the
+// base64 Ed25519 key its releases are signed with. Release builds set them with
+// the linker, for instance:
+//
+//	go build -ldflags "-X github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options.Commit=$(git rev-parse HEAD)"
+var (
+	Version = "0.2.0"
+	Commit  = ""
+	Date    = "" // RFC 3339.
+
+	// PublicKey verifies the releases installed by "mkpr self-update".
+	PublicKey = ""
+)
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Build returns the build information of the running binary. The version of
+// the module is preferred to Version when installed with "go install
+// module@version", which sets no linker flag.
+func Build() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok && Commit == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+
+	return info
+}
@@ -0,0 +1,122 @@
+// Package templates loads the reusable changes applied by "mkpr apply-template".
+//
+// A template is a directory holding a template.yml config file, with the same
+// schema as regular config files, and the files it references. Sources are
+// relative to the template directory and are always rendered for each
+// destination, so the user only needs to supply the destinations and the
+// variables of the template.
+package templates
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+)
+
+// ConfigFile is the name of the config file of each template.
+const ConfigFile = "template.yml"
+
+// Load reads the template named name from the templates directory dir.
+func Load(dir, name string) (options.Config, error) {
+	root := filepath.Join(dir, name)
+	config, err := options.ParseFile(filepath.Join(root, ConfigFile))
+	if err != nil {
+		return config, fmt.Errorf("unable to load template %s: %w", name, err)
+	}
+
+	option := &config.BatchPullRequestOption
+	for i := range option.Files {
+		if option.Files[i].Target == "" {
+			option.Files[i].Target = option.Files[i].Source
+		}
+		option.Files[i].Source = filepath.Join(root, option.Files[i].Source)
+	}
+
+	option.Render = true
+	return config, nil
+}
+
+// List returns the names of the templates found in the templates directory dir.
+func List(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, v := range entries {
+		if !v.IsDir() {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, v.Name(), ConfigFile)); err == nil {
+			names = append(names, v.Name())
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// Fetch downloads the directory dir of a templates repository into a
+// temporary directory and returns its location. The caller is responsible for
+// removing it.
+func Fetch(ctx context.Context, client *github.Client, owner, repo, ref, dir string) (string, error) {
+	tmp, err := ioutil.TempDir("", "mkpr-templates")
+	if err != nil {
+		return "", err
+	}
+
+	opt := &github.RepositoryContentGetOptions{Ref: ref}
+	if err := fetch(ctx, client, owner, repo, dir, tmp, opt); err != nil {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("unable to fetch templates from %s/%s: %w", owner, repo, err)
+	}
+
+	return tmp, nil
+}
+
+func fetch(ctx context.Context, client *github.Client, owner, repo, dir, dst string, opt *github.RepositoryContentGetOptions) error {
+	_, entries, _, err := client.Repositories.GetContents(ctx, owner, repo, dir, opt)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range entries {
+		local := filepath.Join(dst, v.GetName())
+		remote := path.Join(dir, v.GetName())
+		switch v.GetType() {
+		case "dir":
+			if err := os.MkdirAll(local, 0o755); err != nil {
+				return err
+			}
+
+			if err := fetch(ctx, client, owner, repo, remote, local, opt); err != nil {
+				return err
+			}
+		case "file":
+			file, _, _, err := client.Repositories.GetContents(ctx, owner, repo, remote, opt)
+			if err != nil {
+				return err
+			}
+
+			content, err := file.GetContent()
+			if err != nil {
+				return err
+			}
+
+			if err := ioutil.WriteFile(local, []byte(content), 0o600); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/credentials"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+)
+
+// login runs "mkpr login", which obtains a token through the OAuth device
+// flow and saves it for later runs.
+func login(args []string) error {
+	fs := newFlagSet("login", "[flags]", "Obtains a token through the OAuth device flow and saves it in the system keyring for later runs.")
+	host := fs.String("host", credentials.DefaultHost, "GitHub host to log into")
+	clientID := fs.String("client-id", os.Getenv("MKPR_CLIENT_ID"), "Client ID of the OAuth App with the device flow enabled (MKPR_CLIENT_ID)")
+	scopes := fs.String("scopes", "repo,workflow", "Comma separated scopes to request")
+	insecureStorage := fs.Bool("insecure-storage", false, "Saves the token in plain text in the user config directory instead of the system keyring")
+	httpFlags := fleet.RegisterHTTPFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	base, err := transport.NewBase(*httpFlags)
+	if err != nil {
+		return err
+	}
+
+	if *clientID == "" {
+		return errors.New("an OAuth App client ID is required, use -client-id or MKPR_CLIENT_ID")
+	}
+
+	token, err := credentials.DeviceLogin(context.Background(), &http.Client{Transport: base}, *host, *clientID, strings.Split(*scopes, ","), func(code credentials.DeviceCode) {
+		fmt.Printf("open %s and enter the code %s\n", code.VerificationURI, code.UserCode)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := credentials.Store(*host, token, *insecureStorage); err != nil {
+		return fmt.Errorf("unable to save the token: %w", err)
+	}
+
+	fmt.Println("logged in.")
+	return nil
+}
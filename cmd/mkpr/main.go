@@ -2,62 +2,406 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
-	"github.com/sorfino/go-toolkit-cmd/internal/mkpr"
-	"golang.org/x/oauth2"
+	"github.com/sorfino/go-toolkit-cmd/internal/credentials"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/notify"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/internal/state"
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider/dryrun"
 )
 
-var (
-	_location *string = flag.String("config", "config.yml", "Location of config file")
-	_version  *bool   = flag.Bool("v", false, "Prints current version")
-)
+// command is a subcommand of mkpr, run when its name is the first argument.
+type command struct {
+	Summary string // listed by "mkpr help".
+	Run     func(args []string) error
+}
+
+// _commands are the subcommands of mkpr. Without one, the arguments are the
+// flags of "run", so the invocations predating the subcommands keep working.
+var _commands = map[string]command{
+	"run":            {"Creates the pull requests of a config", runBatch},
+	"preview":        {"Prints the changes a config would make, without making them", preview},
+	"validate":       {"Verifies a config without contacting the provider", validate},
+	"status":         {"Reports the pull requests of a config", status},
+	"merge":          {"Merges the open pull requests of a config", merge},
+	"close":          {"Closes the open pull requests of a config without merging them", closePullRequests},
+	"cleanup":        {"Deletes the head branches of the merged or closed pull requests of a config", cleanup},
+	"history":        {"Lists the recorded runs and shows their destinations", history},
+	"apply-template": {"Creates the pull requests of a named template", applyTemplate},
+	"daemon":         {"Runs the jobs of a jobs file on their schedule", daemon},
+	"webhook":        {"Runs the jobs of a jobs file on the pushes to a repository", webhook},
+	"serve":          {"Exposes an HTTP API to submit configs", serve},
+	"login":          {"Obtains a token through the OAuth device flow", login},
+	"self-update":    {"Replaces the binary with the one of the latest release", selfUpdate},
+	"version":        {"Prints the build information of mkpr", version},
+}
 
 func main() {
-	flag.Parse()
-	if *_version {
-		fmt.Println("Go-Toolkit Batch Pull Requester. Version " + options.Version)
-		os.Exit(0)
+	name, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		help(args)
+		return
+	}
+
+	cmd, ok := _commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.Run(args); err != nil {
+		report(err)
+		os.Exit(1)
+	}
+}
+
+// help runs "mkpr help [command]", printing the usage of mkpr or the flags of
+// the command.
+func help(args []string) {
+	if len(args) == 0 {
+		usage()
+		return
 	}
 
-	if err := run(); err != nil {
-		fmt.Printf("sorry: %s\n", err.Error())
+	cmd, ok := _commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+
+	cmd.Run([]string{"-h"})
+}
+
+// usage prints the commands of mkpr.
+func usage() {
+	names := make([]string, 0, len(_commands))
+	for name := range _commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "usage: mkpr <command> [flags]")
+	fmt.Fprintln(out, "\ncommands:")
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-16s%s\n", name, _commands[name].Summary)
+	}
+	fmt.Fprintln(out, "\nRun \"mkpr help <command>\" for the flags of a command. Without a command, the flags are the ones of run.")
+}
+
+// newFlagSet returns the flag set of a command, whose help shows its synopsis
+// and description before its flags.
+func newFlagSet(name, synopsis, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "usage: mkpr %s %s\n\n%s\n\nflags:\n", name, synopsis, description)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// report prints err along with a hint for the failures users can act on.
+func report(err error) {
+	fmt.Printf("sorry: %s\n", redact.String(err.Error()))
+	switch {
+	case errors.Is(err, mkpr.ErrRateLimited):
+		fmt.Println("hint: the API rate limit was exceeded, raise the delay or give more tokens.")
+	case errors.Is(err, mkpr.ErrPRExists):
+		fmt.Println("hint: a pull request from the head branch already exists, use another head.")
+	case errors.Is(err, mkpr.ErrNoPushAccess):
+		fmt.Println("hint: ask for write access to the repositories or remove them from the destinations.")
 	}
 }
 
-func run() error {
-	option, err := options.ParseFile(*_location)
+// runFlags are the settings of a batch run.
+type runFlags struct {
+	Verbose bool
+	DryRun  bool
+	Record  string // cassette to record the API interactions to.
+	Replay  string // cassette to answer the API requests from, offline.
+
+	Incremental bool   // skips the destinations whose change was already pushed.
+	State       string // state file holding the fingerprints of the changes.
+
+	Job string // name of the scheduled job run, recorded and notified.
+	ID  string // identifies the run, sent in the User-Agent of its requests.
+
+	LogRequests bool // logs the method, URL, status and request ID of the API requests.
+	Debug       bool // logs the bodies of the API requests and responses as well.
+}
+
+// registerRunFlags registers on fs the flags changing how the batch is run.
+func registerRunFlags(fs *flag.FlagSet) *runFlags {
+	var r runFlags
+	fs.BoolVar(&r.Verbose, "verbose", false, "Logs every step of the batch")
+	fs.BoolVar(&r.DryRun, "dry-run", false, "Prints the branches, commits and pull requests the batch would create, without creating them")
+	fs.StringVar(&r.Record, "record", "", "Records the API interactions of the run to the given cassette file")
+	fs.StringVar(&r.Replay, "replay", "", "Answers the API requests from the given cassette file instead of the provider, no token is needed")
+	fs.BoolVar(&r.Incremental, "incremental", false, "Skips the destinations whose rendered change matches the last successful run")
+	fs.StringVar(&r.State, "state", state.DefaultPath, "State file recording the changes pushed by the runs")
+	registerLogFlags(fs, &r)
+	return &r
+}
+
+// registerLogFlags registers on fs the flags logging the API requests of the
+// run.
+func registerLogFlags(fs *flag.FlagSet, r *runFlags) {
+	fs.BoolVar(&r.LogRequests, "log-requests", false, "Logs the method, URL, status, duration and request ID of every API request to stderr")
+	fs.BoolVar(&r.Debug, "debug", false, "Logs the API requests along with their bodies, redacted, to stderr")
+}
+
+// newRunHTTPClient returns the HTTP client of a batch run, replaying a cassette
+// or authenticated and recording one when asked, and logging its requests when
+// asked. save writes the recorded cassette.
+func newRunHTTPClient(source *credentials.Source, o transport.Options, basic bool, run runFlags) (tc *http.Client, save func() error, err error) {
+	save = func() error { return nil }
+	if run.Replay != "" {
+		replayer, err := transport.NewReplayer(run.Replay)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return logRequests(&http.Client{Transport: replayer}, run), save, nil
+	}
+
+	o.UserAgent = userAgent(o.UserAgent, run.ID)
+	tc, err = fleet.NewHTTPClient(source, o, basic)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	if run.Record != "" {
+		// recording outside of the authentication keeps the tokens away.
+		recorder := transport.NewRecorder(tc.Transport)
+		tc.Transport = recorder
+		save = func() error { return recorder.Save(run.Record) }
+	}
+
+	return logRequests(tc, run), save, nil
+}
+
+// userAgent returns the User-Agent of the requests of a run: the configured
+// one, mkpr and its version by default, followed by the ID of the run, so the
+// requests of a run can be found by the API host.
+func userAgent(configured, id string) string {
+	if configured == "" {
+		configured = "mkpr/" + options.Build().Version
+	}
+
+	if id == "" {
+		return configured
+	}
+
+	return configured + " (run " + id + ")"
+}
+
+// logRequests wraps the transport of tc to log its requests when asked, outside
+// of the authentication so no header is seen.
+func logRequests(tc *http.Client, run runFlags) *http.Client {
+	if run.LogRequests || run.Debug {
+		tc.Transport = transport.NewLogger(tc.Transport, log.New(redact.Writer(os.Stderr), "http: ", log.LstdFlags), run.Debug)
 	}
 
-	token := os.Getenv("GITHUB_AUTH_TOKEN")
-	if token == "" {
-		return errors.New("GITHUB_AUTH_TOKEN not set")
+	return tc
+}
+
+// newRunID returns a random identifier of a run.
+func newRunID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate the run ID: %w", err)
 	}
 
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(context.Background(), ts)
+	return hex.EncodeToString(b), nil
+}
 
+// execute creates the batch of pull requests and prints their URLs, running
+// the hooks of the config around it and sending its summary to the notifiers,
+// or prints the changes it would make on a dry run. record holds the results
+// of the destinations, even when the run failed.
+func execute(tc *http.Client, config options.Config, run runFlags) (record state.Run, err error) {
 	fmt.Println("hold ...")
-	cmd, err := mkpr.NewBatchPullRequestCommand(tc, option)
+	ctx := context.Background()
+	hooks := config.Hooks
+	if run.DryRun {
+		hooks = options.Hooks{}
+	}
+	if run.ID != "" {
+		fmt.Printf("run %s\n", run.ID)
+	}
+
+	record = state.Run{
+		ID:        run.ID,
+		Job:       run.Job,
+		StartedAt: time.Now().UTC(),
+		Owner:     config.OwnerOrDefault(),
+		Head:      config.Head,
+		Subject:   config.Subject,
+	}
+
+	// dry runs and replays pushed nothing worth recording nor notifying.
+	pushed := !run.DryRun && run.Replay == ""
+	var commenter notify.Commenter // the provider, once built, when able to.
+	if pushed {
+		defer func() {
+			notifyRun(ctx, config.Notify, commenter, runSummary(record, err))
+		}()
+	}
+
+	p, err := mkpr.NewProvider(tc, config.BatchPullRequestOption)
 	if err != nil {
-		return err
+		return record, err
 	}
+	commenter, _ = p.(notify.Commenter)
 
-	done, err := cmd.Do(context.Background())
-	for i := range done {
-		fmt.Println(done[i])
+	if err := applyCentral(ctx, p, &config); err != nil {
+		return record, err
 	}
+	record.Owner = config.OwnerOrDefault()
+	option := config.BatchPullRequestOption
+	option.RunID = run.ID
 
+	// dry runs push nothing, they are allowed during the freezes.
+	if w := config.Frozen(time.Now()); w != nil && !run.DryRun {
+		return record, fmt.Errorf("frozen until %s: %s", w.End.Format(time.RFC3339), w.Reason)
+	}
+
+	if err := runHook(ctx, "pre_run", hooks.PreRun, nil); err != nil {
+		return record, err
+	}
+
+	var preview *dryrun.Provider
+	if run.DryRun {
+		preview = dryrun.New(p)
+		p = preview
+	}
+
+	cmd, err := mkpr.NewBatchPullRequestCommandWithProvider(p, option)
 	if err != nil {
-		return err
+		return record, err
+	}
+
+	if run.Verbose {
+		cmd.Logger = log.New(redact.Writer(os.Stderr), "", log.LstdFlags)
+	}
+	cmd.Hooks = commandHooks(hooks, option)
+
+	if run.Incremental {
+		db, err := state.Open(run.State)
+		if err != nil {
+			return record, err
+		}
+
+		cmd.Fingerprints = db
+		if run.DryRun {
+			cmd.Fingerprints = readOnlyFingerprints{db}
+		}
+	}
+
+	results, err := cmd.DoStream(ctx)
+	if err != nil {
+		return record, err
+	}
+
+	// print the pull requests as they are created, reporting the failures at
+	// the end.
+	var (
+		urls   []string
+		failed []*mkpr.DestinationError
+	)
+	for r := range results {
+		if r.URL != "" {
+			fmt.Println(r.URL)
+			urls = append(urls, r.URL)
+		}
+		for _, v := range r.Parts {
+			fmt.Println(v.URL)
+			urls = append(urls, v.URL)
+		}
+
+		if v := r.Verification; v != nil && !v.Verified {
+			fmt.Printf("%s: warning: the commit is not verified (%s), signed commits may be required\n", r.Repository, v.Reason)
+		}
+
+		if r.Unchanged {
+			fmt.Printf("%s: unchanged since the last run, skipped\n", r.Repository)
+		}
+
+		result := state.Result{Repository: r.Repository, Head: r.Head, URL: r.URL, Unchanged: r.Unchanged}
+		if r.Err != nil {
+			failed = append(failed, &mkpr.DestinationError{Repository: r.Repository, Err: r.Err})
+			result.Error = redact.String(r.Err.Error())
+		}
+		record.Results = append(record.Results, result)
+
+		// every part of a split change is recorded, to be found with -run.
+		for _, v := range r.Parts {
+			record.Results = append(record.Results, state.Result{Repository: r.Repository, Head: v.Head, URL: v.URL})
+		}
+	}
+
+	if pushed {
+		record.FinishedAt = time.Now().UTC()
+		if err := recordRun(run.State, record); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: unable to record the run: %s\n", redact.String(err.Error()))
+		}
+	}
+
+	warnHook(ctx, "post_run", hooks.PostRun, runEnv(urls, failed))
+
+	if preview != nil {
+		if err := preview.Render(os.Stdout); err != nil {
+			return record, err
+		}
+	}
+
+	if len(failed) > 0 {
+		return record, &mkpr.BatchError{Errors: failed}
 	}
 
 	fmt.Println("done.")
+	return record, nil
+}
+
+// recordRun adds the run to the state file.
+func recordRun(path string, r state.Run) error {
+	db, err := state.Open(path)
+	if err != nil {
+		return err
+	}
+
+	return db.AddRun(r)
+}
+
+// readOnlyFingerprints skips the unchanged destinations without recording the
+// changes, which are not pushed on a dry run.
+type readOnlyFingerprints struct {
+	mkpr.Fingerprints
+}
+
+func (readOnlyFingerprints) SetFingerprint(key, fingerprint string) error {
 	return nil
 }
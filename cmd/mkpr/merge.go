@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// merge runs "mkpr merge", which merges the open pull requests created by a
+// config once the rollout is approved.
+func merge(args []string) error {
+	fs := newFlagSet("merge", "[flags]", "Merges the open pull requests from the head branch of the config, or created by the run given with -run, on every destination.")
+	cf := registerConfigFlags(fs)
+	sel := registerSelectionFlags(fs)
+	method := fs.String("method", "", "Merge method: merge, squash or rebase, the default one of the provider when empty")
+	minApprovals := fs.Int("min-approvals", 0, "Approvals required of each pull request, none requesting changes, at least the min_approvals of the config")
+	dryRun := fs.Bool("dry-run", false, "Prints the pull requests that would be merged, without merging them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch provider.MergeMethod(*method) {
+	case "", provider.MergeCommit, provider.MergeSquash, provider.MergeRebase:
+	default:
+		return fmt.Errorf("invalid merge method %q, expected merge, squash or rebase", *method)
+	}
+
+	if *minApprovals < 0 {
+		return fmt.Errorf("invalid min approvals %d", *minApprovals)
+	}
+
+	config, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	p, err := cf.provider(&config)
+	if err != nil {
+		return err
+	}
+
+	if err := sel.apply(&config); err != nil {
+		return err
+	}
+
+	// merging pushes to the base branches, which the freezes protect.
+	if w := config.Frozen(time.Now()); w != nil && !*dryRun {
+		return fmt.Errorf("frozen until %s: %s", w.End.Format(time.RFC3339), w.Reason)
+	}
+
+	// the config, completed by the central defaults, sets the floor.
+	if config.MinApprovals > *minApprovals {
+		*minApprovals = config.MinApprovals
+	}
+
+	lister, ok := p.(provider.ReviewLister)
+	if *minApprovals > 0 && !ok {
+		return fmt.Errorf("the provider cannot list reviews, required by %d min approvals", *minApprovals)
+	}
+
+	fmt.Println("hold ...")
+	owner := config.OwnerOrDefault()
+	err = fleet.Run(context.Background(), fleetConfig(config), func(ctx context.Context, d mkpr.Destination) (string, error) {
+		pulls, err := sel.pullRequests(ctx, p, config, d)
+		if err != nil {
+			return "", err
+		}
+
+		var merged, held []string
+		for _, v := range pulls {
+			if v.State != provider.StateOpen {
+				continue
+			}
+
+			number := fmt.Sprintf("#%d", v.Number)
+			if *minApprovals > 0 {
+				reviews, err := lister.ListReviews(ctx, owner, d.Repository, v.Number)
+				if err != nil {
+					return "", fmt.Errorf("unable to list the reviews of %s: %w", number, err)
+				}
+
+				if reason := approval(reviews, *minApprovals); reason != "" {
+					held = append(held, number+" ("+reason+")")
+					continue
+				}
+			}
+
+			if !*dryRun {
+				if err := p.MergePullRequest(ctx, owner, d.Repository, v.Number, provider.MergeMethod(*method)); err != nil {
+					return "", fmt.Errorf("unable to merge %s: %w", number, err)
+				}
+			}
+			merged = append(merged, number)
+		}
+
+		var summary []string
+		switch {
+		case len(merged) == 0 && len(held) == 0:
+			return "no open pull request", nil
+		case len(merged) == 0:
+		case *dryRun:
+			summary = append(summary, "would merge "+strings.Join(merged, ", "))
+		default:
+			summary = append(summary, "merged "+strings.Join(merged, ", "))
+		}
+		if len(held) > 0 {
+			summary = append(summary, "held "+strings.Join(held, ", "))
+		}
+
+		return strings.Join(summary, "; "), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+// approval returns why the pull request of the reviews is not approved by at
+// least min reviewers, or an empty string when it is. The latest approval,
+// change request or dismissal of each reviewer counts, the comments leaving
+// it as it is.
+func approval(reviews []provider.Review, min int) string {
+	var (
+		reviewers []string
+		latest    = make(map[string]string)
+	)
+	for _, v := range reviews {
+		if v.State == provider.ReviewCommented {
+			continue
+		}
+
+		reviewer := strings.ToLower(v.Reviewer)
+		if _, ok := latest[reviewer]; !ok {
+			reviewers = append(reviewers, v.Reviewer)
+		}
+		latest[reviewer] = v.State
+	}
+
+	var (
+		approvals int
+		changes   []string
+	)
+	for _, v := range reviewers {
+		switch latest[strings.ToLower(v)] {
+		case provider.ReviewApproved:
+			approvals++
+		case provider.ReviewChangesRequested:
+			changes = append(changes, v)
+		}
+	}
+
+	switch {
+	case len(changes) > 0:
+		return "changes requested by " + strings.Join(changes, ", ")
+	case approvals < min:
+		return fmt.Sprintf("%d/%d approvals", approvals, min)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+func TestApproval(t *testing.T) {
+	review := func(reviewer, state string) provider.Review {
+		return provider.Review{Reviewer: reviewer, State: state}
+	}
+
+	tests := []struct {
+		name    string
+		reviews []provider.Review
+		min     int
+		want    string
+	}{
+		{name: "no review", min: 1, want: "0/1 approvals"},
+		{name: "no review required", min: 0, want: ""},
+		{
+			name:    "approved",
+			reviews: []provider.Review{review("alice", provider.ReviewApproved), review("bob", provider.ReviewApproved)},
+			min:     2,
+			want:    "",
+		},
+		{
+			name:    "same reviewer counted once",
+			reviews: []provider.Review{review("alice", provider.ReviewApproved), review("alice", provider.ReviewApproved)},
+			min:     2,
+			want:    "1/2 approvals",
+		},
+		{
+			name:    "reviewer case folded",
+			reviews: []provider.Review{review("Alice", provider.ReviewApproved), review("alice", provider.ReviewApproved)},
+			min:     2,
+			want:    "1/2 approvals",
+		},
+		{
+			name:    "latest review counts",
+			reviews: []provider.Review{review("alice", provider.ReviewChangesRequested), review("alice", provider.ReviewApproved)},
+			min:     1,
+			want:    "",
+		},
+		{
+			name:    "comment leaves the review",
+			reviews: []provider.Review{review("alice", provider.ReviewApproved), review("alice", provider.ReviewCommented)},
+			min:     1,
+			want:    "",
+		},
+		{
+			name:    "dismissed approval",
+			reviews: []provider.Review{review("alice", provider.ReviewApproved), review("alice", provider.ReviewDismissed)},
+			min:     1,
+			want:    "0/1 approvals",
+		},
+		{
+			name:    "dismissed change request",
+			reviews: []provider.Review{review("alice", provider.ReviewChangesRequested), review("ALICE", provider.ReviewDismissed), review("bob", provider.ReviewApproved)},
+			min:     1,
+			want:    "",
+		},
+		{
+			name: "changes requested",
+			reviews: []provider.Review{
+				review("alice", provider.ReviewApproved),
+				review("Bob", provider.ReviewChangesRequested),
+				review("carol", provider.ReviewApproved),
+				review("carol", provider.ReviewChangesRequested),
+			},
+			min:  1,
+			want: "changes requested by Bob, carol",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := approval(tt.reviews, tt.min); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
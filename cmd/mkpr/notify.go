@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/internal/notify"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/internal/state"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// runSummary returns the summary of the recorded run, which err stopped
+// before processing the destinations unless it is the *mkpr.BatchError of
+// the failed ones.
+func runSummary(r state.Run, err error) notify.Summary {
+	s := notify.Summary{
+		ID:        r.ID,
+		Job:       r.Job,
+		Owner:     r.Owner,
+		Subject:   r.Subject,
+		StartedAt: r.StartedAt,
+		Duration:  time.Since(r.StartedAt).Round(time.Second),
+	}
+
+	for _, v := range r.Results {
+		s.Results = append(s.Results, notify.Result{Repository: v.Repository, Head: v.Head, URL: v.URL, Unchanged: v.Unchanged, Error: v.Error})
+		switch {
+		case v.Error != "":
+			s.Failed = append(s.Failed, notify.Failure{Repository: v.Repository, Error: v.Error})
+		case v.Unchanged:
+			s.Unchanged++
+		case v.URL != "":
+			s.Created = append(s.Created, v.URL)
+		}
+	}
+
+	var batch *mkpr.BatchError
+	if err != nil && !errors.As(err, &batch) {
+		s.Err = redact.String(err.Error())
+	}
+
+	return s
+}
+
+// notifyRun sends the summary to the notifiers of the config, commenting on
+// issues with c. Failures are only reported, the run being over.
+func notifyRun(ctx context.Context, n options.Notify, c notify.Commenter, s notify.Summary) {
+	if n.OnlyFailures && s.OK() {
+		return
+	}
+
+	notifiers, err := n.Notifiers(c)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", redact.String(err.Error()))
+		return
+	}
+
+	for _, v := range notifiers {
+		if err := v.Notify(ctx, s); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: unable to notify: %s\n", redact.String(err.Error()))
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/internal/credentials"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/state"
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// configFlags select the config file of a command and override its settings.
+type configFlags struct {
+	Location  string
+	Head      string
+	Owner     string
+	GitHubURL string
+	UploadURL string
+
+	// overrides of the change, only registered by the commands making it.
+	Delay         time.Duration
+	Subject       string
+	Body          string
+	CommitMessage string
+	Files         options.StringList
+
+	Credentials *credentials.Source
+	HTTP        *transport.Options
+}
+
+// registerConfigFlags registers on fs the flags selecting the config file, its
+// head and provider endpoints, and the credentials and HTTP settings.
+func registerConfigFlags(fs *flag.FlagSet) *configFlags {
+	var f configFlags
+	fs.StringVar(&f.Location, "config", "config.yml", "Location of config file")
+	fs.StringVar(&f.Head, "head", "", "Overrides the head branch of the config file")
+	fs.StringVar(&f.Owner, "owner", "", "Overrides the owner of the destinations of the config file")
+	fs.StringVar(&f.GitHubURL, "github-url", "", "GitHub Enterprise Server API URL, overrides the one of the config file")
+	fs.StringVar(&f.UploadURL, "upload-url", "", "GitHub Enterprise Server uploads URL, overrides the one of the config file")
+	f.Credentials = fleet.RegisterCredentialFlags(fs)
+	f.HTTP = fleet.RegisterHTTPFlags(fs)
+	return &f
+}
+
+// registerChangeFlags registers on fs the flags overriding the change of the
+// config file.
+func (f *configFlags) registerChangeFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&f.Delay, "delay", 0, "Overrides the delay between the destinations of the config file, for instance, 5s")
+	fs.StringVar(&f.Subject, "subject", "", "Overrides the pull request subject of the config file")
+	fs.StringVar(&f.Body, "body", "", "Overrides the pull request body of the config file")
+	fs.StringVar(&f.CommitMessage, "commit-message", "", "Overrides the commit message of the config file")
+	fs.Var(&f.Files, "file", "File to commit in addition to the ones of the config file (local[:target]), can be repeated")
+}
+
+// load parses the config file, applying the overrides of the flags.
+func (f *configFlags) load() (options.Config, error) {
+	config, err := options.ParseFile(f.Location)
+	if err != nil {
+		return config, err
+	}
+
+	options.Override{
+		Head:          f.Head,
+		Owner:         f.Owner,
+		Delay:         f.Delay,
+		Subject:       f.Subject,
+		Body:          f.Body,
+		CommitMessage: f.CommitMessage,
+		Files:         f.Files,
+		GitHubURL:     f.GitHubURL,
+		UploadURL:     f.UploadURL,
+
+		Proxy:              f.HTTP.Proxy,
+		CABundle:           f.HTTP.CABundle,
+		InsecureSkipVerify: f.HTTP.InsecureSkipVerify,
+		Timeout:            f.HTTP.Timeout,
+		CacheDir:           f.HTTP.CacheDir,
+		MaxRPS:             f.HTTP.MaxRPS,
+		UserAgent:          f.HTTP.UserAgent,
+	}.Apply(&config)
+
+	return config, nil
+}
+
+// provider returns the provider of the config, authenticated with the
+// credentials of the flags, and completes the config with its central
+// defaults.
+func (f *configFlags) provider(config *options.Config) (provider.Provider, error) {
+	o := config.HTTP
+	o.UserAgent = userAgent(o.UserAgent, "")
+	f.Credentials.Host = config.Host()
+	tc, err := fleet.NewHTTPClient(f.Credentials, o, config.BasicAuth())
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := mkpr.NewProvider(tc, config.BatchPullRequestOption)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, applyCentral(context.Background(), p, config)
+}
+
+// applyCentral completes the config with the defaults of its central
+// repository, when it has one, read through p.
+func applyCentral(ctx context.Context, p provider.Provider, config *options.Config) error {
+	if config.Central == nil {
+		return nil
+	}
+
+	reader, ok := p.(provider.FileReader)
+	if !ok {
+		return errors.New("the provider cannot read the central config")
+	}
+
+	defaults, location, err := config.Central.Load(ctx, reader, config.OwnerOrDefault())
+	if err != nil {
+		return err
+	}
+
+	defaults.Apply(config)
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid config with the defaults of %s: %w", location, err)
+	}
+
+	return nil
+}
+
+// runBatch runs "mkpr run", which creates the pull requests of a config.
+func runBatch(args []string) error {
+	fs := newFlagSet("run", "[flags]", "Creates the branch, commit and pull request of the config on every destination, printing their URLs.")
+	cf := registerConfigFlags(fs)
+	cf.registerChangeFlags(fs)
+	run := registerRunFlags(fs)
+	version := fs.Bool("v", false, "Prints current version, see mkpr version")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *version {
+		printVersion(options.Build())
+		return nil
+	}
+
+	return batch(cf, *run)
+}
+
+// preview runs "mkpr preview", which prints the changes a config would make,
+// like "mkpr run -dry-run".
+func preview(args []string) error {
+	fs := newFlagSet("preview", "[flags]", "Prints the branches, commits and pull requests the config would create, without creating them.")
+	cf := registerConfigFlags(fs)
+	cf.registerChangeFlags(fs)
+	run := runFlags{DryRun: true}
+	fs.BoolVar(&run.Verbose, "verbose", false, "Logs every step of the batch")
+	fs.BoolVar(&run.Incremental, "incremental", false, "Skips the destinations whose rendered change matches the last successful run")
+	fs.StringVar(&run.State, "state", state.DefaultPath, "State file recording the changes pushed by the runs")
+	registerLogFlags(fs, &run)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return batch(cf, run)
+}
+
+// batch runs the config of the flags, recording the API interactions when
+// asked even if the run failed, to reproduce it.
+func batch(cf *configFlags, run runFlags) error {
+	config, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	if run.ID, err = newRunID(); err != nil {
+		return err
+	}
+
+	cf.Credentials.Host = config.Host()
+	tc, save, err := newRunHTTPClient(cf.Credentials, config.HTTP, config.BasicAuth(), run)
+	if err != nil {
+		return err
+	}
+
+	_, err = execute(tc, config, run)
+	if saveErr := save(); err == nil {
+		err = saveErr
+	}
+
+	return err
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/internal/state"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// selection is the pull requests a command manages: the ones from the head
+// branch of the config or, when a run is given, the ones it created.
+type selection struct {
+	Run   string // ID of the run, "last" for the latest one.
+	State string // state file the run is recorded in.
+
+	urls  map[string]bool     // of the pull requests of the run.
+	heads map[string][]string // head branches of the run, by repository.
+}
+
+// registerSelectionFlags registers on fs the flags selecting a run.
+func registerSelectionFlags(fs *flag.FlagSet) *selection {
+	var s selection
+	fs.StringVar(&s.Run, "run", "", "Only the pull requests created by the run with the given ID, or the latest run with \"last\", instead of every one from the head branch")
+	fs.StringVar(&s.State, "state", state.DefaultPath, "State file recording the runs")
+	return &s
+}
+
+// apply replaces the owner, head and destinations of the config with the ones
+// of the run, when one is given.
+func (s *selection) apply(config *options.Config) error {
+	if s.Run == "" {
+		return nil
+	}
+
+	db, err := state.Open(s.State)
+	if err != nil {
+		return err
+	}
+
+	run, err := db.Run(s.Run)
+	if err != nil {
+		return err
+	}
+
+	config.Owner, config.Head, config.Destinations = run.Owner, run.Head, nil
+	s.urls, s.heads = make(map[string]bool), make(map[string][]string)
+	for _, v := range run.Results {
+		if v.URL == "" || s.urls[v.URL] {
+			continue
+		}
+
+		s.urls[v.URL] = true
+		if _, ok := s.heads[v.Repository]; !ok {
+			config.Destinations = append(config.Destinations, mkpr.Destination{Repository: v.Repository})
+		}
+		s.heads[v.Repository] = append(s.heads[v.Repository], v.Head)
+	}
+
+	if len(config.Destinations) == 0 {
+		return errors.New("the run created no pull request")
+	}
+
+	return nil
+}
+
+// pullRequests returns the selected pull requests of the destination, in
+// every state.
+func (s *selection) pullRequests(ctx context.Context, p provider.Provider, config options.Config, d mkpr.Destination) ([]provider.PullRequest, error) {
+	var out []provider.PullRequest
+	for _, head := range s.headsOf(config, d) {
+		pulls, err := s.pullRequestsFrom(ctx, p, config, d, head)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pulls...)
+	}
+
+	return out, nil
+}
+
+// pullRequestsFrom returns the selected pull requests of the destination from
+// the head branch, in every state.
+func (s *selection) pullRequestsFrom(ctx context.Context, p provider.Provider, config options.Config, d mkpr.Destination, head string) ([]provider.PullRequest, error) {
+	pulls, err := p.ListPullRequests(ctx, config.OwnerOrDefault(), d.Repository, provider.ListOptions{
+		State: provider.StateAll,
+		Head:  head,
+		Base:  d.Base,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list pull requests: %w", err)
+	}
+
+	if s.urls == nil {
+		return pulls, nil
+	}
+
+	var out []provider.PullRequest
+	for _, v := range pulls {
+		if s.urls[v.URL] {
+			out = append(out, v)
+		}
+	}
+
+	return out, nil
+}
+
+// headsOf returns the head branches of the pull requests of the destination:
+// the ones the run pushed to, suffixed or split, or the one of the config.
+func (s *selection) headsOf(config options.Config, d mkpr.Destination) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, v := range s.heads[d.Repository] {
+		if v == "" {
+			// recorded before the heads were.
+			v = config.Head
+		}
+
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+
+	if len(out) == 0 {
+		return []string{config.Head}
+	}
+
+	return out
+}
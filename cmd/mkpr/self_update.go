@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/internal/credentials"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/selfupdate"
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// _releases is the repository publishing the releases of mkpr.
+const _releases = "sorfino/go-toolkit-cmd"
+
+// selfUpdate runs "mkpr self-update", which replaces the running binary with
+// the one of the latest release, or of the given one, once its checksum and
+// signature are verified.
+func selfUpdate(args []string) error {
+	fs := newFlagSet("self-update", "[flags]", "Replaces the running binary with the one of the latest release, or of the given one, once its checksum and signature are verified.")
+	repository := fs.String("repository", _releases, "Repository publishing the releases, owner/name")
+	githubURL := fs.String("github-url", "", "GitHub Enterprise Server API URL of the repository, github.com when empty")
+	version := fs.String("version", "", "Release to install, for instance, v0.3.0, the latest one when empty")
+	check := fs.Bool("check", false, "Only reports whether a newer release is available")
+	publicKey := fs.String("public-key", releasePublicKey(), "Base64 Ed25519 key the checksums of the releases are signed with (MKPR_RELEASE_PUBLIC_KEY), the one of the build by default")
+	skipSignature := fs.Bool("insecure-skip-signature", false, "Installs the release without verifying its signature, only its checksum, which proves nothing about who published it")
+	source := fleet.RegisterCredentialFlags(fs)
+	httpFlags := fleet.RegisterHTTPFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	owner, repo, client, direct, err := releaseClient(*repository, *githubURL, source, *httpFlags)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	release, err := selfupdate.Release(ctx, client, owner, repo, *version)
+	if err != nil {
+		return err
+	}
+
+	tag, current := release.GetTagName(), options.Build().Version
+	if *version == "" && !selfupdate.Newer(current, tag) {
+		fmt.Printf("mkpr %s is up to date.\n", current)
+		return nil
+	}
+	if *check {
+		fmt.Printf("mkpr %s is available, running %s: %s\n", tag, current, release.GetHTMLURL())
+		return nil
+	}
+
+	if *publicKey == "" && !*skipSignature {
+		return errors.New("no public key to verify the release with, this build has none: give -public-key, or -insecure-skip-signature to install it unverified")
+	}
+
+	name := selfupdate.AssetName("mkpr")
+	binary, err := selfupdate.Download(ctx, client, direct, owner, repo, release, name)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("release %s: %s: %w", tag, name, selfupdate.ErrNoAsset)
+	}
+	if err != nil {
+		return err
+	}
+
+	checksums, err := selfupdate.Download(ctx, client, direct, owner, repo, release, selfupdate.Checksums)
+	if err != nil {
+		return fmt.Errorf("release %s: %w", tag, err)
+	}
+
+	if *skipSignature {
+		fmt.Println("warning: the signature of the release is not verified.")
+		err = selfupdate.VerifyChecksum(name, binary, checksums)
+	} else {
+		var signature []byte
+		if signature, err = selfupdate.Download(ctx, client, direct, owner, repo, release, selfupdate.Checksums+".sig"); err != nil {
+			return fmt.Errorf("release %s: %w", tag, err)
+		}
+		err = selfupdate.Verify(name, binary, checksums, signature, *publicKey)
+	}
+	if err != nil {
+		return fmt.Errorf("release %s: %w", tag, err)
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("unable to find the running binary: %w", err)
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return fmt.Errorf("unable to find the running binary: %w", err)
+	}
+
+	if err := selfupdate.Replace(path, binary); err != nil {
+		return err
+	}
+
+	fmt.Printf("updated %s from %s to %s.\n", path, current, tag)
+	return nil
+}
+
+// releasePublicKey returns the key verifying the releases: the one of
+// MKPR_RELEASE_PUBLIC_KEY, or the one set at build time.
+func releasePublicKey() string {
+	if v := os.Getenv("MKPR_RELEASE_PUBLIC_KEY"); v != "" {
+		return v
+	}
+
+	return options.PublicKey
+}
+
+// releaseClient returns the client of the repository publishing the releases,
+// authenticated when a token is found, and the client following the redirects
+// of their downloads.
+func releaseClient(repository, githubURL string, source *credentials.Source, httpFlags transport.Options) (owner, repo string, client *github.Client, direct *http.Client, err error) {
+	parts := strings.Split(repository, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", nil, nil, fmt.Errorf("invalid repository %q, owner/name expected", repository)
+	}
+
+	base, err := transport.NewBase(httpFlags)
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+	direct = &http.Client{Transport: base}
+
+	// the releases of public repositories need no token.
+	source.Host = "github.com"
+	if u, err := url.Parse(githubURL); err == nil && u.Hostname() != "" {
+		source.Host = u.Hostname()
+	}
+	tc, err := fleet.NewHTTPClient(source, httpFlags, false)
+	if errors.Is(err, credentials.ErrNotFound) {
+		tc, err = direct, nil
+	}
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+
+	client, err = provider.NewGitHubClient(tc, githubURL, "")
+	if err != nil {
+		return "", "", nil, nil, fmt.Errorf("invalid GitHub URL: %w", err)
+	}
+
+	return parts[0], parts[1], client, direct, nil
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/internal/credentials"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/internal/state"
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+)
+
+// Statuses of the runs submitted to the server.
+const (
+	statusQueued    = "queued"
+	statusRunning   = "running"
+	statusSucceeded = "succeeded"
+	statusFailed    = "failed"
+)
+
+// _queueSize is the number of runs waiting to start at most.
+const _queueSize = 100
+
+// _webhookHosts are the hosts of the Slack and Teams webhooks the untrusted
+// configs can notify by default, along with their subdomains.
+const _webhookHosts = "hooks.slack.com,webhook.office.com,logic.azure.com,api.powerplatform.com"
+
+// serverRun is a run submitted to the server, as answered by its API.
+type serverRun struct {
+	ID         string         `json:"id"`
+	Status     string         `json:"status"`
+	DryRun     bool           `json:"dry_run"`
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Results    []state.Result `json:"results,omitempty"`
+
+	config options.Config
+}
+
+// server runs the submitted configs one at a time, in order.
+type server struct {
+	token   string // authenticates the API requests.
+	trusted bool   // accepts configs running commands or choosing the endpoints.
+
+	webhookHosts []string // the webhooks of the untrusted configs are on.
+
+	source    credentials.Source
+	httpFlags transport.Options
+	statePath string
+	verbose   bool
+
+	mu    sync.Mutex
+	runs  map[string]*serverRun
+	queue chan *serverRun
+}
+
+// serve runs "mkpr serve", an HTTP API to submit configs whose batches run in
+// the background, for self-service large scale changes behind a portal.
+func serve(args []string) error {
+	fs := newFlagSet("serve", "[flags]", "Exposes an HTTP API to submit configs whose batches run in the background, see the README for its endpoints.")
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	tokenEnv := fs.String("api-token-env", "MKPR_API_TOKEN", "Environment variable holding the bearer token of the API clients")
+	trusted := fs.Bool("trusted", false, "Accepts configs with hooks, external transforms, files outside the working directory, API endpoints or notifications reading the environment")
+	webhookHosts := fs.String("webhook-hosts", _webhookHosts, "Comma-separated hosts, subdomains included, the Slack and Teams webhooks of the untrusted configs can be on")
+	statePath := fs.String("state", state.DefaultPath, "State file recording the changes pushed by the runs")
+	verbose := fs.Bool("verbose", false, "Logs every step of the batches")
+	source := fleet.RegisterCredentialFlags(fs)
+	httpFlags := fleet.RegisterHTTPFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	token := os.Getenv(*tokenEnv)
+	if token == "" {
+		return fmt.Errorf("%s is not set, the API requires a token", *tokenEnv)
+	}
+
+	s := &server{
+		token:        token,
+		trusted:      *trusted,
+		webhookHosts: strings.Split(*webhookHosts, ","),
+		source:       *source,
+		httpFlags:    *httpFlags,
+		statePath:    *statePath,
+		verbose:      *verbose,
+		runs:         make(map[string]*serverRun),
+		queue:        make(chan *serverRun, _queueSize),
+	}
+	go s.work()
+
+	srv := &http.Server{Addr: *addr, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	fmt.Printf("listening on %s\n", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	fmt.Println("stopped.")
+	return nil
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/runs", s.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			s.submit(w, r)
+		case http.MethodGet:
+			s.list(w)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}))
+	mux.HandleFunc("/runs/", s.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		s.mu.Lock()
+		run, ok := s.runs[strings.TrimPrefix(r.URL.Path, "/runs/")]
+		var out serverRun
+		if ok {
+			out = *run
+		}
+		s.mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusNotFound, "run not found")
+			return
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}))
+
+	return mux
+}
+
+// authenticated verifies the bearer token of the requests before calling h.
+func (s *server) authenticated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+// submit queues the config of the request body, a dry run when the query has
+// dry_run=true.
+func (s *server) submit(w http.ResponseWriter, r *http.Request) {
+	config, err := options.Parse(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid config: "+err.Error())
+		return
+	}
+
+	if !s.trusted {
+		if err := restricted(config, s.webhookHosts); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+	}
+
+	id, err := newRunID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	run := &serverRun{ID: id, Status: statusQueued, DryRun: r.URL.Query().Get("dry_run") == "true", CreatedAt: time.Now().UTC(), config: config}
+	s.mu.Lock()
+	select {
+	case s.queue <- run:
+		s.runs[id] = run
+	default:
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "too many queued runs")
+		return
+	}
+	out := *run
+	s.mu.Unlock()
+
+	w.Header().Set("Location", "/runs/"+id)
+	writeJSON(w, http.StatusAccepted, out)
+}
+
+// list answers every run, newest first, without their results.
+func (s *server) list(w http.ResponseWriter) {
+	s.mu.Lock()
+	out := make([]serverRun, 0, len(s.runs))
+	for _, v := range s.runs {
+		run := *v
+		run.Results = nil
+		out = append(out, run)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	writeJSON(w, http.StatusOK, out)
+}
+
+// work runs the queued runs one at a time, so they share the rate limit of the
+// token.
+func (s *server) work() {
+	for run := range s.queue {
+		s.update(func() {
+			now := time.Now().UTC()
+			run.Status, run.StartedAt = statusRunning, &now
+		})
+
+		fmt.Printf("%s: starting run %s\n", time.Now().Format(time.RFC3339), run.ID)
+		record, err := s.execute(run)
+
+		s.update(func() {
+			now := time.Now().UTC()
+			run.Status, run.FinishedAt, run.Results = statusSucceeded, &now, record.Results
+			if err != nil {
+				run.Status, run.Error = statusFailed, redact.String(err.Error())
+			}
+		})
+		fmt.Printf("%s: run %s %s\n", time.Now().Format(time.RFC3339), run.ID, run.Status)
+	}
+}
+
+func (s *server) update(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f()
+}
+
+// execute runs the config of the run with the credentials and HTTP settings
+// of the server.
+func (s *server) execute(run *serverRun) (state.Run, error) {
+	config := run.config
+	config.HTTP = s.httpFlags
+
+	source := s.source
+	source.Host = config.Host()
+	flags := runFlags{Verbose: s.verbose, DryRun: run.DryRun, Incremental: true, State: s.statePath, Job: "serve/" + run.ID, ID: run.ID}
+	tc, _, err := newRunHTTPClient(&source, config.HTTP, config.BasicAuth(), flags)
+	if err != nil {
+		return state.Run{}, err
+	}
+
+	if !run.DryRun {
+		unlock, err := state.Lock(s.statePath)
+		if err != nil {
+			return state.Run{}, err
+		}
+		defer unlock()
+	}
+
+	return execute(tc, config, flags)
+}
+
+// restricted verifies the config runs no command on the server, reads none of
+// its files but the ones under the working directory and sends the credentials
+// and secrets of the server to no other API than the default one, the summary
+// being posted to the webhook hosts only.
+func restricted(config options.Config, webhookHosts []string) error {
+	if config.GitHubURL != "" || config.UploadURL != "" || config.ProviderURL != "" {
+		return errors.New("API endpoints are not allowed")
+	}
+
+	h := config.Hooks
+	if h.PreRun != "" || h.PreDestination != "" || h.PostDestination != "" || h.PostRun != "" {
+		return errors.New("hooks are not allowed")
+	}
+
+	if len(config.Transforms) > 0 {
+		return errors.New("external transforms are not allowed")
+	}
+
+	// the central config of the owner is, another repository is not.
+	if config.Central != nil && config.Central.Repository != "" {
+		return errors.New("central repositories are not allowed")
+	}
+
+	if i := config.Notify.Issue; i != nil && strings.Contains(i.Repository, "/") {
+		return errors.New("issues of other owners are not allowed")
+	}
+
+	// the password would be read from any variable of the server and sent to
+	// the SMTP server of the config.
+	if e := config.Notify.Email; e != nil && (e.SMTP != "" || e.PasswordEnv != "") {
+		return errors.New("email notifications are not allowed")
+	}
+
+	for i, w := range []*options.Webhook{config.Notify.Slack, config.Notify.Teams} {
+		if w == nil {
+			continue
+		}
+
+		name := [...]string{"slack", "teams"}[i]
+
+		// any variable of the server would be read, and sent along.
+		if w.URLEnv != "" {
+			return fmt.Errorf("%s url_env is not allowed", name)
+		}
+
+		if !webhookAllowed(w.URL, webhookHosts) {
+			return fmt.Errorf("%s webhook host is not allowed", name)
+		}
+	}
+
+	for _, v := range config.Files {
+		if filepath.IsAbs(v.Source) || strings.HasPrefix(filepath.Clean(v.Source), "..") {
+			return fmt.Errorf("file %s is outside the working directory", v.Source)
+		}
+	}
+
+	return nil
+}
+
+// webhookAllowed reports whether the URL is an HTTPS one on one of the hosts,
+// or their subdomains.
+func webhookAllowed(rawURL string, hosts []string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, v := range hosts {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v != "" && (host == v || strings.HasSuffix(host, "."+v)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// status runs "mkpr status", which reports the state of the pull requests
+// created by a config, found by its head branch.
+func status(args []string) error {
+	fs := newFlagSet("status", "[flags]", "Reports whether the pull requests from the head branch of the config, or created by the run given with -run, are open, merged or closed on every destination.")
+	cf := registerConfigFlags(fs)
+	sel := registerSelectionFlags(fs)
+	report := fs.String("report", "", "Writes the pull requests to the given file, as CSV or JSON by extension")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	p, err := cf.provider(&config)
+	if err != nil {
+		return err
+	}
+
+	if err := sel.apply(&config); err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := config.OwnerOrDefault()
+	counts := make(map[string]int)
+	table := fleet.Table{Header: []string{"repository", "number", "state", "url"}}
+	err = fleet.Run(context.Background(), fleetConfig(config), func(ctx context.Context, d mkpr.Destination) (string, error) {
+		pulls, err := sel.pullRequests(ctx, p, config, d)
+		if err != nil {
+			return "", err
+		}
+
+		if len(pulls) == 0 {
+			counts["missing"]++
+			table.Add(owner+"/"+d.Repository, "", "missing", "")
+			return "no pull request from " + config.Head, nil
+		}
+
+		parts := make([]string, 0, len(pulls))
+		for _, v := range pulls {
+			counts[v.State]++
+			table.Add(owner+"/"+d.Repository, fmt.Sprint(v.Number), v.State, v.URL)
+			parts = append(parts, fmt.Sprintf("#%d %s %s", v.Number, v.State, v.URL))
+		}
+
+		return strings.Join(parts, ", "), nil
+	})
+	if err := fleet.WriteTable(*report, table); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(countsSummary(counts))
+	return nil
+}
+
+// fleetConfig returns the destinations of the config, to run a command on
+// them with fleet.Run.
+func fleetConfig(config options.Config) fleet.Config {
+	return fleet.Config{Owner: config.Owner, Destinations: config.Destinations, Delay: config.Delay}
+}
+
+// countsSummary describes the number of pull requests by state, such as "3
+// open, 2 merged".
+func countsSummary(counts map[string]int) string {
+	states := make([]string, 0, len(counts))
+	for k := range counts {
+		states = append(states, k)
+	}
+	sort.Strings(states)
+
+	parts := make([]string, 0, len(states))
+	for _, v := range states {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[v], v))
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/notify"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// validate runs "mkpr validate", which verifies a config without contacting
+// the provider, for instance, in the CI of the repository holding it.
+func validate(args []string) error {
+	fs := newFlagSet("validate", "[flags]", "Verifies the config file, its provider settings and local files, without contacting the provider.")
+	cf := registerConfigFlags(fs)
+	cf.registerChangeFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := cf.load()
+	if err != nil {
+		return fmt.Errorf("invalid config %s: %w", cf.Location, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid config %s: %w", cf.Location, err)
+	}
+
+	if len(config.Destinations) == 0 {
+		return fmt.Errorf("invalid config %s: %w", cf.Location, errors.New("destinations are required"))
+	}
+
+	if config.Delay != "" {
+		if _, err := time.ParseDuration(config.Delay); err != nil {
+			return fmt.Errorf("invalid config %s: invalid delay: %w", cf.Location, err)
+		}
+	}
+
+	// building the provider sends no request, it verifies its name and URLs.
+	p, err := mkpr.NewProvider(http.DefaultClient, config.BatchPullRequestOption)
+	if err != nil {
+		return fmt.Errorf("invalid config %s: %w", cf.Location, err)
+	}
+
+	for _, v := range config.Files {
+		if v.Source == "" {
+			continue
+		}
+
+		if _, err := os.Stat(v.Source); err != nil {
+			return fmt.Errorf("invalid config %s: %w", cf.Location, err)
+		}
+	}
+
+	// the notifications may rely on variables only set where mkpr runs.
+	commenter, _ := p.(notify.Commenter)
+	if _, err := config.Notify.Notifiers(commenter); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+	}
+
+	fmt.Printf("%s is valid.\n", cf.Location)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sorfino/go-toolkit-cmd/cmd/mkpr/internal/options"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/selfupdate"
+)
+
+// version runs "mkpr version", which prints the build information of the
+// binary and, when asked, whether a newer release is available.
+func version(args []string) error {
+	fs := newFlagSet("version", "[flags]", "Prints the version, commit, build date and Go version of mkpr.")
+	check := fs.Bool("check", false, "Reports whether a newer release is available")
+	asJSON := fs.Bool("json", false, "Prints the build information as JSON")
+	repository := fs.String("repository", _releases, "Repository publishing the releases, owner/name, for -check")
+	githubURL := fs.String("github-url", "", "GitHub Enterprise Server API URL of the repository, github.com when empty")
+	source := fleet.RegisterCredentialFlags(fs)
+	httpFlags := fleet.RegisterHTTPFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	info := options.Build()
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			return err
+		}
+	} else {
+		printVersion(info)
+	}
+
+	if !*check {
+		return nil
+	}
+
+	owner, repo, client, _, err := releaseClient(*repository, *githubURL, source, *httpFlags)
+	if err != nil {
+		return err
+	}
+
+	release, err := selfupdate.Release(context.Background(), client, owner, repo, "")
+	if err != nil {
+		return err
+	}
+
+	if selfupdate.Newer(info.Version, release.GetTagName()) {
+		fmt.Printf("mkpr %s is available, run \"mkpr self-update\": %s\n", release.GetTagName(), release.GetHTMLURL())
+	} else {
+		fmt.Println("mkpr is up to date.")
+	}
+
+	return nil
+}
+
+// printVersion prints the build information, omitting what the build did not
+// set.
+func printVersion(info options.BuildInfo) {
+	fmt.Println("Go-Toolkit Batch Pull Requester. Version " + info.Version)
+	if info.Commit != "" {
+		fmt.Println("commit:     " + info.Commit)
+	}
+	if info.Date != "" {
+		fmt.Println("built:      " + info.Date)
+	}
+	fmt.Println("go version: " + info.GoVersion)
+	fmt.Println("platform:   " + info.Platform)
+}
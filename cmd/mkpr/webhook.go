@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/credentials"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/internal/state"
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+)
+
+// Push runs a job on the pushes to a repository, such as the one holding the
+// templates of its config.
+type Push struct {
+	Repository string `yaml:"repository"` // owner/name of the pushed repository.
+	Branch     string `yaml:"branch"`     // pushed branch, the default one when empty.
+
+	// Paths the push must change one of, in path.Match syntax, any when
+	// empty.
+	Paths []string `yaml:"paths"`
+}
+
+func (p Push) validate() error {
+	if strings.Count(p.Repository, "/") != 1 {
+		return fmt.Errorf("invalid push repository %q, owner/name expected", p.Repository)
+	}
+
+	for _, v := range p.Paths {
+		if _, err := path.Match(v, ""); err != nil {
+			return fmt.Errorf("invalid push path %q: %w", v, err)
+		}
+	}
+
+	return nil
+}
+
+// pushEvent is the part of the payload of a push webhook the jobs are
+// triggered by.
+type pushEvent struct {
+	Ref        string `json:"ref"`
+	Deleted    bool   `json:"deleted"`
+	Repository struct {
+		FullName      string `json:"full_name"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+	Commits []struct {
+		Added    []string `json:"added"`
+		Removed  []string `json:"removed"`
+		Modified []string `json:"modified"`
+	} `json:"commits"`
+}
+
+// triggers reports whether the push triggers a job with the given trigger.
+func (e pushEvent) triggers(p Push) bool {
+	branch := p.Branch
+	if branch == "" {
+		branch = e.Repository.DefaultBranch
+	}
+
+	if e.Deleted || !strings.EqualFold(e.Repository.FullName, p.Repository) || e.Ref != "refs/heads/"+branch {
+		return false
+	}
+
+	if len(p.Paths) == 0 {
+		return true
+	}
+
+	for _, c := range e.Commits {
+		for _, files := range [][]string{c.Added, c.Removed, c.Modified} {
+			for _, f := range files {
+				for _, pattern := range p.Paths {
+					if ok, _ := path.Match(pattern, f); ok {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// listener runs the jobs triggered by the webhooks it receives, one at a time.
+type listener struct {
+	secret []byte // signs the deliveries.
+	jobs   []job
+	dir    string // of the jobs file, the configs are relative to.
+
+	source    credentials.Source
+	httpFlags transport.Options
+	statePath string
+	verbose   bool
+
+	mu      sync.Mutex
+	pending map[string]bool // jobs queued, a push triggers them once.
+	queue   chan *job
+}
+
+// webhook runs "mkpr webhook", which listens for the push webhooks of GitHub
+// and runs the jobs of a jobs file they trigger, so template changes reach the
+// fleet without manual runs.
+func webhook(args []string) error {
+	fs := newFlagSet("webhook", "[flags]", "Listens for the push webhooks of GitHub and runs the jobs of a jobs file they trigger.")
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	location := fs.String("jobs", "jobs.yml", "Location of the jobs file")
+	secretEnv := fs.String("secret-env", "MKPR_WEBHOOK_SECRET", "Environment variable holding the secret of the webhooks")
+	statePath := fs.String("state", state.DefaultPath, "State file recording the changes pushed by the runs, locked while a job runs")
+	verbose := fs.Bool("verbose", false, "Logs every step of the batches")
+	source := fleet.RegisterCredentialFlags(fs)
+	httpFlags := fleet.RegisterHTTPFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	secret := os.Getenv(*secretEnv)
+	if secret == "" {
+		return fmt.Errorf("%s is not set, the webhooks require a secret", *secretEnv)
+	}
+
+	js, err := parseJobs(*location, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var triggered []job
+	for _, j := range js {
+		if j.Push != nil {
+			triggered = append(triggered, j)
+		}
+	}
+	if len(triggered) == 0 {
+		return errors.New("no job has a push trigger")
+	}
+
+	l := &listener{
+		secret:    []byte(secret),
+		jobs:      triggered,
+		dir:       filepath.Dir(*location),
+		source:    *source,
+		httpFlags: *httpFlags,
+		statePath: *statePath,
+		verbose:   *verbose,
+		pending:   make(map[string]bool),
+		queue:     make(chan *job, len(triggered)),
+	}
+	go l.work()
+
+	srv := &http.Server{Addr: *addr, Handler: l, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+
+	fmt.Printf("listening on %s for %d jobs from %s\n", *addr, len(triggered), *location)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	fmt.Println("stopped.")
+	return nil
+}
+
+// ServeHTTP verifies the signature of a delivery and queues the jobs its push
+// triggers, answering right away as GitHub waits 10 seconds at most.
+func (l *listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 25<<20))
+	if err != nil {
+		http.Error(w, "unable to read the payload", http.StatusBadRequest)
+		return
+	}
+
+	if !l.verify(payload, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Header.Get("X-GitHub-Event") != "push" {
+		// pings and the other events are acknowledged and ignored.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var event pushEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	queued := []string{}
+	l.mu.Lock()
+	for i := range l.jobs {
+		j := &l.jobs[i]
+		if !event.triggers(*j.Push) || l.pending[j.Name] {
+			continue
+		}
+
+		l.pending[j.Name] = true
+		l.queue <- j
+		queued = append(queued, j.Name)
+	}
+	l.mu.Unlock()
+
+	fmt.Printf("%s: push to %s %s queued %d jobs\n", time.Now().Format(time.RFC3339), event.Repository.FullName, event.Ref, len(queued))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string][]string{"queued": queued})
+}
+
+// verify reports whether signature is the HMAC of the payload with the secret
+// of the webhooks.
+func (l *listener) verify(payload []byte, signature string) bool {
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// work runs the queued jobs one at a time. A job is queued again by the pushes
+// received while it runs, which its run may not have seen.
+func (l *listener) work() {
+	for j := range l.queue {
+		l.mu.Lock()
+		delete(l.pending, j.Name)
+		l.mu.Unlock()
+
+		fmt.Printf("%s: running job %s\n", time.Now().Format(time.RFC3339), j.Name)
+		run := runFlags{Verbose: l.verbose, Incremental: *j.Incremental, State: l.statePath, Job: j.Name}
+		if err := runJob(filepath.Join(l.dir, j.Config), l.source, l.httpFlags, run); err != nil {
+			fmt.Printf("%s: job %s failed: %s\n", time.Now().Format(time.RFC3339), j.Name, redact.String(err.Error()))
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// assets returns the files matching the asset patterns of the release, which
+// are rendered for the destination so each one can upload its own artifacts.
+func (r release) assets(data mkpr.TemplateData) ([]string, error) {
+	var (
+		paths []string
+		names = make(map[string]string)
+	)
+	for _, v := range r.Assets {
+		pattern, err := mkpr.Render("assets", v, data)
+		if err != nil {
+			return nil, err
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no asset matches %q", pattern)
+		}
+
+		for _, path := range matches {
+			// assets are named after the file, which must be unique.
+			name := filepath.Base(path)
+			if other, ok := names[name]; ok && other != path {
+				return nil, fmt.Errorf("assets %s and %s have the same name", other, path)
+			}
+			if _, ok := names[name]; !ok {
+				names[name] = path
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// uploadAssets uploads the files the release does not have an asset of the
+// same name yet, returning how many were uploaded.
+func uploadAssets(ctx context.Context, client *github.Client, owner, repo string, rel *github.RepositoryRelease, paths []string) (int, error) {
+	if len(paths) == 0 {
+		return 0, nil
+	}
+
+	existing := make(map[string]bool)
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		assets, resp, err := client.Repositories.ListReleaseAssets(ctx, owner, repo, rel.GetID(), opt)
+		if err != nil {
+			return 0, fmt.Errorf("unable to list release assets: %w", err)
+		}
+
+		for _, v := range assets {
+			existing[v.GetName()] = true
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	uploaded := 0
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if existing[name] {
+			continue
+		}
+
+		if err := uploadAsset(ctx, client, owner, repo, rel.GetID(), name, path); err != nil {
+			return uploaded, err
+		}
+		uploaded++
+	}
+
+	return uploaded, nil
+}
+
+func uploadAsset(ctx context.Context, client *github.Client, owner, repo string, id int64, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, _, err := client.Repositories.UploadReleaseAsset(ctx, owner, repo, id, &github.UploadOptions{Name: name}, f); err != nil {
+		return fmt.Errorf("unable to upload asset %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+// Command mkrelease creates the same tag and release on a batch of
+// repositories, for coordinated releases of related services or libraries.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the release to
+// create on each of them.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Release release `yaml:"release"`
+}
+
+// release is created on every destination. Tag, name and notes are rendered
+// as text/template for each destination, see mkpr.TemplateData.
+type release struct {
+	Tag        string `yaml:"tag"`        // tag to create, for instance, "v1.2.0".
+	Name       string `yaml:"name"`       // title of the release, the tag when empty.
+	Notes      string `yaml:"notes"`      // description of the release.
+	Target     string `yaml:"target"`     // branch or commit to tag, overridden by the base of the destinations.
+	Draft      bool   `yaml:"draft"`      // creates unpublished releases.
+	Prerelease bool   `yaml:"prerelease"` // marks the releases as not ready for production.
+
+	// Assets are the paths or globs of the files to upload, such as binaries
+	// and SBOMs, rendered for each destination.
+	Assets []string `yaml:"assets"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if c.Release.Tag == "" {
+		return errors.New("release tag is required")
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		data := c.Data(d)
+		request, err := c.Release.request(data)
+		if err != nil {
+			return "", err
+		}
+
+		assets, err := c.Release.assets(data)
+		if err != nil {
+			return "", err
+		}
+
+		// re-runs do not fail on the releases already created, they upload
+		// the assets missing from them.
+		rel, _, err := client.Repositories.GetReleaseByTag(ctx, owner, d.Repository, request.GetTagName())
+		switch {
+		case err == nil && _flags.DryRun:
+			return "already released " + rel.GetHTMLURL(), nil
+		case err == nil:
+			uploaded, err := uploadAssets(ctx, client, owner, d.Repository, rel, assets)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("already released %s, %d asset(s) uploaded", rel.GetHTMLURL(), uploaded), nil
+		case !fleet.IsNotFound(err):
+			return "", fmt.Errorf("unable to get release: %w", err)
+		}
+
+		if _flags.DryRun {
+			return fmt.Sprintf("would release %s at %s with %d asset(s)", request.GetTagName(), targetOrDefault(request.GetTargetCommitish()), len(assets)), nil
+		}
+
+		rel, _, err = client.Repositories.CreateRelease(ctx, owner, d.Repository, request)
+		if err != nil {
+			return "", fmt.Errorf("unable to create release: %w", err)
+		}
+
+		if _, err := uploadAssets(ctx, client, owner, d.Repository, rel, assets); err != nil {
+			return "", err
+		}
+
+		return rel.GetHTMLURL(), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+// request renders the release of a destination.
+func (r release) request(data mkpr.TemplateData) (*github.RepositoryRelease, error) {
+	tag, err := mkpr.Render("tag", r.Tag, data)
+	if err != nil {
+		return nil, err
+	}
+
+	name := tag
+	if r.Name != "" {
+		if name, err = mkpr.Render("name", r.Name, data); err != nil {
+			return nil, err
+		}
+	}
+
+	notes, err := mkpr.Render("notes", r.Notes, data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &github.RepositoryRelease{
+		TagName:    &tag,
+		Name:       &name,
+		Body:       &notes,
+		Draft:      &r.Draft,
+		Prerelease: &r.Prerelease,
+	}
+
+	// an empty target would be sent as is, instead of defaulting.
+	target := r.Target
+	if data.Base != "" {
+		target = data.Base
+	}
+	if target != "" {
+		out.TargetCommitish = &target
+	}
+
+	return out, nil
+}
+
+// targetOrDefault describes the target of a release, GitHub tagging the
+// default branch when there is none.
+func targetOrDefault(target string) string {
+	if target == "" {
+		return "the default branch"
+	}
+
+	return target
+}
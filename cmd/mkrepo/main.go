@@ -0,0 +1,306 @@
+// Command mkrepo creates a batch of repositories from a template repository,
+// with their topics, team access and branch protection.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/protection"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the template, the repositories to
+// create from it and what to apply to them.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Template    string `yaml:"template"`     // template repository, as owner/name.
+	AllBranches bool   `yaml:"all_branches"` // copies every branch of the template, only the default one otherwise.
+	Private     bool   `yaml:"private"`
+
+	// Description is rendered as text/template for each repository, see
+	// mkpr.TemplateData, unless the names file gives one.
+	Description string `yaml:"description"`
+
+	// NamesFile is a CSV file with the name of a repository to create per
+	// line, optionally followed by its description, added to the destinations.
+	NamesFile string `yaml:"names_file"`
+
+	Topics     []string               `yaml:"topics"`
+	Teams      []team                 `yaml:"teams"`
+	Protection *protection.Protection `yaml:"protection"` // of the default branch, or the base of the destination.
+
+	descriptions map[string]string // of the names file, by repository.
+}
+
+// team is granted a permission on the repositories.
+type team struct {
+	Slug       string `yaml:"slug"`
+	Permission string `yaml:"permission"` // pull, triage, push (default), maintain or admin.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.readNames(); err != nil {
+		return err
+	}
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		summary := "exists"
+		repo, _, err := client.Repositories.Get(ctx, owner, d.Repository)
+		switch {
+		case err == nil:
+		case !fleet.IsNotFound(err):
+			return "", fmt.Errorf("unable to get repository: %w", err)
+		case _flags.DryRun:
+			return "would create from " + c.Template, nil
+		default:
+			if repo, err = c.generate(ctx, client, d); err != nil {
+				return "", err
+			}
+			summary = "created " + repo.GetHTMLURL()
+		}
+
+		steps, err := c.apply(ctx, client, repo, d, _flags.DryRun)
+		if err != nil {
+			return "", err
+		}
+
+		if len(steps) > 0 {
+			summary += ", " + strings.Join(steps, ", ")
+		}
+
+		return summary, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if parts := strings.Split(c.Template, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return errors.New("template is required, as owner/name")
+	}
+
+	for i, v := range c.Teams {
+		if v.Slug == "" {
+			return errors.New("teams need a slug")
+		}
+		switch v.Permission {
+		case "":
+			c.Teams[i].Permission = "push"
+		case "pull", "triage", "push", "maintain", "admin":
+		default:
+			return fmt.Errorf("invalid permission %q of team %s", v.Permission, v.Slug)
+		}
+	}
+
+	return c.Validate()
+}
+
+// generate creates the repository of the destination from the template.
+func (c config) generate(ctx context.Context, client *github.Client, d mkpr.Destination) (*github.Repository, error) {
+	description, ok := c.descriptions[d.Repository]
+	if !ok {
+		var err error
+		if description, err = mkpr.Render("description", c.Description, c.Data(d)); err != nil {
+			return nil, err
+		}
+	}
+
+	body := map[string]interface{}{
+		"owner":                c.OwnerOrDefault(),
+		"name":                 d.Repository,
+		"description":          description,
+		"private":              c.Private,
+		"include_all_branches": c.AllBranches,
+	}
+
+	var repo github.Repository
+	if err := fleet.Send(ctx, client, http.MethodPost, "repos/"+c.Template+"/generate", body, &repo); err != nil {
+		return nil, fmt.Errorf("unable to create repository: %w", err)
+	}
+
+	return &repo, nil
+}
+
+// apply sets the topics, team access and branch protection of the repository,
+// returning the steps taken, or that would be on a dry run.
+func (c config) apply(ctx context.Context, client *github.Client, repo *github.Repository, d mkpr.Destination, dryRun bool) ([]string, error) {
+	owner, name := c.OwnerOrDefault(), repo.GetName()
+	var steps []string
+
+	if len(c.Topics) > 0 {
+		current, _, err := client.Repositories.ListAllTopics(ctx, owner, name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list topics: %w", err)
+		}
+
+		if !sameSet(current, c.Topics) {
+			if !dryRun {
+				if _, _, err := client.Repositories.ReplaceAllTopics(ctx, owner, name, c.Topics); err != nil {
+					return nil, fmt.Errorf("unable to set topics: %w", err)
+				}
+			}
+			steps = append(steps, "topics")
+		}
+	}
+
+	if len(c.Teams) > 0 {
+		granted, err := c.grant(ctx, client, owner, name, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, granted...)
+	}
+
+	if c.Protection != nil {
+		branch := d.Base
+		if branch == "" {
+			branch = repo.GetDefaultBranch()
+		}
+
+		changed, err := c.protect(ctx, client, owner, name, branch, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			steps = append(steps, "protection of "+branch)
+		}
+	}
+
+	if dryRun && len(steps) > 0 {
+		steps[0] = "would set " + steps[0]
+	} else if len(steps) > 0 {
+		steps[0] = "set " + steps[0]
+	}
+
+	return steps, nil
+}
+
+// protect applies the protection to the branch when it differs, waiting for
+// the branch of a repository just created, as templates are copied in the
+// background.
+func (c config) protect(ctx context.Context, client *github.Client, owner, repo, branch string, dryRun bool) (bool, error) {
+	for i := 0; ; i++ {
+		_, _, err := client.Repositories.GetBranch(ctx, owner, repo, branch)
+		if err == nil {
+			break
+		}
+		if !fleet.IsNotFound(err) || i == 30 {
+			return false, fmt.Errorf("unable to get branch %s: %w", branch, err)
+		}
+		time.Sleep(2 * time.Second)
+	}
+
+	current, err := protection.Get(ctx, client, owner, repo, branch)
+	if err != nil {
+		return false, err
+	}
+
+	if len(fleet.Changes(current.Settings(), c.Protection.Settings())) == 0 {
+		return false, nil
+	}
+
+	if !dryRun {
+		if err := protection.Set(ctx, client, owner, repo, branch, *c.Protection, current); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// grant gives the teams their permission on the repository when they do not
+// have it yet, returning the teams granted.
+func (c config) grant(ctx context.Context, client *github.Client, owner, repo string, dryRun bool) ([]string, error) {
+	var current []struct {
+		Slug       string `json:"slug"`
+		Permission string `json:"permission"`
+	}
+	// organizations rarely grant access to more than 100 teams.
+	if err := fleet.Send(ctx, client, http.MethodGet, "repos/"+owner+"/"+repo+"/teams?per_page=100", nil, &current); err != nil {
+		return nil, fmt.Errorf("unable to list teams: %w", err)
+	}
+
+	permissions := make(map[string]string, len(current))
+	for _, v := range current {
+		permissions[strings.ToLower(v.Slug)] = v.Permission
+	}
+
+	var granted []string
+	for _, v := range c.Teams {
+		// the API reads pull and push as read and write.
+		switch permissions[strings.ToLower(v.Slug)] {
+		case v.Permission, strings.NewReplacer("pull", "read", "push", "write").Replace(v.Permission):
+			continue
+		}
+
+		if !dryRun {
+			path := fmt.Sprintf("orgs/%s/teams/%s/repos/%s/%s", owner, v.Slug, owner, repo)
+			if err := fleet.Send(ctx, client, http.MethodPut, path, map[string]string{"permission": v.Permission}, nil); err != nil {
+				return nil, fmt.Errorf("unable to grant %s to team %s: %w", v.Permission, v.Slug, err)
+			}
+		}
+		granted = append(granted, "team "+v.Slug)
+	}
+
+	return granted, nil
+}
+
+// sameSet reports whether a and b hold the same values, in any order.
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	values := make(map[string]bool, len(a))
+	for _, v := range a {
+		values[v] = true
+	}
+	for _, v := range b {
+		if !values[v] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// readNames adds the repositories of the names file to the destinations, along
+// with their descriptions. A first line starting with "name" is a header.
+func (c *config) readNames() error {
+	if c.NamesFile == "" {
+		return nil
+	}
+
+	f, err := os.Open(c.NamesFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %w", c.NamesFile, err)
+	}
+
+	c.descriptions = make(map[string]string)
+	for i, v := range records {
+		name := strings.TrimSpace(v[0])
+		if name == "" || (i == 0 && strings.EqualFold(name, "name")) {
+			continue
+		}
+
+		c.Destinations = append(c.Destinations, mkpr.Destination{Repository: name})
+		if len(v) > 1 {
+			c.descriptions[name] = strings.TrimSpace(v[1])
+		}
+	}
+
+	return nil
+}
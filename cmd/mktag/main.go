@@ -0,0 +1,194 @@
+// Command mktag creates the same annotated tag on a batch of repositories,
+// such as a fleet wide freeze point or a compliance snapshot.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the tag to
+// create on each of them.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Tag tag `yaml:"tag"`
+}
+
+// tag is created on every destination. Name and message are rendered as
+// text/template for each destination, see mkpr.TemplateData.
+type tag struct {
+	Name    string `yaml:"name"`    // name of the tag, for instance, "freeze-2024-06".
+	Message string `yaml:"message"` // message of the annotated tag.
+	Ref     string `yaml:"ref"`     // branch, tag or commit to tag, overridden by the base of the destinations.
+
+	// Tagger of the tag objects, the token user when empty. Signed tags
+	// need it.
+	Tagger struct {
+		Name  string `yaml:"name"`
+		Email string `yaml:"email"`
+	} `yaml:"tagger"`
+
+	// Sign signs the tags with gpg, using SigningKey or the default key.
+	Sign       bool   `yaml:"sign"`
+	SigningKey string `yaml:"signing_key"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.Tag.validate(); err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		data := c.Data(d)
+		name, err := mkpr.Render("name", c.Tag.Name, data)
+		if err != nil {
+			return "", err
+		}
+
+		message, err := mkpr.Render("message", c.Tag.Message, data)
+		if err != nil {
+			return "", err
+		}
+
+		// re-runs do not fail on the tags already created.
+		exists, err := tagExists(ctx, client, owner, d.Repository, name)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "already tagged " + name, nil
+		}
+
+		ref := c.Tag.Ref
+		if d.Base != "" {
+			ref = d.Base
+		}
+		if ref == "" {
+			repository, _, err := client.Repositories.Get(ctx, owner, d.Repository)
+			if err != nil {
+				return "", fmt.Errorf("unable to get repository: %w", err)
+			}
+			ref = repository.GetDefaultBranch()
+		}
+
+		sha, _, err := client.Repositories.GetCommitSHA1(ctx, owner, d.Repository, ref, "")
+		if err != nil {
+			return "", fmt.Errorf("unable to resolve %s: %w", ref, err)
+		}
+
+		if _flags.DryRun {
+			return fmt.Sprintf("would tag %s at %s (%s)", name, ref, sha), nil
+		}
+
+		object := &github.Tag{
+			Tag:     &name,
+			Message: github.String(strings.TrimSuffix(message, "\n") + "\n"),
+			Object:  &github.GitObject{SHA: &sha, Type: github.String("commit")},
+		}
+		if c.Tag.Tagger.Name != "" {
+			// the date is part of the signed content, git keeps seconds.
+			date := time.Now().UTC().Truncate(time.Second)
+			object.Tagger = &github.CommitAuthor{Name: &c.Tag.Tagger.Name, Email: &c.Tag.Tagger.Email, Date: &date}
+		}
+
+		if c.Tag.Sign {
+			signature, err := sign(ctx, c.Tag.SigningKey, object)
+			if err != nil {
+				return "", err
+			}
+			object.Message = github.String(object.GetMessage() + signature)
+		}
+
+		created, _, err := client.Git.CreateTag(ctx, owner, d.Repository, object)
+		if err != nil {
+			return "", fmt.Errorf("unable to create tag object: %w", err)
+		}
+
+		if _, _, err := client.Git.CreateRef(ctx, owner, d.Repository, &github.Reference{
+			Ref:    github.String("refs/tags/" + name),
+			Object: &github.GitObject{SHA: created.SHA},
+		}); err != nil {
+			return "", fmt.Errorf("unable to create tag: %w", err)
+		}
+
+		return fmt.Sprintf("tagged %s at %s (%s)", name, ref, sha), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (t tag) validate() error {
+	if t.Name == "" {
+		return errors.New("tag name is required")
+	}
+
+	if t.Message == "" {
+		return errors.New("tag message is required, tags are annotated")
+	}
+
+	if t.Sign && (t.Tagger.Name == "" || t.Tagger.Email == "") {
+		return errors.New("signed tags need the name and email of the tagger")
+	}
+
+	return nil
+}
+
+// tagExists reports whether the repository has the tag. The API matches refs
+// by prefix, so the exact name is looked for.
+func tagExists(ctx context.Context, client *github.Client, owner, repo, name string) (bool, error) {
+	refs, _, err := client.Git.GetRefs(ctx, owner, repo, "tags/"+name)
+	if fleet.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get tags: %w", err)
+	}
+
+	for _, v := range refs {
+		if v.GetRef() == "refs/tags/"+name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
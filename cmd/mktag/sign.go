@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/google/go-github/github"
+)
+
+// sign returns the armored gpg signature of the tag object, appended to its
+// message the way git does. GitHub rebuilds the same object from the request,
+// so the signature verifies as long as the tagger and its date are sent.
+func sign(ctx context.Context, key string, t *github.Tag) (string, error) {
+	var payload bytes.Buffer
+	fmt.Fprintf(&payload, "object %s\ntype %s\ntag %s\n", t.GetObject().GetSHA(), t.GetObject().GetType(), t.GetTag())
+	fmt.Fprintf(&payload, "tagger %s <%s> %d +0000\n\n", t.GetTagger().GetName(), t.GetTagger().GetEmail(), t.GetTagger().GetDate().Unix())
+	payload.WriteString(t.GetMessage())
+
+	args := []string{"--detach-sign", "--armor"}
+	if key != "" {
+		args = append(args, "--local-user", key)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gpg", args...)
+	cmd.Stdin = &payload
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("unable to sign tag: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,192 @@
+// Command pr-analytics measures the pull requests created by mkpr, from the
+// runs of its state file and the API: how long they take to be approved and
+// merged, and how often each repository fails.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/state"
+)
+
+var (
+	_state     *string        = flag.String("state", state.DefaultPath, "State file of mkpr recording its runs")
+	_head      *string        = flag.String("head", "", "Only measures the runs of the given head branch")
+	_since     *time.Duration = flag.Duration("since", 0, "Only measures the runs started in the given period, for instance, 720h")
+	_report    *string        = flag.String("report", "", "Writes the statistics of every repository to the given file, as CSV or, ending in .json, as JSON")
+	_githubURL *string        = flag.String("github-url", "", "GitHub Enterprise Server API URL, github.com when empty")
+	_flags     *fleet.Flags   = &fleet.Flags{
+		Credentials: fleet.RegisterCredentialFlags(flag.CommandLine),
+		HTTP:        fleet.RegisterHTTPFlags(flag.CommandLine),
+	}
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	db, err := state.Open(*_state)
+	if err != nil {
+		return err
+	}
+
+	var runs []state.Run
+	for _, v := range db.Runs() {
+		if (*_head == "" || v.Head == *_head) && (*_since == 0 || time.Since(v.StartedAt) <= *_since) {
+			runs = append(runs, v)
+		}
+	}
+	if len(runs) == 0 {
+		return errors.New("no runs recorded in " + *_state + " match")
+	}
+
+	c := fleet.Config{GitHubURL: *_githubURL, HTTP: *_flags.HTTP}
+	client, err := _flags.Client(c)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	stats, err := collect(context.Background(), client, runs)
+	if err != nil {
+		return err
+	}
+
+	printStats(stats)
+	if err := fleet.WriteTable(*_report, table(stats)); err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+// repoStats are the statistics of the pull requests of a repository.
+type repoStats struct {
+	repository string
+	attempts   int // results of the runs, other than the unchanged ones.
+	failures   int
+	open       int
+	merged     int
+	closed     int // without merging.
+
+	toApprove []time.Duration // from creation to the first approval.
+	toMerge   []time.Duration // from creation to merge.
+}
+
+// collect returns the statistics of the repositories of the runs, sorted by
+// repository, getting every pull request once.
+func collect(ctx context.Context, client *github.Client, runs []state.Run) ([]*repoStats, error) {
+	byRepo := make(map[string]*repoStats)
+	seen := make(map[string]bool)
+	for _, run := range runs {
+		for _, result := range run.Results {
+			if result.Unchanged {
+				continue
+			}
+
+			name := run.Owner + "/" + result.Repository
+			s := byRepo[name]
+			if s == nil {
+				s = &repoStats{repository: name}
+				byRepo[name] = s
+			}
+
+			s.attempts++
+			if result.Error != "" {
+				s.failures++
+			}
+			if result.URL == "" || seen[result.URL] {
+				continue
+			}
+			seen[result.URL] = true
+
+			// pull requests of deleted repositories are left out.
+			if err := s.add(ctx, client, result.URL); err != nil && !fleet.IsNotFound(err) {
+				return nil, err
+			}
+		}
+	}
+
+	out := make([]*repoStats, 0, len(byRepo))
+	for _, v := range byRepo {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].repository < out[j].repository })
+
+	return out, nil
+}
+
+// add measures the pull request of the URL.
+func (s *repoStats) add(ctx context.Context, client *github.Client, url string) error {
+	owner, repo, number, err := parseURL(url)
+	if err != nil {
+		return err
+	}
+
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return fmt.Errorf("unable to get %s: %w", url, err)
+	}
+
+	switch {
+	case pr.MergedAt != nil:
+		s.merged++
+		s.toMerge = append(s.toMerge, pr.GetMergedAt().Sub(pr.GetCreatedAt()))
+	case pr.GetState() == "closed":
+		s.closed++
+	default:
+		s.open++
+	}
+
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		reviews, resp, err := client.PullRequests.ListReviews(ctx, owner, repo, number, opt)
+		if err != nil {
+			return fmt.Errorf("unable to list the reviews of %s: %w", url, err)
+		}
+
+		for _, v := range reviews {
+			if v.GetState() == "APPROVED" {
+				s.toApprove = append(s.toApprove, v.GetSubmittedAt().Sub(pr.GetCreatedAt()))
+				return nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// parseURL returns the repository and number of the pull request of the URL,
+// as https://<host>/<owner>/<repo>/pull/<number>.
+func parseURL(url string) (string, string, int, error) {
+	parts := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	if len(parts) < 4 || parts[len(parts)-2] != "pull" {
+		return "", "", 0, fmt.Errorf("unknown pull request URL %s", url)
+	}
+
+	number, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return "", "", 0, fmt.Errorf("unknown pull request URL %s", url)
+	}
+
+	return parts[len(parts)-4], parts[len(parts)-3], number, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+var _header = []string{"repository", "attempts", "failure rate", "open", "merged", "closed", "median to approve", "median to merge"}
+
+// printStats writes the statistics as a table, with the totals of the fleet
+// last.
+func printStats(stats []*repoStats) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, v := range _header {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, v)
+	}
+	fmt.Fprintln(w)
+
+	for _, v := range append(stats, total(stats)) {
+		for i, value := range v.row() {
+			if i > 0 {
+				fmt.Fprint(w, "\t")
+			}
+			fmt.Fprint(w, value)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
+
+// table returns the statistics of every repository as a table.
+func table(stats []*repoStats) fleet.Table {
+	t := fleet.Table{Header: _header}
+	for _, v := range stats {
+		t.Add(v.row()...)
+	}
+
+	return t
+}
+
+// total returns the statistics of the whole fleet.
+func total(stats []*repoStats) *repoStats {
+	out := &repoStats{repository: "total"}
+	for _, v := range stats {
+		out.attempts += v.attempts
+		out.failures += v.failures
+		out.open += v.open
+		out.merged += v.merged
+		out.closed += v.closed
+		out.toApprove = append(out.toApprove, v.toApprove...)
+		out.toMerge = append(out.toMerge, v.toMerge...)
+	}
+
+	return out
+}
+
+func (s *repoStats) row() []string {
+	rate := "-"
+	if s.attempts > 0 {
+		rate = strconv.Itoa(100*s.failures/s.attempts) + "%"
+	}
+
+	return []string{
+		s.repository,
+		strconv.Itoa(s.attempts),
+		rate,
+		strconv.Itoa(s.open),
+		strconv.Itoa(s.merged),
+		strconv.Itoa(s.closed),
+		median(s.toApprove),
+		median(s.toMerge),
+	}
+}
+
+// median returns the median of the durations, in hours or minutes when
+// shorter, or "-" when there are none.
+func median(durations []time.Duration) string {
+	if len(durations) == 0 {
+		return "-"
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	m := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		m = (sorted[len(sorted)/2-1] + m) / 2
+	}
+
+	if m < time.Hour {
+		return fmt.Sprintf("%dm", int(m.Round(time.Minute).Minutes()))
+	}
+
+	return fmt.Sprintf("%dh", int(m.Round(time.Hour).Hours()))
+}
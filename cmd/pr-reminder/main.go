@@ -0,0 +1,251 @@
+// Command pr-reminder comments on the pull requests of a batch of repositories
+// that are open for too long, nudging their reviewers to follow up a rollout.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// _marker is appended to the reminders, so the pull requests already reminded
+// are found again.
+const _marker = "<!-- pr-reminder -->"
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations, the pull requests
+// to remind and the comment to post on them.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Days int `yaml:"days"` // days the pull requests are open, and between reminders, 7 by default.
+
+	// Head the pull requests come from, in path.Match syntax, for instance, the
+	// head of a mkpr config to only remind the pull requests it created. Every
+	// pull request when empty.
+	Head string `yaml:"head"`
+
+	// Comment is rendered as text/template for each pull request, see data.
+	Comment       string `yaml:"comment"`
+	PingReviewers bool   `yaml:"ping_reviewers"` // mentions the requested reviewers in the comment.
+}
+
+// data is the data available to the comment: the one of the destination along
+// with the pull request.
+type data struct {
+	mkpr.TemplateData
+
+	Number    int
+	Title     string
+	URL       string
+	Author    string
+	Days      int      // days the pull request is open.
+	Reviewers []string // logins of the requested reviewers.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -c.Days)
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		pulls, err := oldPullRequests(ctx, client, owner, d.Repository, c.Head, cutoff)
+		if err != nil {
+			return "", err
+		}
+
+		var reminded, skipped []string
+		for _, v := range pulls {
+			number := fmt.Sprintf("#%d", v.GetNumber())
+			recent, err := remindedSince(ctx, client, owner, d.Repository, v.GetNumber(), cutoff)
+			if err != nil {
+				return "", err
+			}
+			if recent {
+				skipped = append(skipped, number)
+				continue
+			}
+
+			body, err := c.comment(d, v, now)
+			if err != nil {
+				return "", err
+			}
+
+			if !_flags.DryRun {
+				if _, _, err := client.Issues.CreateComment(ctx, owner, d.Repository, v.GetNumber(), &github.IssueComment{Body: &body}); err != nil {
+					return "", fmt.Errorf("unable to comment on %s: %w", number, err)
+				}
+			}
+			reminded = append(reminded, number)
+		}
+
+		return summary(reminded, skipped, _flags.DryRun), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if c.Comment == "" {
+		return errors.New("comment is required")
+	}
+
+	if _, err := path.Match(c.Head, ""); err != nil {
+		return fmt.Errorf("invalid head %q: %w", c.Head, err)
+	}
+
+	if c.Days < 0 {
+		return errors.New("days must be positive")
+	}
+	if c.Days == 0 {
+		c.Days = 7
+	}
+
+	return c.Validate()
+}
+
+// comment returns the reminder for the pull request.
+func (c config) comment(d mkpr.Destination, pr *github.PullRequest, now time.Time) (string, error) {
+	reviewers := make([]string, 0, len(pr.RequestedReviewers))
+	for _, v := range pr.RequestedReviewers {
+		reviewers = append(reviewers, v.GetLogin())
+	}
+
+	body, err := mkpr.Render("comment", c.Comment, data{
+		TemplateData: c.Data(d),
+		Number:       pr.GetNumber(),
+		Title:        pr.GetTitle(),
+		URL:          pr.GetHTMLURL(),
+		Author:       pr.GetUser().GetLogin(),
+		Days:         int(now.Sub(pr.GetCreatedAt()).Hours() / 24),
+		Reviewers:    reviewers,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if c.PingReviewers && len(reviewers) > 0 {
+		body = "@" + strings.Join(reviewers, " @") + " " + body
+	}
+
+	return body + "\n\n" + _marker, nil
+}
+
+// oldPullRequests returns the open pull requests of the repository created
+// before cutoff whose head matches the pattern.
+func oldPullRequests(ctx context.Context, client *github.Client, owner, repo, head string, cutoff time.Time) ([]*github.PullRequest, error) {
+	opt := &github.PullRequestListOptions{
+		State:       "open",
+		Sort:        "created",
+		Direction:   "asc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var out []*github.PullRequest
+	for {
+		pulls, resp, err := client.PullRequests.List(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list pull requests: %w", err)
+		}
+
+		for _, v := range pulls {
+			if !v.GetCreatedAt().Before(cutoff) {
+				// sorted by creation, the rest are newer.
+				return out, nil
+			}
+
+			if ok, _ := path.Match(head, v.GetHead().GetRef()); ok || head == "" {
+				out = append(out, v)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// remindedSince reports whether the pull request has a reminder posted after
+// since.
+func remindedSince(ctx context.Context, client *github.Client, owner, repo string, number int, since time.Time) (bool, error) {
+	opt := &github.IssueListCommentsOptions{
+		Since:       since,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for {
+		comments, resp, err := client.Issues.ListComments(ctx, owner, repo, number, opt)
+		if err != nil {
+			return false, fmt.Errorf("unable to list the comments of #%d: %w", number, err)
+		}
+
+		for _, v := range comments {
+			if strings.Contains(v.GetBody(), _marker) && v.GetCreatedAt().After(since) {
+				return true, nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// summary describes the reminders posted to a destination, or that would be
+// on a dry run.
+func summary(reminded, skipped []string, dryRun bool) string {
+	var parts []string
+	switch {
+	case len(reminded) == 0:
+		parts = append(parts, "nothing to remind")
+	case dryRun:
+		parts = append(parts, "would remind "+strings.Join(reminded, ", "))
+	default:
+		parts = append(parts, "reminded "+strings.Join(reminded, ", "))
+	}
+
+	if len(skipped) > 0 {
+		parts = append(parts, "already reminded "+strings.Join(skipped, ", "))
+	}
+
+	return strings.Join(parts, ", ")
+}
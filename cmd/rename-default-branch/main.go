@@ -0,0 +1,223 @@
+// Command rename-default-branch renames the default branch of a batch of
+// repositories, for instance, from master to main.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/protection"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the names of
+// their default branch.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	From      string `yaml:"from"`       // current default branch, "master" by default.
+	To        string `yaml:"to"`         // new default branch, "main" by default.
+	DeleteOld bool   `yaml:"delete_old"` // deletes the old branch once migrated.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if c.From == "" {
+		c.From = "master"
+	}
+	if c.To == "" {
+		c.To = "main"
+	}
+	if c.From == c.To {
+		return errors.New("from and to must be different branches")
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		m := migration{client: client, owner: owner, repo: d.Repository, from: c.From, to: c.To, dryRun: _flags.DryRun}
+		steps, err := m.run(ctx, c.DeleteOld)
+		if err != nil {
+			return "", err
+		}
+
+		return fleet.Summary(steps, _flags.DryRun), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+// migration moves a repository from one default branch to another.
+type migration struct {
+	client   *github.Client
+	owner    string
+	repo     string
+	from, to string
+	dryRun   bool
+}
+
+// run creates the new branch from the old one, copies its protection, makes
+// it the default, retargets the open pull requests and optionally deletes
+// the old branch, returning the steps taken, or that would be on a dry run.
+// Every step is skipped when already done, so failed migrations can be run
+// again.
+func (m migration) run(ctx context.Context, deleteOld bool) ([]string, error) {
+	repository, _, err := m.client.Repositories.Get(ctx, m.owner, m.repo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get repository: %w", err)
+	}
+
+	current := repository.GetDefaultBranch()
+	if current != m.from && current != m.to {
+		return nil, fmt.Errorf("the default branch is %s, not %s", current, m.from)
+	}
+
+	var steps []string
+	oldSHA, err := m.sha(ctx, m.from)
+	if err != nil {
+		return nil, err
+	}
+
+	newSHA, err := m.sha(ctx, m.to)
+	if err != nil {
+		return nil, err
+	}
+
+	if newSHA == "" {
+		if oldSHA == "" {
+			return nil, fmt.Errorf("neither %s nor %s exist", m.from, m.to)
+		}
+
+		steps = append(steps, "create "+m.to)
+		if !m.dryRun {
+			ref := &github.Reference{Ref: github.String("refs/heads/" + m.to), Object: &github.GitObject{SHA: &oldSHA}}
+			if _, _, err := m.client.Git.CreateRef(ctx, m.owner, m.repo, ref); err != nil {
+				return steps, fmt.Errorf("unable to create %s: %w", m.to, err)
+			}
+		}
+
+		// the protection is only copied to the branch created here, so a
+		// protection changed since on the new branch is left alone.
+		var protected bool
+		if m.dryRun {
+			source, err := protection.Get(ctx, m.client, m.owner, m.repo, m.from)
+			if err != nil {
+				return steps, err
+			}
+			protected = source.Protected()
+		} else if protected, err = protection.Copy(ctx, m.client, m.owner, m.repo, m.from, m.to); err != nil {
+			return steps, err
+		}
+		if protected {
+			steps = append(steps, "protect "+m.to)
+		}
+	}
+
+	if current != m.to {
+		steps = append(steps, "set default "+m.to)
+		if !m.dryRun {
+			if _, _, err := m.client.Repositories.Edit(ctx, m.owner, m.repo, &github.Repository{DefaultBranch: &m.to}); err != nil {
+				return steps, fmt.Errorf("unable to set the default branch: %w", err)
+			}
+		}
+	}
+
+	retargeted, err := m.retarget(ctx)
+	if retargeted > 0 {
+		steps = append(steps, fmt.Sprintf("retarget %d pull request(s)", retargeted))
+	}
+	if err != nil {
+		return steps, err
+	}
+
+	if deleteOld && oldSHA != "" {
+		steps = append(steps, "delete "+m.from)
+		if !m.dryRun {
+			if err := protection.Remove(ctx, m.client, m.owner, m.repo, m.from); err != nil {
+				return steps, err
+			}
+			if _, err := m.client.Git.DeleteRef(ctx, m.owner, m.repo, "refs/heads/"+m.from); err != nil {
+				return steps, fmt.Errorf("unable to delete %s: %w", m.from, err)
+			}
+		}
+	}
+
+	return steps, nil
+}
+
+// sha returns the commit the branch points to, empty when it does not exist.
+func (m migration) sha(ctx context.Context, branch string) (string, error) {
+	b, _, err := m.client.Repositories.GetBranch(ctx, m.owner, m.repo, branch)
+	if fleet.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to get %s: %w", branch, err)
+	}
+
+	return b.GetCommit().GetSHA(), nil
+}
+
+// retarget moves the open pull requests against the old branch to the new
+// one, returning how many there were.
+func (m migration) retarget(ctx context.Context) (int, error) {
+	var pulls []*github.PullRequest
+	opt := &github.PullRequestListOptions{State: "open", Base: m.from, ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := m.client.PullRequests.List(ctx, m.owner, m.repo, opt)
+		if err != nil {
+			return 0, fmt.Errorf("unable to list pull requests: %w", err)
+		}
+		pulls = append(pulls, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	if m.dryRun {
+		return len(pulls), nil
+	}
+
+	for i, v := range pulls {
+		edit := &github.PullRequest{Base: &github.PullRequestBranch{Ref: &m.to}}
+		if _, _, err := m.client.PullRequests.Edit(ctx, m.owner, m.repo, v.GetNumber(), edit); err != nil {
+			return i, fmt.Errorf("unable to retarget #%d: %w", v.GetNumber(), err)
+		}
+	}
+
+	return len(pulls), nil
+}
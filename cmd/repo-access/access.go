@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+// repository manages the access of teams and users to a repository.
+type repository struct {
+	client *github.Client
+	owner  string
+	name   string
+}
+
+// access returns the current permissions of the teams and users, "none" when
+// they have no access.
+func (r repository) access(ctx context.Context, teams, users []grant) ([]fleet.Setting, error) {
+	var settings []fleet.Setting
+	if len(teams) > 0 {
+		var repoTeams []struct {
+			Slug       string `json:"slug"`
+			Permission string `json:"permission"`
+		}
+		// organizations rarely grant access to more than 100 teams.
+		if err := fleet.Send(ctx, r.client, http.MethodGet, r.path("/teams?per_page=100"), nil, &repoTeams); err != nil {
+			return nil, fmt.Errorf("unable to list teams: %w", err)
+		}
+
+		permissions := make(map[string]string, len(repoTeams))
+		for _, v := range repoTeams {
+			permissions[strings.ToLower(v.Slug)] = normalize(v.Permission)
+		}
+		for _, v := range teams {
+			permission, ok := permissions[strings.ToLower(v.Name)]
+			if !ok {
+				permission = "none"
+			}
+			settings = append(settings, fleet.Setting{Name: "team " + v.Name, Value: permission})
+		}
+	}
+
+	for _, v := range users {
+		var out struct {
+			Permission string `json:"permission"`
+			RoleName   string `json:"role_name"`
+		}
+		if err := fleet.Send(ctx, r.client, http.MethodGet, r.path("/collaborators/"+v.Name+"/permission"), nil, &out); err != nil {
+			return nil, fmt.Errorf("unable to get the permission of %s: %w", v.Name, err)
+		}
+
+		// role_name tells triage and maintain apart from read and write.
+		permission := out.RoleName
+		if permission == "" {
+			permission = out.Permission
+		}
+		settings = append(settings, fleet.Setting{Name: "user " + v.Name, Value: normalize(permission)})
+	}
+
+	return settings, nil
+}
+
+// setTeam grants or revokes the access of the team when it changed.
+func (r repository) setTeam(ctx context.Context, g grant, current []fleet.Setting) error {
+	if !changed(current, "team "+g.Name, g.permission()) {
+		return nil
+	}
+
+	path := fmt.Sprintf("orgs/%s/teams/%s/repos/%s/%s", r.owner, g.Name, r.owner, r.name)
+	if g.Revoke {
+		if err := fleet.Send(ctx, r.client, http.MethodDelete, path, nil, nil); err != nil {
+			return fmt.Errorf("unable to revoke the access of team %s: %w", g.Name, err)
+		}
+		return nil
+	}
+
+	if err := fleet.Send(ctx, r.client, http.MethodPut, path, map[string]string{"permission": g.Permission}, nil); err != nil {
+		return fmt.Errorf("unable to grant %s to team %s: %w", g.Permission, g.Name, err)
+	}
+
+	return nil
+}
+
+// setUser grants or revokes the access of the user when it changed.
+func (r repository) setUser(ctx context.Context, g grant, current []fleet.Setting) error {
+	if !changed(current, "user "+g.Name, g.permission()) {
+		return nil
+	}
+
+	path := r.path("/collaborators/" + g.Name)
+	if g.Revoke {
+		if err := fleet.Send(ctx, r.client, http.MethodDelete, path, nil, nil); err != nil {
+			return fmt.Errorf("unable to revoke the access of %s: %w", g.Name, err)
+		}
+		return nil
+	}
+
+	if err := fleet.Send(ctx, r.client, http.MethodPut, path, map[string]string{"permission": g.Permission}, nil); err != nil {
+		return fmt.Errorf("unable to grant %s to %s: %w", g.Permission, g.Name, err)
+	}
+
+	return nil
+}
+
+func (r repository) path(suffix string) string {
+	return "repos/" + r.owner + "/" + r.name + suffix
+}
+
+// normalize returns the permission with the names used to grant it, as the
+// API reads some of them differently.
+func normalize(permission string) string {
+	switch permission {
+	case "read":
+		return "pull"
+	case "write":
+		return "push"
+	case "":
+		return "none"
+	default:
+		return permission
+	}
+}
+
+func changed(current []fleet.Setting, name, value string) bool {
+	for _, v := range current {
+		if v.Name == name {
+			return v.Value != value
+		}
+	}
+
+	return true
+}
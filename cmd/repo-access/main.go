@@ -0,0 +1,132 @@
+// Command repo-access grants or revokes the permissions of teams and users on
+// a batch of repositories.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the access
+// of the teams and users to them.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Teams []grant `yaml:"teams"` // teams of the owner organization, by slug.
+	Users []grant `yaml:"users"` // users, by login, invited when they are not collaborators.
+}
+
+// grant is the permission of a team or user.
+type grant struct {
+	Name       string `yaml:"name"`       // slug of the team or login of the user.
+	Permission string `yaml:"permission"` // pull, triage, push, maintain or admin.
+	Revoke     bool   `yaml:"revoke"`     // removes the access instead.
+}
+
+// _permissions are the permissions that can be granted.
+var _permissions = map[string]bool{"pull": true, "triage": true, "push": true, "maintain": true, "admin": true}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		r := repository{client: client, owner: owner, name: d.Repository}
+		current, err := r.access(ctx, c.Teams, c.Users)
+		if err != nil {
+			return "", err
+		}
+
+		changes := fleet.Changes(current, c.desired())
+		if len(changes) == 0 || _flags.DryRun {
+			return fleet.Summary(changes, _flags.DryRun), nil
+		}
+
+		for _, v := range c.Teams {
+			if err := r.setTeam(ctx, v, current); err != nil {
+				return "", err
+			}
+		}
+		for _, v := range c.Users {
+			if err := r.setUser(ctx, v, current); err != nil {
+				return "", err
+			}
+		}
+
+		return fleet.Summary(changes, false), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() error {
+	if len(c.Teams) == 0 && len(c.Users) == 0 {
+		return errors.New("teams or users are required")
+	}
+
+	for _, v := range append(append([]grant(nil), c.Teams...), c.Users...) {
+		switch {
+		case v.Name == "":
+			return errors.New("teams and users need a name")
+		case !v.Revoke && !_permissions[v.Permission]:
+			return fmt.Errorf("invalid permission %q of %s, use pull, triage, push, maintain or admin", v.Permission, v.Name)
+		}
+	}
+
+	return c.Validate()
+}
+
+// desired returns the access the config grants.
+func (c config) desired() []fleet.Setting {
+	var settings []fleet.Setting
+	for _, v := range c.Teams {
+		settings = append(settings, fleet.Setting{Name: "team " + v.Name, Value: v.permission()})
+	}
+	for _, v := range c.Users {
+		settings = append(settings, fleet.Setting{Name: "user " + v.Name, Value: v.permission()})
+	}
+
+	return settings
+}
+
+func (g grant) permission() string {
+	if g.Revoke {
+		return "none"
+	}
+
+	return g.Permission
+}
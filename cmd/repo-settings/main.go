@@ -0,0 +1,91 @@
+// Command repo-settings enforces the same repository settings on a batch of
+// repositories, reporting the drift before applying them.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the settings
+// they must have.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Settings settings `yaml:"settings"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	desired := c.Settings.list()
+	if len(desired) == 0 {
+		return errors.New("settings are required")
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		path := "repos/" + owner + "/" + d.Repository
+		req, err := client.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			return "", err
+		}
+
+		var current settings
+		if _, err := client.Do(ctx, req, &current); err != nil {
+			return "", fmt.Errorf("unable to get repository: %w", err)
+		}
+
+		changes := fleet.Changes(current.list(), desired)
+		if len(changes) == 0 || _flags.DryRun {
+			return fleet.Summary(changes, _flags.DryRun), nil
+		}
+
+		// only the settings of the config are sent, the rest is left as is.
+		if req, err = client.NewRequest(http.MethodPatch, path, c.Settings); err != nil {
+			return "", err
+		}
+		if _, err := client.Do(ctx, req, nil); err != nil {
+			return "", fmt.Errorf("unable to update repository: %w", err)
+		}
+
+		return fleet.Summary(changes, false), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+// settings are the repository settings enforced, with the names of the API.
+// The ones left out of the config are not enforced.
+type settings struct {
+	AllowMergeCommit    *bool `yaml:"allow_merge_commit" json:"allow_merge_commit,omitempty"`
+	AllowSquashMerge    *bool `yaml:"allow_squash_merge" json:"allow_squash_merge,omitempty"`
+	AllowRebaseMerge    *bool `yaml:"allow_rebase_merge" json:"allow_rebase_merge,omitempty"`
+	AllowAutoMerge      *bool `yaml:"allow_auto_merge" json:"allow_auto_merge,omitempty"`
+	AllowUpdateBranch   *bool `yaml:"allow_update_branch" json:"allow_update_branch,omitempty"`
+	DeleteBranchOnMerge *bool `yaml:"delete_branch_on_merge" json:"delete_branch_on_merge,omitempty"`
+
+	// PR_TITLE or COMMIT_OR_PR_TITLE, and PR_BODY, COMMIT_MESSAGES or BLANK.
+	SquashMergeCommitTitle   *string `yaml:"squash_merge_commit_title" json:"squash_merge_commit_title,omitempty"`
+	SquashMergeCommitMessage *string `yaml:"squash_merge_commit_message" json:"squash_merge_commit_message,omitempty"`
+
+	// PR_TITLE or MERGE_MESSAGE, and PR_BODY, PR_TITLE or BLANK.
+	MergeCommitTitle   *string `yaml:"merge_commit_title" json:"merge_commit_title,omitempty"`
+	MergeCommitMessage *string `yaml:"merge_commit_message" json:"merge_commit_message,omitempty"`
+
+	HasIssues      *bool `yaml:"has_issues" json:"has_issues,omitempty"`
+	HasWiki        *bool `yaml:"has_wiki" json:"has_wiki,omitempty"`
+	HasProjects    *bool `yaml:"has_projects" json:"has_projects,omitempty"`
+	HasDiscussions *bool `yaml:"has_discussions" json:"has_discussions,omitempty"`
+
+	WebCommitSignoffRequired *bool `yaml:"web_commit_signoff_required" json:"web_commit_signoff_required,omitempty"`
+}
+
+// list returns the settings that are set.
+func (s settings) list() []fleet.Setting {
+	var list []fleet.Setting
+	v := reflect.ValueOf(s)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsNil() {
+			continue
+		}
+
+		name := strings.TrimSuffix(v.Type().Field(i).Tag.Get("json"), ",omitempty")
+		list = append(list, fleet.Setting{Name: name, Value: fmt.Sprint(v.Field(i).Elem().Interface())})
+	}
+
+	return list
+}
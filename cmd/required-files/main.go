@@ -0,0 +1,193 @@
+// Command required-files audits a batch of repositories for the files every
+// repository must have, such as LICENSE, SECURITY.md or CODEOWNERS.
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// statuses of a required file in a destination.
+const (
+	_ok      = "ok"
+	_missing = "missing"
+	_differs = "differs"
+)
+
+var (
+	_flags  *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_report *string      = flag.String("report", "", "Writes the compliance report to the given file, as CSV or, ending in .json, as JSON")
+)
+
+// config is the content of a config file: the destinations, the files they
+// must have and where to write the mkpr configs adding the missing ones.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Files []requiredFile `yaml:"files"`
+
+	// MkprDir is the directory where an mkpr config is written for every file
+	// with a source, whose destinations are the ones missing it. Run them from
+	// the directory of this config, as the sources are relative to it.
+	MkprDir string `yaml:"mkpr_dir"`
+}
+
+// requiredFile is a file every destination must have, at any of its paths.
+type requiredFile struct {
+	Name   string   `yaml:"name"`   // shown in the report, the first path by default.
+	Paths  []string `yaml:"paths"`  // where the file can be, in order of preference.
+	SHA256 string   `yaml:"sha256"` // expected hash of the content, any content is fine when empty.
+	Source string   `yaml:"source"` // local canonical file, added by the mkpr configs.
+}
+
+// finding is the status of a required file in a destination.
+type finding struct {
+	file   requiredFile
+	status string
+	path   string // where the file was found, if anywhere.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	report := fleet.Table{Header: []string{"repository", "file", "status", "path"}}
+	jobs := make(map[string][]mkpr.Destination)
+	var compliant int
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		findings := make([]finding, 0, len(c.Files))
+		for _, v := range c.Files {
+			f, err := audit(ctx, client, owner, d, v)
+			if err != nil {
+				return "", err
+			}
+			findings = append(findings, f)
+		}
+
+		var problems []string
+		for _, v := range findings {
+			report.Add(owner+"/"+d.Repository, v.file.Name, v.status, v.path)
+			if v.status == _ok {
+				continue
+			}
+
+			problems = append(problems, v.status+" "+v.file.Name)
+			if c.MkprDir == "" || v.file.Source == "" || (v.status == _differs && v.path != v.file.Paths[0]) {
+				continue
+			}
+
+			// mkpr needs the base of every destination.
+			if d.Base == "" {
+				repo, _, err := client.Repositories.Get(ctx, owner, d.Repository)
+				if err != nil {
+					return "", fmt.Errorf("unable to get repository: %w", err)
+				}
+				d.Base = repo.GetDefaultBranch()
+			}
+			jobs[v.file.Name] = append(jobs[v.file.Name], d)
+		}
+
+		if len(problems) == 0 {
+			compliant++
+			return "compliant", nil
+		}
+
+		return strings.Join(problems, ", "), nil
+	})
+
+	fmt.Printf("%d of %d repositories compliant.\n", compliant, len(c.Destinations))
+	if err := fleet.WriteTable(*_report, report); err != nil {
+		return err
+	}
+	if err := writeJobs(c, jobs); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if len(c.Files) == 0 {
+		return errors.New("files are required")
+	}
+
+	for i, v := range c.Files {
+		if len(v.Paths) == 0 {
+			return errors.New("files need paths")
+		}
+		if c.Files[i].Name == "" {
+			c.Files[i].Name = v.Paths[0]
+		}
+		c.Files[i].SHA256 = strings.ToLower(v.SHA256)
+	}
+
+	return c.Validate()
+}
+
+// audit returns the status of the required file in the destination.
+func audit(ctx context.Context, client *github.Client, owner string, d mkpr.Destination, file requiredFile) (finding, error) {
+	for _, path := range file.Paths {
+		content, _, _, err := client.Repositories.GetContents(ctx, owner, d.Repository, path, &github.RepositoryContentGetOptions{Ref: d.Base})
+		if fleet.IsNotFound(err) || (err == nil && content == nil) {
+			// not found, or a directory.
+			continue
+		}
+		if err != nil {
+			return finding{}, fmt.Errorf("unable to get %s: %w", path, err)
+		}
+
+		if file.SHA256 == "" {
+			return finding{file: file, status: _ok, path: path}, nil
+		}
+
+		text, err := content.GetContent()
+		if err != nil {
+			return finding{}, fmt.Errorf("unable to decode %s: %w", path, err)
+		}
+
+		sum := sha256.Sum256([]byte(text))
+		if hex.EncodeToString(sum[:]) != file.SHA256 {
+			return finding{file: file, status: _differs, path: path}, nil
+		}
+
+		return finding{file: file, status: _ok, path: path}, nil
+	}
+
+	return finding{file: file, status: _missing}, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"gopkg.in/yaml.v3"
+)
+
+// _unsafe matches the characters left out of the names of the mkpr configs
+// and of their head branches.
+var _unsafe = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// job is the part of an mkpr config written for a required file.
+type job struct {
+	Version       int                `yaml:"version"`
+	CommitMessage string             `yaml:"commit_message"`
+	Subject       string             `yaml:"subject"`
+	Body          string             `yaml:"body"`
+	Head          string             `yaml:"head"`
+	Owner         string             `yaml:"owner"`
+	GitHubURL     string             `yaml:"github_url,omitempty"`
+	Delay         string             `yaml:"delay,omitempty"`
+	Destinations  []mkpr.Destination `yaml:"destinations"`
+	Files         []jobFile          `yaml:"files"`
+}
+
+type jobFile struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
+// writeJobs writes to the mkpr directory of the config a config adding each
+// required file to the destinations it was found missing or different in.
+func writeJobs(c config, jobs map[string][]mkpr.Destination) error {
+	if c.MkprDir == "" || len(jobs) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.MkprDir, 0o755); err != nil {
+		return err
+	}
+
+	for _, v := range c.Files {
+		destinations := jobs[v.Name]
+		if len(destinations) == 0 {
+			continue
+		}
+
+		name := _unsafe.ReplaceAllString(v.Name, "-")
+		content, err := yaml.Marshal(job{
+			Version:       2,
+			CommitMessage: "Add " + v.Paths[0],
+			Subject:       "Add " + v.Paths[0],
+			Body:          fmt.Sprintf("This is an autogenerated pull request adding the required %s.", v.Paths[0]),
+			Head:          "required-files/" + name,
+			Owner:         c.OwnerOrDefault(),
+			GitHubURL:     c.GitHubURL,
+			Delay:         c.Delay,
+			Destinations:  destinations,
+			Files:         []jobFile{{Source: v.Source, Target: v.Paths[0]}},
+		})
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(c.MkprDir, name+".yml")
+		if err := os.WriteFile(path, append([]byte("---\n"), content...), 0o644); err != nil {
+			return fmt.Errorf("unable to write %s: %w", path, err)
+		}
+		fmt.Printf("wrote %s for %d repositories.\n", path, len(destinations))
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+// Command rulesets creates or updates the rulesets of an organization and of
+// a batch of repositories from a declarative config.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the rulesets of the organization
+// and the ones of every destination, matched by name with the existing ones.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	OrgRulesets []ruleset `yaml:"org_rulesets"` // applied once to the owner, which must be an organization.
+	Rulesets    []ruleset `yaml:"rulesets"`     // applied to every destination.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	ctx := context.Background()
+	owner := c.OwnerOrDefault()
+	org := rulesets{client: client, prefix: "orgs/" + owner}
+	for _, v := range c.OrgRulesets {
+		summary, err := org.apply(ctx, v, _flags.DryRun)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %s\n", owner, summary)
+	}
+
+	if len(c.Rulesets) > 0 {
+		err = fleet.Run(ctx, c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+			repo := rulesets{client: client, prefix: "repos/" + owner + "/" + d.Repository}
+			summaries := make([]string, 0, len(c.Rulesets))
+			for _, v := range c.Rulesets {
+				summary, err := repo.apply(ctx, v, _flags.DryRun)
+				if err != nil {
+					return "", err
+				}
+				summaries = append(summaries, summary)
+			}
+
+			return strings.Join(summaries, ", "), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() error {
+	if len(c.OrgRulesets) == 0 && len(c.Rulesets) == 0 {
+		return errors.New("rulesets or org_rulesets are required")
+	}
+
+	for _, v := range append(append([]ruleset(nil), c.OrgRulesets...), c.Rulesets...) {
+		if v.Name == "" {
+			return errors.New("rulesets need a name")
+		}
+	}
+
+	if len(c.Rulesets) > 0 {
+		return c.Validate()
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/google/go-github/github"
+)
+
+// ruleset is a repository or organization ruleset, in the schema of the API
+// so rules and conditions are passed through as written.
+type ruleset struct {
+	ID           int64                    `yaml:"-" json:"id,omitempty"`
+	Name         string                   `yaml:"name" json:"name"`
+	Target       string                   `yaml:"target" json:"target,omitempty"`           // branch (default) or tag.
+	Enforcement  string                   `yaml:"enforcement" json:"enforcement,omitempty"` // active (default), evaluate or disabled.
+	BypassActors []map[string]interface{} `yaml:"bypass_actors" json:"bypass_actors,omitempty"`
+	Conditions   map[string]interface{}   `yaml:"conditions" json:"conditions,omitempty"`
+	Rules        []map[string]interface{} `yaml:"rules" json:"rules"`
+}
+
+// withDefaults returns the ruleset with the defaults of the API, so they are
+// not reported as drift.
+func (r ruleset) withDefaults() ruleset {
+	if r.Target == "" {
+		r.Target = "branch"
+	}
+	if r.Enforcement == "" {
+		r.Enforcement = "active"
+	}
+
+	return r
+}
+
+// changes returns the fields of current that differ from desired. Only the
+// keys set in desired are compared, as the API fills in the parameters left
+// out with their defaults.
+func (r ruleset) changes(current ruleset) []string {
+	fields := []struct {
+		name             string
+		desired, current interface{}
+	}{
+		{"target", r.Target, current.Target},
+		{"enforcement", r.Enforcement, current.Enforcement},
+		{"bypass_actors", r.BypassActors, current.BypassActors},
+		{"conditions", r.Conditions, current.Conditions},
+		{"rules", r.Rules, current.Rules},
+	}
+
+	var changes []string
+	for _, v := range fields {
+		if !contains(normalize(v.current), normalize(v.desired)) {
+			changes = append(changes, v.name)
+		}
+	}
+
+	return changes
+}
+
+// normalize returns v as decoded from JSON, so values from YAML and from the
+// API compare alike.
+func normalize(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+
+	return out
+}
+
+// contains reports whether current holds every key of the objects of desired
+// with the same value.
+func contains(current, desired interface{}) bool {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		c, ok := current.(map[string]interface{})
+		if !ok {
+			return len(d) == 0 && current == nil
+		}
+		for k, v := range d {
+			if !contains(c[k], v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		c, ok := current.([]interface{})
+		if !ok {
+			return len(d) == 0 && current == nil
+		}
+		if len(c) != len(d) {
+			return false
+		}
+		for i := range d {
+			if !contains(c[i], d[i]) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return true
+	default:
+		return reflect.DeepEqual(current, desired)
+	}
+}
+
+// rulesets reads and writes the rulesets of a repository or organization,
+// whose endpoints only differ by their prefix.
+type rulesets struct {
+	client *github.Client
+	prefix string // "repos/<owner>/<repo>" or "orgs/<org>".
+}
+
+// find returns the ruleset of the given name defined at this level, nil when
+// there is none.
+func (s rulesets) find(ctx context.Context, name string) (*ruleset, error) {
+	for page := 1; ; page++ {
+		req, err := s.client.NewRequest(http.MethodGet, fmt.Sprintf("%s/rulesets?includes_parents=false&per_page=100&page=%d", s.prefix, page), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var list []ruleset
+		resp, err := s.client.Do(ctx, req, &list)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list rulesets: %w", err)
+		}
+
+		for _, v := range list {
+			if v.Name == name {
+				// the list leaves the rules and conditions out.
+				return s.get(ctx, v.ID)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+	}
+}
+
+func (s rulesets) get(ctx context.Context, id int64) (*ruleset, error) {
+	req, err := s.client.NewRequest(http.MethodGet, fmt.Sprintf("%s/rulesets/%d", s.prefix, id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var out ruleset
+	if _, err := s.client.Do(ctx, req, &out); err != nil {
+		return nil, fmt.Errorf("unable to get ruleset: %w", err)
+	}
+
+	return &out, nil
+}
+
+// apply creates the ruleset, or updates the one of the same name when it
+// differs, returning what was done, or would be on a dry run.
+func (s rulesets) apply(ctx context.Context, desired ruleset, dryRun bool) (string, error) {
+	desired = desired.withDefaults()
+	current, err := s.find(ctx, desired.Name)
+	if err != nil {
+		return "", err
+	}
+
+	method, path := http.MethodPost, s.prefix+"/rulesets"
+	done, planned := "created", "would create"
+	if current != nil {
+		changes := desired.changes(*current)
+		if len(changes) == 0 {
+			return fmt.Sprintf("ruleset %q up to date", desired.Name), nil
+		}
+
+		method, path = http.MethodPut, fmt.Sprintf("%s/rulesets/%d", s.prefix, current.ID)
+		done, planned = fmt.Sprintf("updated %v of", changes), fmt.Sprintf("would update %v of", changes)
+	}
+
+	if dryRun {
+		return fmt.Sprintf("%s ruleset %q", planned, desired.Name), nil
+	}
+
+	req, err := s.client.NewRequest(method, path, desired)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.client.Do(ctx, req, nil); err != nil {
+		return "", fmt.Errorf("unable to save ruleset %q: %w", desired.Name, err)
+	}
+
+	return fmt.Sprintf("%s ruleset %q", done, desired.Name), nil
+}
@@ -0,0 +1,116 @@
+// Command sbom downloads the SPDX SBOM of the dependency graph of a batch of
+// repositories, writing one file per repository, a fleet-wide inventory of
+// the packages, or both.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var (
+	_flags     *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_dir       *string      = flag.String("dir", "", "Writes the SBOM of every destination to <repository>.spdx.json in the given directory")
+	_inventory *string      = flag.String("inventory", "", "Writes the packages of every SBOM to the given file, as CSV or, ending in .json, as JSON")
+)
+
+// config is the content of a config file: the destinations to collect the
+// SBOM of.
+type config struct {
+	fleet.Config `yaml:",inline"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if *_dir == "" && *_inventory == "" {
+		return errors.New("-dir or -inventory is required")
+	}
+	if *_dir != "" {
+		if err := os.MkdirAll(*_dir, 0o755); err != nil {
+			return fmt.Errorf("unable to create %s: %w", *_dir, err)
+		}
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	inv := make(inventory)
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		var out struct {
+			SBOM json.RawMessage `json:"sbom"`
+		}
+		err := fleet.Send(ctx, client, http.MethodGet, "repos/"+owner+"/"+d.Repository+"/dependency-graph/sbom", nil, &out)
+		switch {
+		case fleet.IsNotFound(err):
+			// repositories without a dependency graph.
+			return "dependency graph not enabled", nil
+		case err != nil:
+			return "", fmt.Errorf("unable to get SBOM: %w", err)
+		}
+
+		var doc document
+		if err := json.Unmarshal(out.SBOM, &doc); err != nil {
+			return "", fmt.Errorf("unable to decode SBOM: %w", err)
+		}
+		packages := doc.dependencies()
+		inv.add(owner+"/"+d.Repository, packages)
+
+		summary := fmt.Sprintf("%d packages", len(packages))
+		if *_dir != "" {
+			path := filepath.Join(*_dir, d.Repository+".spdx.json")
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, out.SBOM, "", "  "); err != nil {
+				return "", fmt.Errorf("unable to format SBOM: %w", err)
+			}
+			if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+				return "", fmt.Errorf("unable to write %s: %w", path, err)
+			}
+			summary += " written to " + path
+		}
+
+		return summary, nil
+	})
+
+	if *_inventory != "" {
+		if err := fleet.WriteTable(*_inventory, inv.table()); err != nil {
+			return err
+		}
+		fmt.Printf("%d distinct packages written to %s\n", len(inv), *_inventory)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+// document is the part of an SPDX document read for the inventory.
+type document struct {
+	DocumentDescribes []string `json:"documentDescribes"`
+	Packages          []struct {
+		SPDXID           string `json:"SPDXID"`
+		Name             string `json:"name"`
+		VersionInfo      string `json:"versionInfo"`
+		LicenseConcluded string `json:"licenseConcluded"`
+		LicenseDeclared  string `json:"licenseDeclared"`
+		ExternalRefs     []struct {
+			ReferenceType    string `json:"referenceType"`
+			ReferenceLocator string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+}
+
+// pkg is a package of the inventory.
+type pkg struct {
+	name, version, license, purl string
+}
+
+// dependencies returns the packages of the document but the ones it
+// describes, that is, the repository itself.
+func (d document) dependencies() []pkg {
+	described := make(map[string]bool, len(d.DocumentDescribes))
+	for _, v := range d.DocumentDescribes {
+		described[v] = true
+	}
+
+	var out []pkg
+	for _, v := range d.Packages {
+		if described[v.SPDXID] {
+			continue
+		}
+
+		p := pkg{name: v.Name, version: v.VersionInfo, license: v.LicenseConcluded}
+		if p.license == "" || p.license == "NOASSERTION" {
+			p.license = v.LicenseDeclared
+		}
+		for _, ref := range v.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				p.purl = ref.ReferenceLocator
+				break
+			}
+		}
+		out = append(out, p)
+	}
+
+	return out
+}
+
+// inventory is the repositories using every package, by name and version.
+type inventory map[pkg]map[string]bool
+
+func (inv inventory) add(repository string, packages []pkg) {
+	for _, v := range packages {
+		if inv[v] == nil {
+			inv[v] = make(map[string]bool)
+		}
+		inv[v][repository] = true
+	}
+}
+
+// table returns the packages sorted by name and version, with the
+// repositories using them.
+func (inv inventory) table() fleet.Table {
+	packages := make([]pkg, 0, len(inv))
+	for k := range inv {
+		packages = append(packages, k)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		a, b := packages[i], packages[j]
+		switch {
+		case a.name != b.name:
+			return a.name < b.name
+		case a.version != b.version:
+			return a.version < b.version
+		default:
+			return a.purl < b.purl
+		}
+	})
+
+	t := fleet.Table{Header: []string{"package", "version", "license", "purl", "count", "repositories"}}
+	for _, v := range packages {
+		repositories := make([]string, 0, len(inv[v]))
+		for k := range inv[v] {
+			repositories = append(repositories, k)
+		}
+		sort.Strings(repositories)
+
+		t.Add(v.name, v.version, v.license, v.purl, strconv.Itoa(len(repositories)), strings.Join(repositories, " "))
+	}
+
+	return t
+}
@@ -0,0 +1,289 @@
+// Command secret-scanning-alerts lists the secret scanning alerts of a batch
+// of repositories with their resolution, to coordinate the revocation of the
+// leaked credentials.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// _resolutions are the resolutions of the resolved alerts.
+var _resolutions = fleet.Ranking{"revoked", "false_positive", "wont_fix", "used_in_tests"}
+
+var (
+	_flags  *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+	_report *string      = flag.String("report", "", "Writes every alert to the given file, as CSV or, ending in .json, as JSON")
+)
+
+// config is the content of a config file: the destinations and the alerts
+// listed.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	State       string   `yaml:"state"`        // open or resolved, every alert when empty.
+	Resolutions []string `yaml:"resolutions"`  // any of _resolutions, only listing resolved alerts.
+	SecretTypes []string `yaml:"secret_types"` // for instance, github_personal_access_token, every type when empty.
+}
+
+// alert is a secret scanning alert, in the schema of the API. The secret
+// itself is never decoded.
+type alert struct {
+	Number                int        `json:"number"`
+	HTMLURL               string     `json:"html_url"`
+	State                 string     `json:"state"`
+	Resolution            string     `json:"resolution"`
+	SecretType            string     `json:"secret_type"`
+	SecretTypeDisplayName string     `json:"secret_type_display_name"`
+	CreatedAt             time.Time  `json:"created_at"`
+	ResolvedAt            *time.Time `json:"resolved_at"`
+	ResolvedBy            *struct {
+		Login string `json:"login"`
+	} `json:"resolved_by"`
+	PushProtectionBypassed bool `json:"push_protection_bypassed"`
+
+	repository string
+}
+
+// status returns the state of the alert, with the resolution of the resolved
+// ones.
+func (a alert) status() string {
+	if a.State == "resolved" && a.Resolution != "" {
+		return a.Resolution
+	}
+
+	return a.State
+}
+
+func (a alert) secretType() string {
+	if a.SecretTypeDisplayName != "" {
+		return a.SecretTypeDisplayName
+	}
+
+	return a.SecretType
+}
+
+func (a alert) resolvedBy() string {
+	if a.ResolvedBy != nil {
+		return a.ResolvedBy.Login
+	}
+
+	return ""
+}
+
+func (a alert) resolvedAt() string {
+	if a.ResolvedAt != nil {
+		return a.ResolvedAt.Format(time.RFC3339)
+	}
+
+	return ""
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	query := url.Values{"per_page": {"100"}}
+	if c.State != "" {
+		query.Set("state", c.State)
+	}
+	if len(c.Resolutions) > 0 {
+		query.Set("resolution", strings.Join(c.Resolutions, ","))
+	}
+	if len(c.SecretTypes) > 0 {
+		query.Set("secret_type", strings.Join(c.SecretTypes, ","))
+	}
+
+	var alerts []alert
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		counts := make(map[string]int)
+		path := "repos/" + owner + "/" + d.Repository + "/secret-scanning/alerts?" + query.Encode()
+		err := fleet.SendAll(ctx, client, path, func(item json.RawMessage) error {
+			var a alert
+			if err := json.Unmarshal(item, &a); err != nil {
+				return err
+			}
+
+			a.repository = owner + "/" + d.Repository
+			alerts = append(alerts, a)
+			counts[a.status()]++
+			return nil
+		})
+		switch {
+		case fleet.IsNotFound(err):
+			// repositories with secret scanning disabled.
+			return "secret scanning disabled", nil
+		case err != nil:
+			return "", fmt.Errorf("unable to list alerts: %w", err)
+		}
+
+		return summary(counts), nil
+	})
+
+	printSecretTypes(alerts)
+	if err := fleet.WriteTable(*_report, table(alerts)); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() error {
+	switch c.State {
+	case "", "open", "resolved":
+	default:
+		return fmt.Errorf("invalid state %q, expected open or resolved", c.State)
+	}
+
+	if err := _resolutions.Check("resolution", c.Resolutions); err != nil {
+		return err
+	}
+	if len(c.Resolutions) > 0 && c.State == "open" {
+		return errors.New("resolutions only apply to resolved alerts")
+	}
+
+	return c.Validate()
+}
+
+// summary describes the alerts of a destination by state and resolution.
+func summary(counts map[string]int) string {
+	var resolved int
+	var parts []string
+	for _, v := range _resolutions {
+		if counts[v] > 0 {
+			resolved += counts[v]
+			parts = append(parts, fmt.Sprintf("%d %s", counts[v], v))
+		}
+	}
+	// resolved alerts without a resolution, if any.
+	resolved += counts["resolved"]
+
+	switch {
+	case counts["open"] == 0 && resolved == 0:
+		return "no alerts"
+	case resolved == 0:
+		return fmt.Sprintf("%d open alerts", counts["open"])
+	case len(parts) == 0:
+		return fmt.Sprintf("%d open alerts, %d resolved", counts["open"], resolved)
+	default:
+		return fmt.Sprintf("%d open alerts, %d resolved (%s)", counts["open"], resolved, strings.Join(parts, ", "))
+	}
+}
+
+// group is the alerts of a secret type.
+type group struct {
+	secretType   string
+	open         int
+	revoked      int
+	resolved     int // revoked alerts included.
+	repositories map[string]bool
+}
+
+// printSecretTypes prints the alerts grouped by secret type, the ones with
+// the most open alerts first.
+func printSecretTypes(alerts []alert) {
+	groups := make(map[string]*group)
+	for _, v := range alerts {
+		g := groups[v.secretType()]
+		if g == nil {
+			g = &group{secretType: v.secretType(), repositories: make(map[string]bool)}
+			groups[v.secretType()] = g
+		}
+
+		g.repositories[v.repository] = true
+		switch {
+		case v.State == "open":
+			g.open++
+		case v.Resolution == "revoked":
+			g.revoked++
+			g.resolved++
+		default:
+			g.resolved++
+		}
+	}
+	if len(groups) == 0 {
+		return
+	}
+
+	sorted := make([]*group, 0, len(groups))
+	for _, v := range groups {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch {
+		case a.open != b.open:
+			return a.open > b.open
+		case len(a.repositories) != len(b.repositories):
+			return len(a.repositories) > len(b.repositories)
+		default:
+			return a.secretType < b.secretType
+		}
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "secret type\topen\tresolved\trevoked\trepositories")
+	for _, v := range sorted {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", v.secretType, v.open, v.resolved, v.revoked, len(v.repositories))
+	}
+	w.Flush()
+}
+
+func table(alerts []alert) fleet.Table {
+	t := fleet.Table{Header: []string{"repository", "number", "secret_type", "state", "resolution", "resolved_by", "resolved_at", "created_at", "push_protection_bypassed", "url"}}
+	for _, v := range alerts {
+		t.Add(
+			v.repository,
+			strconv.Itoa(v.Number),
+			v.SecretType,
+			v.State,
+			v.Resolution,
+			v.resolvedBy(),
+			v.resolvedAt(),
+			v.CreatedAt.Format(time.RFC3339),
+			strconv.FormatBool(v.PushProtectionBypassed),
+			v.HTMLURL,
+		)
+	}
+
+	return t
+}
@@ -0,0 +1,197 @@
+// Command stale-branches lists, and optionally deletes, the branches of a
+// batch of repositories without recent commits nor open pull requests.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the branches
+// considered stale.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	// Patterns the branches must match, in path.Match syntax, for instance,
+	// "feature/*". Default and protected branches are never stale.
+	Patterns []string `yaml:"patterns"`
+	Days     int      `yaml:"days"`   // days without commits, 90 by default.
+	Delete   bool     `yaml:"delete"` // deletes the stale branches instead of only listing them.
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	cutoff := time.Now().AddDate(0, 0, -c.Days)
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		r := repository{client: client, owner: owner, name: d.Repository}
+		stale, err := r.stale(ctx, c.Patterns, cutoff)
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case len(stale) == 0:
+			return "no stale branches", nil
+		case !c.Delete:
+			return "stale " + strings.Join(stale, ", "), nil
+		case _flags.DryRun:
+			return "would delete " + strings.Join(stale, ", "), nil
+		}
+
+		for i, v := range stale {
+			if _, err := client.Git.DeleteRef(ctx, owner, d.Repository, "refs/heads/"+v); err != nil {
+				return "", fmt.Errorf("unable to delete %s, after deleting %s: %w", v, strings.Join(stale[:i], ", "), err)
+			}
+		}
+
+		return "deleted " + strings.Join(stale, ", "), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if len(c.Patterns) == 0 {
+		return errors.New("patterns are required, use \"*\" and \"*/*\" for every branch")
+	}
+
+	for _, v := range c.Patterns {
+		if _, err := path.Match(v, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", v, err)
+		}
+	}
+
+	if c.Days < 0 {
+		return errors.New("days must be positive")
+	}
+	if c.Days == 0 {
+		c.Days = 90
+	}
+
+	return c.Validate()
+}
+
+// repository finds the stale branches of a repository.
+type repository struct {
+	client *github.Client
+	owner  string
+	name   string
+}
+
+// stale returns the branches matching any pattern, neither default nor
+// protected, whose last commit is older than cutoff and without open pull
+// requests.
+func (r repository) stale(ctx context.Context, patterns []string, cutoff time.Time) ([]string, error) {
+	repo, _, err := r.client.Repositories.Get(ctx, r.owner, r.name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get repository: %w", err)
+	}
+
+	heads, err := r.openHeads(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		branches, resp, err := r.client.Repositories.ListBranches(ctx, r.owner, r.name, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list branches: %w", err)
+		}
+
+		for _, v := range branches {
+			name := v.GetName()
+			if name == repo.GetDefaultBranch() || v.GetProtected() || heads[name] || !matches(patterns, name) {
+				continue
+			}
+
+			commit, _, err := r.client.Git.GetCommit(ctx, r.owner, r.name, v.GetCommit().GetSHA())
+			if err != nil {
+				return nil, fmt.Errorf("unable to get the last commit of %s: %w", name, err)
+			}
+
+			if commit.GetCommitter().GetDate().Before(cutoff) {
+				stale = append(stale, name)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return stale, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// openHeads returns the branches of the repository with open pull requests.
+func (r repository) openHeads(ctx context.Context) (map[string]bool, error) {
+	heads := make(map[string]bool)
+	opt := &github.PullRequestListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		pulls, resp, err := r.client.PullRequests.List(ctx, r.owner, r.name, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list pull requests: %w", err)
+		}
+
+		for _, v := range pulls {
+			if v.GetHead().GetRepo().GetFullName() == r.owner+"/"+r.name {
+				heads[v.GetHead().GetRef()] = true
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return heads, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func matches(patterns []string, name string) bool {
+	for _, v := range patterns {
+		if ok, _ := path.Match(v, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
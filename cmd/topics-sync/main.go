@@ -0,0 +1,160 @@
+// Command topics-sync adds, removes or replaces the topics of a batch of
+// repositories.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the changes
+// to their topics.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Topics topics `yaml:"topics"`
+}
+
+// topics are the changes to the topics of every destination: Replace sets
+// them all, otherwise Add and Remove are applied to the current ones.
+type topics struct {
+	Add     []string `yaml:"add"`
+	Remove  []string `yaml:"remove"`
+	Replace []string `yaml:"replace"`
+}
+
+// _topic is the format GitHub accepts for topics.
+var _topic = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.Topics.validate(); err != nil {
+		return err
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		current, _, err := client.Repositories.ListAllTopics(ctx, owner, d.Repository)
+		if err != nil {
+			return "", fmt.Errorf("unable to list topics: %w", err)
+		}
+
+		desired := c.Topics.apply(current)
+		changes := diff(current, desired)
+		if len(changes) == 0 || _flags.DryRun {
+			return fleet.Summary(changes, _flags.DryRun), nil
+		}
+
+		if _, _, err := client.Repositories.ReplaceAllTopics(ctx, owner, d.Repository, desired); err != nil {
+			return "", fmt.Errorf("unable to replace topics: %w", err)
+		}
+
+		return fleet.Summary(changes, false), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (t topics) validate() error {
+	if len(t.Replace) > 0 && (len(t.Add) > 0 || len(t.Remove) > 0) {
+		return errors.New("topics replace cannot be combined with add nor remove")
+	}
+
+	if len(t.Replace) == 0 && len(t.Add) == 0 && len(t.Remove) == 0 {
+		return errors.New("topics add, remove or replace are required")
+	}
+
+	for _, v := range append(append(append([]string(nil), t.Add...), t.Remove...), t.Replace...) {
+		if !_topic.MatchString(v) {
+			return fmt.Errorf("invalid topic %q, topics are lowercase letters, numbers and hyphens", v)
+		}
+	}
+
+	return nil
+}
+
+// apply returns the topics resulting of applying the changes to current,
+// sorted.
+func (t topics) apply(current []string) []string {
+	set := make(map[string]bool)
+	if len(t.Replace) > 0 {
+		current = t.Replace
+	}
+
+	for _, v := range current {
+		set[v] = true
+	}
+	for _, v := range t.Add {
+		set[v] = true
+	}
+	for _, v := range t.Remove {
+		delete(set, v)
+	}
+
+	out := make([]string, 0, len(set))
+	for v := range set {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// diff returns the topics added and removed from current to desired.
+func diff(current, desired []string) []string {
+	have := make(map[string]bool, len(current))
+	for _, v := range current {
+		have[v] = true
+	}
+
+	var changes []string
+	for _, v := range desired {
+		if !have[v] {
+			changes = append(changes, "add "+v)
+		}
+		delete(have, v)
+	}
+
+	removed := make([]string, 0, len(have))
+	for v := range have {
+		removed = append(removed, "remove "+v)
+	}
+	sort.Strings(removed)
+
+	return append(changes, removed...)
+}
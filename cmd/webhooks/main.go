@@ -0,0 +1,91 @@
+// Command webhooks creates, updates or deletes the same webhooks on a batch
+// of repositories.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and their
+// webhooks.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Webhooks []webhook `yaml:"webhooks"`
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	owner := c.OwnerOrDefault()
+	err = fleet.Run(context.Background(), c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		existing, err := listHooks(ctx, client, owner, d.Repository)
+		if err != nil {
+			return "", err
+		}
+
+		summaries := make([]string, 0, len(c.Webhooks))
+		for _, v := range c.Webhooks {
+			summary, err := v.apply(ctx, client, owner, d.Repository, existing, _flags.DryRun)
+			if err != nil {
+				return "", err
+			}
+			summaries = append(summaries, summary)
+		}
+
+		return strings.Join(summaries, ", "), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c *config) validate() error {
+	if len(c.Webhooks) == 0 {
+		return fmt.Errorf("webhooks are required")
+	}
+
+	for i := range c.Webhooks {
+		if err := c.Webhooks[i].resolve(); err != nil {
+			return err
+		}
+		redact.Secret(c.Webhooks[i].secret)
+	}
+
+	return c.Validate()
+}
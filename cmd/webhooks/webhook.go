@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// webhook is a repository webhook, identified by its URL.
+type webhook struct {
+	URL         string   `yaml:"url"`
+	Events      []string `yaml:"events"`       // "push" by default.
+	ContentType string   `yaml:"content_type"` // json (default) or form.
+	InsecureSSL bool     `yaml:"insecure_ssl"` // skips the verification of the certificate of the URL.
+	Inactive    bool     `yaml:"inactive"`     // creates the webhook without delivering events.
+
+	// SecretEnv is the environment variable holding the secret signing the
+	// deliveries, kept out of the config file. The secret cannot be read
+	// back, so it is only sent when the webhook is created or updated, or on
+	// every destination when RotateSecret is set.
+	SecretEnv    string `yaml:"secret_env"`
+	RotateSecret bool   `yaml:"rotate_secret"`
+
+	// Delete deletes the webhooks with the URL instead.
+	Delete bool `yaml:"delete"`
+
+	secret string
+}
+
+// resolve validates the webhook, filling in its defaults and its secret.
+func (w *webhook) resolve() error {
+	if w.URL == "" {
+		return errors.New("webhooks need a url")
+	}
+
+	if len(w.Events) == 0 {
+		w.Events = []string{"push"}
+	}
+	if w.ContentType == "" {
+		w.ContentType = "json"
+	}
+
+	if w.SecretEnv != "" {
+		if w.secret = os.Getenv(w.SecretEnv); w.secret == "" {
+			return fmt.Errorf("the secret of the webhook %s is not set in %s", w.URL, w.SecretEnv)
+		}
+	}
+
+	if w.RotateSecret && w.secret == "" {
+		return fmt.Errorf("the webhook %s rotates its secret but has no secret_env", w.URL)
+	}
+
+	return nil
+}
+
+// listHooks returns the webhooks of the repository.
+func listHooks(ctx context.Context, client *github.Client, owner, repo string) ([]*github.Hook, error) {
+	var hooks []*github.Hook
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Repositories.ListHooks(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list webhooks: %w", err)
+		}
+		hooks = append(hooks, page...)
+
+		if resp.NextPage == 0 {
+			return hooks, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// apply creates, updates or deletes the webhook among the existing ones of
+// the repository, returning what was done, or would be on a dry run.
+func (w webhook) apply(ctx context.Context, client *github.Client, owner, repo string, existing []*github.Hook, dryRun bool) (string, error) {
+	var found []*github.Hook
+	for _, v := range existing {
+		if url, _ := v.Config["url"].(string); url == w.URL {
+			found = append(found, v)
+		}
+	}
+
+	verb := ""
+	switch {
+	case w.Delete && len(found) == 0:
+		return "no webhook " + w.URL, nil
+	case w.Delete:
+		verb = "delete"
+	case len(found) == 0:
+		verb = "create"
+	case w.RotateSecret || !w.matches(found[0]):
+		verb = "update"
+	default:
+		return "webhook " + w.URL + " up to date", nil
+	}
+
+	if dryRun {
+		return "would " + verb + " webhook " + w.URL, nil
+	}
+
+	var err error
+	switch verb {
+	case "delete":
+		for _, v := range found {
+			if _, err = client.Repositories.DeleteHook(ctx, owner, repo, v.GetID()); err != nil {
+				break
+			}
+		}
+	case "create":
+		_, _, err = client.Repositories.CreateHook(ctx, owner, repo, w.hook())
+	case "update":
+		_, _, err = client.Repositories.EditHook(ctx, owner, repo, found[0].GetID(), w.hook())
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to %s webhook %s: %w", verb, w.URL, err)
+	}
+
+	return verb + "d webhook " + w.URL, nil
+}
+
+// matches reports whether the existing webhook has the settings of w, but for
+// the secret, which is not readable.
+func (w webhook) matches(h *github.Hook) bool {
+	contentType, _ := h.Config["content_type"].(string)
+	insecure, _ := h.Config["insecure_ssl"].(string)
+
+	return h.GetActive() == !w.Inactive &&
+		contentType == w.ContentType &&
+		(insecure == "1") == w.InsecureSSL &&
+		sameSet(h.Events, w.Events)
+}
+
+func (w webhook) hook() *github.Hook {
+	insecure := "0"
+	if w.InsecureSSL {
+		insecure = "1"
+	}
+
+	config := map[string]interface{}{
+		"url":          w.URL,
+		"content_type": w.ContentType,
+		"insecure_ssl": insecure,
+	}
+	if w.secret != "" {
+		config["secret"] = w.secret
+	}
+
+	return &github.Hook{
+		Name:   github.String("web"),
+		Events: w.Events,
+		Active: github.Bool(!w.Inactive),
+		Config: config,
+	}
+}
+
+// sameSet reports whether a and b hold the same values, in any order.
+func sameSet(a, b []string) bool {
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
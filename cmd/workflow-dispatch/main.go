@@ -0,0 +1,135 @@
+// Command workflow-dispatch triggers the same workflow on a batch of
+// repositories, optionally waiting for the runs to report their conclusions.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+var _flags *fleet.Flags = fleet.RegisterFlags(flag.CommandLine, "config.yml")
+
+// config is the content of a config file: the destinations and the workflow
+// to dispatch on each of them.
+type config struct {
+	fleet.Config `yaml:",inline"`
+
+	Workflow string            `yaml:"workflow"` // file name or ID of the workflow, for instance, "rebuild.yml".
+	Ref      string            `yaml:"ref"`      // branch or tag to run on, overridden by the base of the destinations, the default branch when both are empty.
+	Inputs   map[string]string `yaml:"inputs"`   // inputs of the workflow, rendered for each destination.
+
+	// Wait waits for the runs to complete, reporting their conclusions, for
+	// up to Timeout ("30m" by default).
+	Wait    bool   `yaml:"wait"`
+	Timeout string `yaml:"timeout"`
+}
+
+// _pollInterval is the time between the lookups of the runs waited for.
+const _pollInterval = 15 * time.Second
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fleet.Report(err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var c config
+	if err := fleet.ParseFile(_flags.Config, &c); err != nil {
+		return err
+	}
+	_flags.Apply(&c.Config)
+
+	timeout, err := c.validate()
+	if err != nil {
+		return err
+	}
+
+	client, err := _flags.Client(c.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("hold ...")
+	ctx := context.Background()
+	owner := c.OwnerOrDefault()
+	var dispatched []dispatch
+	err = fleet.Run(ctx, c.Config, func(ctx context.Context, d mkpr.Destination) (string, error) {
+		ref := c.Ref
+		if d.Base != "" {
+			ref = d.Base
+		}
+		if ref == "" {
+			repository, _, err := client.Repositories.Get(ctx, owner, d.Repository)
+			if err != nil {
+				return "", fmt.Errorf("unable to get repository: %w", err)
+			}
+			ref = repository.GetDefaultBranch()
+		}
+
+		inputs := make(map[string]string, len(c.Inputs))
+		for k, v := range c.Inputs {
+			rendered, err := mkpr.Render(k, v, c.Data(d))
+			if err != nil {
+				return "", err
+			}
+			inputs[k] = rendered
+		}
+
+		if _flags.DryRun {
+			return fmt.Sprintf("would dispatch %s on %s", c.Workflow, ref), nil
+		}
+
+		prefix := "repos/" + owner + "/" + d.Repository + "/actions/workflows/" + url.PathEscape(c.Workflow)
+		since := time.Now()
+		body := map[string]interface{}{"ref": ref, "inputs": inputs}
+		if err := fleet.Send(ctx, client, http.MethodPost, prefix+"/dispatches", body, nil); err != nil {
+			return "", fmt.Errorf("unable to dispatch %s: %w", c.Workflow, err)
+		}
+
+		dispatched = append(dispatched, dispatch{repository: owner + "/" + d.Repository, prefix: prefix, ref: ref, since: since})
+		return fmt.Sprintf("dispatched %s on %s", c.Workflow, ref), nil
+	})
+
+	// the runs of the destinations that were dispatched are waited for even
+	// when others failed, to report their conclusions.
+	if c.Wait && len(dispatched) > 0 {
+		fmt.Println("waiting for the runs ...")
+		if waitErr := wait(ctx, client, dispatched, timeout); err == nil {
+			err = waitErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("done.")
+	return nil
+}
+
+func (c config) validate() (time.Duration, error) {
+	if c.Workflow == "" {
+		return 0, errors.New("workflow is required")
+	}
+
+	timeout := 30 * time.Minute
+	if c.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil {
+			return 0, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+
+	return timeout, c.Validate()
+}
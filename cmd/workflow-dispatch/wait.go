@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// dispatch is a workflow dispatched on a destination.
+type dispatch struct {
+	repository string // owner/repo.
+	prefix     string // API path of the workflow.
+	ref        string
+	since      time.Time
+}
+
+// workflowRun is a run of a workflow.
+type workflowRun struct {
+	HTMLURL    string    `json:"html_url"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// errConclusion reports a run that did not succeed.
+var errConclusion = errors.New("workflow run did not succeed")
+
+// wait polls the runs of the dispatches until they complete or the timeout
+// expires, printing their conclusions. The dispatches that did not succeed
+// are returned as a *mkpr.BatchError.
+func wait(ctx context.Context, client *github.Client, pending []dispatch, timeout time.Duration) error {
+	var (
+		failed   []*mkpr.DestinationError
+		deadline = time.Now().Add(timeout)
+	)
+	for len(pending) > 0 {
+		if time.Now().After(deadline) {
+			for _, v := range pending {
+				failed = append(failed, &mkpr.DestinationError{Repository: v.repository, Err: fmt.Errorf("timed out waiting for the run")})
+			}
+			break
+		}
+		time.Sleep(_pollInterval)
+
+		var next []dispatch
+		for _, v := range pending {
+			run, err := v.run(ctx, client)
+			switch {
+			case err != nil:
+				failed = append(failed, &mkpr.DestinationError{Repository: v.repository, Err: err})
+			case run == nil || run.Status != "completed":
+				next = append(next, v)
+			default:
+				fmt.Printf("%s: %s %s\n", v.repository, run.Conclusion, run.HTMLURL)
+				if run.Conclusion != "success" {
+					failed = append(failed, &mkpr.DestinationError{Repository: v.repository, Err: fmt.Errorf("%w: %s %s", errConclusion, run.Conclusion, run.HTMLURL)})
+				}
+			}
+		}
+		pending = next
+	}
+
+	if len(failed) > 0 {
+		return &mkpr.BatchError{Errors: failed}
+	}
+
+	return nil
+}
+
+// run returns the run created by the dispatch, nil while it does not show up.
+// The dispatch API does not return the run, so it is the oldest run of the
+// ref created by a dispatch since then.
+func (d dispatch) run(ctx context.Context, client *github.Client) (*workflowRun, error) {
+	// a few seconds of margin for the clock skew with GitHub.
+	since := d.since.Add(-5 * time.Second).UTC().Format(time.RFC3339)
+	path := fmt.Sprintf("%s/runs?event=workflow_dispatch&branch=%s&created=%s&per_page=100", d.prefix, url.QueryEscape(d.ref), url.QueryEscape(">="+since))
+
+	var out struct {
+		WorkflowRuns []workflowRun `json:"workflow_runs"`
+	}
+	if err := fleet.Send(ctx, client, http.MethodGet, path, nil, &out); err != nil {
+		return nil, fmt.Errorf("unable to list the workflow runs: %w", err)
+	}
+
+	// runs are listed newest first.
+	if n := len(out.WorkflowRuns); n > 0 {
+		return &out.WorkflowRuns[n-1], nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,115 @@
+// Package credentials resolves the token used to authenticate against the
+// GitHub API.
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// EnvVar is the environment variable holding the token by default.
+const EnvVar = "GITHUB_AUTH_TOKEN"
+
+// ErrNotFound is returned when no source provides a token.
+var ErrNotFound = errors.New("no GitHub token found: set " + EnvVar + ", use -token, -token-file or -token-command, or run mkpr login")
+
+// Source describes where the token can be read from. Sources are tried in
+// field order, falling back to the EnvVar environment variable, the .netrc
+// file, the token saved by Store and the credentials of the gh CLI.
+type Source struct {
+	Token   string // the token itself.
+	File    string // path of a file holding the token.
+	Command string // shell command printing the token to its standard output.
+	Host    string // host whose stored credentials are used, DefaultHost when empty.
+}
+
+// Resolve returns the tokens of the first configured source. Several tokens
+// can be given, separated by commas in Token and EnvVar or by new lines in the
+// output of File and Command, to spread the requests of large batches among
+// them.
+func (s Source) Resolve(ctx context.Context) ([]string, error) {
+	switch {
+	case s.Token != "":
+		return nonEmpty(s.Token, ",", "token")
+	case s.File != "":
+		return fromFile(s.File)
+	case s.Command != "":
+		return fromCommand(ctx, s.Command)
+	}
+
+	if tokens := split(os.Getenv(EnvVar), ","); len(tokens) > 0 {
+		return tokens, nil
+	}
+
+	host := s.Host
+	if host == "" {
+		host = DefaultHost
+	}
+
+	if token := fromNetrc(host); token != "" {
+		return []string{token}, nil
+	}
+
+	token, err := fromStore(host)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		return []string{token}, nil
+	}
+
+	if token := fromGitHubCLI(ctx, host); token != "" {
+		return []string{token}, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+func fromFile(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read token file: %w", err)
+	}
+
+	return nonEmpty(string(content), "\n", "token file "+path)
+}
+
+func fromCommand(ctx context.Context, command string) ([]string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // the command is given by the user on purpose.
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("token command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nonEmpty(string(out), "\n", "token command")
+}
+
+// nonEmpty returns the tokens of content separated by sep, failing when there
+// is none.
+func nonEmpty(content, sep, source string) ([]string, error) {
+	tokens := split(content, sep)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%s is empty", source)
+	}
+
+	return tokens, nil
+}
+
+// split returns the non blank values of s separated by sep.
+func split(s, sep string) []string {
+	var values []string
+	for _, v := range strings.Split(s, sep) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
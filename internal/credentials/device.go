@@ -0,0 +1,110 @@
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DeviceCode is the code the user enters at VerificationURI to authorize the
+// device flow.
+type DeviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// _devicePollInterval is the default polling interval of the device flow, and
+// its increment when asked to slow down.
+const _devicePollInterval = 5 * time.Second
+
+type deviceToken struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// DeviceLogin runs the OAuth device authorization flow of the OAuth App
+// clientID against host and returns the granted token. prompt is called with
+// the code the user must enter before polling for the authorization.
+func DeviceLogin(ctx context.Context, client *http.Client, host, clientID string, scopes []string, prompt func(DeviceCode)) (string, error) {
+	var code DeviceCode
+	err := postForm(ctx, client, "https://"+host+"/login/device/code", url.Values{
+		"client_id": {clientID},
+		"scope":     {strings.Join(scopes, " ")},
+	}, &code)
+	if err != nil {
+		return "", fmt.Errorf("unable to request a device code: %w", err)
+	}
+
+	if code.DeviceCode == "" {
+		return "", errors.New("unable to request a device code: empty response, is the device flow enabled for the OAuth App?")
+	}
+
+	prompt(code)
+
+	// RFC 8628: 5 seconds when the server gives no interval, and 5 more on
+	// every slow_down.
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = _devicePollInterval
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var token deviceToken
+		err := postForm(ctx, client, "https://"+host+"/login/oauth/access_token", url.Values{
+			"client_id":   {clientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		}, &token)
+		if err != nil {
+			return "", fmt.Errorf("unable to poll the access token: %w", err)
+		}
+
+		switch token.Error {
+		case "":
+			return token.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += _devicePollInterval
+		default:
+			return "", fmt.Errorf("device authorization failed: %s: %s", token.Error, token.Description)
+		}
+	}
+
+	return "", errors.New("device authorization expired")
+}
+
+func postForm(ctx context.Context, client *http.Client, endpoint string, values url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
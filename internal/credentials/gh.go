@@ -0,0 +1,68 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHost is the host of github.com credentials.
+const DefaultHost = "github.com"
+
+// fromGitHubCLI returns the token stored by "gh auth login" for host. Tokens
+// stored in plain text in the gh hosts.yml file are read directly, while the
+// ones kept in the system keyring are obtained with "gh auth token". An empty
+// token is returned when gh has none.
+func fromGitHubCLI(ctx context.Context, host string) string {
+	if token := fromGitHubCLIConfig(host); token != "" {
+		return token
+	}
+
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gh", "auth", "token", "--hostname", host)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(stdout.String())
+}
+
+func fromGitHubCLIConfig(host string) string {
+	content, err := os.ReadFile(filepath.Join(ghConfigDir(), "hosts.yml"))
+	if err != nil {
+		return ""
+	}
+
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(content, &hosts); err != nil {
+		return ""
+	}
+
+	return hosts[host].OAuthToken
+}
+
+// ghConfigDir follows the lookup of the gh CLI for its config directory.
+func ghConfigDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "gh")
+}
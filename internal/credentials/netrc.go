@@ -0,0 +1,76 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fromNetrc returns the password of host, or of its api. sub domain, in the
+// .netrc file located at $NETRC or in the home directory.
+func fromNetrc(host string) string {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	passwords := parseNetrc(string(content))
+	if v := passwords[host]; v != "" {
+		return v
+	}
+
+	return passwords["api."+host]
+}
+
+// parseNetrc returns the password of each machine. Comments and macro
+// definitions are skipped and the default entry is ignored as it is not
+// specific to GitHub.
+func parseNetrc(content string) map[string]string {
+	passwords := make(map[string]string)
+	var machine string
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		for j := 0; j < len(fields); j++ {
+			// a comment runs until the end of the line, the values such as
+			// the passwords being read with their keyword.
+			if strings.HasPrefix(fields[j], "#") {
+				break
+			}
+
+			switch fields[j] {
+			case "machine":
+				if j+1 < len(fields) {
+					j++
+					machine = fields[j]
+				}
+			case "default":
+				machine = ""
+			case "password":
+				if j+1 < len(fields) {
+					j++
+					if machine != "" {
+						passwords[machine] = fields[j]
+					}
+				}
+			case "macdef":
+				// the macro body runs until the next empty line.
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			}
+		}
+	}
+
+	return passwords
+}
@@ -0,0 +1,57 @@
+package credentials
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNetrc(t *testing.T) {
+	tests := []struct {
+		name, content string
+		want          map[string]string
+	}{
+		{
+			name:    "one line",
+			content: "machine github.com login bot password ghp_one\n",
+			want:    map[string]string{"github.com": "ghp_one"},
+		},
+		{
+			name:    "several lines",
+			content: "machine github.com\n\tlogin bot\n\tpassword ghp_one\nmachine api.example.com\n\tpassword ghp_two\n",
+			want:    map[string]string{"github.com": "ghp_one", "api.example.com": "ghp_two"},
+		},
+		{
+			name:    "comments",
+			content: "# machine github.com password ghp_commented\nmachine github.com # the bot\n\tpassword ghp_one # machine example.com password ghp_two\n",
+			want:    map[string]string{"github.com": "ghp_one"},
+		},
+		{
+			name:    "password starting with a hash",
+			content: "machine github.com password #ghp_one\n",
+			want:    map[string]string{"github.com": "#ghp_one"},
+		},
+		{
+			name:    "default ignored",
+			content: "machine github.com password ghp_one\ndefault password ghp_default\n",
+			want:    map[string]string{"github.com": "ghp_one"},
+		},
+		{
+			name:    "macro skipped",
+			content: "macdef init\nmachine example.com password ghp_macro\n\nmachine github.com password ghp_one\n",
+			want:    map[string]string{"github.com": "ghp_one"},
+		},
+		{
+			name:    "missing password",
+			content: "machine github.com password",
+			want:    map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseNetrc(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zalando/go-keyring"
+	"gopkg.in/yaml.v3"
+)
+
+// _keyringService is the service the tokens are saved under in the system
+// keyring, keyed by host.
+const _keyringService = "go-toolkit-cmd"
+
+// storePath returns the location of the tokens saved in plain text by "mkpr
+// login" when asked. The file is only readable by its owner.
+func storePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "go-toolkit-cmd", "tokens.yml"), nil
+}
+
+// Store saves the token of host so it is used when no other source is set. It
+// is kept in the system keyring (macOS Keychain, Windows Credential Manager or
+// the Secret Service on Linux) unless plaintext is set, which saves it in a
+// file readable by its owner only, for the systems without a keyring.
+func Store(host, token string, plaintext bool) error {
+	path, err := storePath()
+	if err != nil {
+		return err
+	}
+
+	tokens, err := readStore(path)
+	if err != nil {
+		return err
+	}
+
+	if !plaintext {
+		if err := keyring.Set(_keyringService, host, token); err != nil {
+			return fmt.Errorf("unable to use the system keyring, save the token in plain text instead with -insecure-storage: %w", err)
+		}
+
+		// a token saved in plain text before is not left behind.
+		if _, ok := tokens[host]; !ok {
+			return nil
+		}
+		delete(tokens, host)
+	} else {
+		tokens[host] = token
+	}
+
+	content, err := yaml.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// the file may have been created with wider permissions by someone else.
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return err
+	}
+
+	return os.Chmod(path, 0o600)
+}
+
+// fromStore returns the token of host saved by Store, from the system keyring
+// first, an empty one when none was saved.
+func fromStore(host string) (string, error) {
+	// systems without a keyring, such as CI runners, fall back to the file.
+	if token, err := keyring.Get(_keyringService, host); err == nil {
+		return token, nil
+	}
+
+	path, err := storePath()
+	if err != nil {
+		return "", nil
+	}
+
+	tokens, err := readStore(path)
+	if err != nil {
+		return "", err
+	}
+
+	return tokens[host], nil
+}
+
+// readStore returns the tokens saved in plain text, none when the file does
+// not exist.
+func readStore(path string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the saved tokens: %w", err)
+	}
+
+	if err := yaml.Unmarshal(content, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid saved tokens %s: %w", path, err)
+	}
+
+	return tokens, nil
+}
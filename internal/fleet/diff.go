@@ -0,0 +1,44 @@
+package fleet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Setting is a named setting of a repository, compared by its value in
+// text form to report the drift from the desired state.
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// Changes returns the settings of desired whose value differs from current, as
+// "name: current -> desired".
+func Changes(current, desired []Setting) []string {
+	values := make(map[string]string, len(current))
+	for _, v := range current {
+		values[v.Name] = v.Value
+	}
+
+	var changes []string
+	for _, v := range desired {
+		if values[v.Name] != v.Value {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", v.Name, values[v.Name], v.Value))
+		}
+	}
+
+	return changes
+}
+
+// Summary describes the changes applied to a destination, or that would be on
+// a dry run.
+func Summary(changes []string, dryRun bool) string {
+	switch {
+	case len(changes) == 0:
+		return "up to date"
+	case dryRun:
+		return "would change " + strings.Join(changes, ", ")
+	default:
+		return "changed " + strings.Join(changes, ", ")
+	}
+}
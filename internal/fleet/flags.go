@@ -0,0 +1,171 @@
+package fleet
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/credentials"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+	"golang.org/x/oauth2"
+)
+
+// Flags are the flags shared by the batch commands.
+type Flags struct {
+	Config    string
+	GitHubURL string
+	UploadURL string
+	DryRun    bool
+
+	Credentials *credentials.Source
+	HTTP        *transport.Options
+}
+
+// RegisterFlags registers on fs the flags shared by the batch commands, the
+// config file defaulting to location.
+func RegisterFlags(fs *flag.FlagSet, location string) *Flags {
+	f := Flags{
+		Credentials: RegisterCredentialFlags(fs),
+		HTTP:        RegisterHTTPFlags(fs),
+	}
+	fs.StringVar(&f.Config, "config", location, "Location of config file")
+	fs.StringVar(&f.GitHubURL, "github-url", "", "GitHub Enterprise Server API URL, overrides the one of the config file")
+	fs.StringVar(&f.UploadURL, "upload-url", "", "GitHub Enterprise Server uploads URL, overrides the one of the config file")
+	fs.BoolVar(&f.DryRun, "dry-run", false, "Prints what would be done on every destination, without doing it")
+	return &f
+}
+
+// Apply overrides the settings of the config with the flags that were set.
+func (f *Flags) Apply(c *Config) {
+	if f.GitHubURL != "" {
+		c.GitHubURL = f.GitHubURL
+	}
+	if f.UploadURL != "" {
+		c.UploadURL = f.UploadURL
+	}
+
+	if f.HTTP.Proxy != "" {
+		c.HTTP.Proxy = f.HTTP.Proxy
+	}
+	if f.HTTP.CABundle != "" {
+		c.HTTP.CABundle = f.HTTP.CABundle
+	}
+	if f.HTTP.InsecureSkipVerify {
+		c.HTTP.InsecureSkipVerify = true
+	}
+	if f.HTTP.Timeout != "" {
+		c.HTTP.Timeout = f.HTTP.Timeout
+	}
+	if f.HTTP.CacheDir != "" {
+		c.HTTP.CacheDir = f.HTTP.CacheDir
+	}
+	if f.HTTP.MaxRPS > 0 {
+		c.HTTP.MaxRPS = f.HTTP.MaxRPS
+	}
+	if f.HTTP.UserAgent != "" {
+		c.HTTP.UserAgent = f.HTTP.UserAgent
+	}
+}
+
+// Client returns the GitHub client of the config, authenticated with the
+// token of the flags.
+func (f *Flags) Client(c Config) (*github.Client, error) {
+	f.Credentials.Host = "github.com"
+	if u, err := url.Parse(c.GitHubURL); err == nil && u.Hostname() != "" {
+		f.Credentials.Host = u.Hostname()
+	}
+
+	tc, err := NewHTTPClient(f.Credentials, c.HTTP, false)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := provider.NewGitHubClient(tc, c.GitHubURL, c.UploadURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub URL: %w", err)
+	}
+
+	return client, nil
+}
+
+// RegisterCredentialFlags registers on fs the flags selecting where the token
+// is read from.
+func RegisterCredentialFlags(fs *flag.FlagSet) *credentials.Source {
+	var source credentials.Source
+	fs.StringVar(&source.Token, "token", "", "GitHub token or comma separated tokens, prefer -token-file or -token-command as flags are visible to other users")
+	fs.StringVar(&source.File, "token-file", "", "File holding the GitHub token, or one token per line")
+	fs.StringVar(&source.Command, "token-command", "", "Command printing the GitHub token, for instance, a secret manager helper")
+	return &source
+}
+
+// RegisterHTTPFlags registers on fs the flags overriding the HTTP settings of
+// the config file.
+func RegisterHTTPFlags(fs *flag.FlagSet) *transport.Options {
+	var o transport.Options
+	fs.StringVar(&o.Proxy, "proxy", "", "HTTP(S) proxy URL, HTTPS_PROXY is honored when empty")
+	fs.StringVar(&o.CABundle, "ca-bundle", "", "PEM file with additional root certificates")
+	fs.BoolVar(&o.InsecureSkipVerify, "insecure-skip-verify", false, "Disables the verification of server certificates")
+	fs.StringVar(&o.Timeout, "timeout", "", "Time limit of each request, for instance, 30s")
+	fs.StringVar(&o.CacheDir, "cache-dir", "", "Directory caching the API responses by ETag, so repeated reads spare the rate limit")
+	fs.Float64Var(&o.MaxRPS, "max-rps", 0, "Maximum API requests per second, to stay below the secondary rate limits")
+	fs.StringVar(&o.UserAgent, "user-agent", "", "User-Agent of the API requests, identifying the tool to the API host")
+	return &o
+}
+
+// NewHTTPClient returns an HTTP client authenticated against the provider API,
+// with basic authentication when basic is set.
+func NewHTTPClient(source *credentials.Source, o transport.Options, basic bool) (*http.Client, error) {
+	tokens, err := source.Resolve(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	redact.Secret(tokens...)
+
+	base, err := transport.NewBase(o)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := o.RequestTimeout()
+	if err != nil {
+		return nil, err
+	}
+
+	var rt http.RoundTripper = base
+	if o.MaxRPS > 0 {
+		rt = transport.NewThrottle(rt, o.MaxRPS)
+	}
+
+	if o.UserAgent != "" {
+		rt = transport.NewUserAgent(rt, o.UserAgent)
+	}
+
+	if o.CacheDir != "" {
+		// caching under the authentication keys the entries by token.
+		if rt, err = transport.NewCache(rt, o.CacheDir); err != nil {
+			return nil, err
+		}
+	}
+
+	scheme := "Bearer"
+	if basic {
+		scheme = "Basic"
+		for i := range tokens {
+			tokens[i] = base64.StdEncoding.EncodeToString([]byte(":" + tokens[i]))
+			redact.Secret(tokens[i])
+		}
+	}
+
+	if len(tokens) > 1 {
+		return &http.Client{Transport: transport.NewPool(rt, scheme, tokens), Timeout: timeout}, nil
+	}
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: tokens[0], TokenType: scheme})
+	return &http.Client{Transport: &oauth2.Transport{Source: ts, Base: rt}, Timeout: timeout}, nil
+}
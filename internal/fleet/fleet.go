@@ -0,0 +1,83 @@
+// Package fleet holds what the batch commands other than mkpr share: the
+// destinations of their config files, the flags authenticating against GitHub
+// and the loop applying an action to every destination.
+package fleet
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/transport"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the part of the config files of the batch commands selecting the
+// destinations and how to reach them. Commands embed it inline along with
+// their own settings.
+type Config struct {
+	Owner        string             `yaml:"owner"`        // owner (user or org) of the destinations, "mercadolibre" by default.
+	Destinations []mkpr.Destination `yaml:"destinations"` // repositories to apply the command to, base is optional.
+	Delay        string             `yaml:"delay"`        // delay between destinations (to avoid abuse errors from GH API).
+
+	// GitHub Enterprise Server API endpoints, github.com is used when empty.
+	GitHubURL string `yaml:"github_url"`
+	UploadURL string `yaml:"upload_url"`
+
+	Vars map[string]string `yaml:"vars"` // variables available to templates as .Vars.
+
+	HTTP transport.Options `yaml:"http"` // proxy, TLS and timeout settings.
+}
+
+// ParseFile decodes the config file at path into out, usually a struct
+// embedding Config inline.
+func ParseFile(path string, out interface{}) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(content, out)
+}
+
+// Validate verifies the destinations and the delay.
+func (c Config) Validate() error {
+	if len(c.Destinations) == 0 {
+		return errors.New("destinations are required")
+	}
+
+	for _, d := range c.Destinations {
+		if d.Repository == "" {
+			return errors.New("destinations need a repository")
+		}
+	}
+
+	if c.Delay != "" {
+		if _, err := time.ParseDuration(c.Delay); err != nil {
+			return errors.New("invalid delay: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// OwnerOrDefault returns the owner of the destinations.
+func (c Config) OwnerOrDefault() string {
+	if c.Owner == "" {
+		return "mercadolibre"
+	}
+
+	return c.Owner
+}
+
+// Data returns the template data of the destination, for the commands
+// rendering their content with mkpr.Render.
+func (c Config) Data(d mkpr.Destination) mkpr.TemplateData {
+	return mkpr.TemplateData{
+		Owner:      c.OwnerOrDefault(),
+		Repository: d.Repository,
+		Base:       d.Base,
+		Vars:       c.Vars,
+	}
+}
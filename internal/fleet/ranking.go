@@ -0,0 +1,53 @@
+package fleet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ranking orders the values of a field of the alerts, such as their
+// severities, the first ranking highest.
+type Ranking []string
+
+// Rank returns the position of the value in the ranking, past the end for
+// unknown ones.
+func (r Ranking) Rank(value string) int {
+	for i, v := range r {
+		if v == value {
+			return i
+		}
+	}
+
+	return len(r)
+}
+
+// Check returns an error for the first of the values not ranked, name being
+// the field they are the values of, for instance, "severity".
+func (r Ranking) Check(name string, values []string) error {
+	for _, v := range values {
+		if r.Rank(v) == len(r) {
+			return fmt.Errorf("invalid %s %q, expected %s", name, v, strings.Join(r, ", "))
+		}
+	}
+
+	return nil
+}
+
+// Summary describes the open alerts of a destination, counted by value, in
+// the order of the ranking.
+func (r Ranking) Summary(counts map[string]int) string {
+	var total int
+	var parts []string
+	for _, v := range r {
+		if counts[v] > 0 {
+			total += counts[v]
+			parts = append(parts, fmt.Sprintf("%d %s", counts[v], v))
+		}
+	}
+
+	if total == 0 {
+		return "no open alerts"
+	}
+
+	return fmt.Sprintf("%d open alerts (%s)", total, strings.Join(parts, ", "))
+}
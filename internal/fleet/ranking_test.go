@@ -0,0 +1,28 @@
+package fleet
+
+import "testing"
+
+func TestRanking(t *testing.T) {
+	r := Ranking{"critical", "high", "low"}
+
+	if got := r.Rank("high"); got != 1 {
+		t.Errorf("got rank %d, want 1", got)
+	}
+	if got := r.Rank("unknown"); got != 3 {
+		t.Errorf("got rank %d for an unknown value, want 3", got)
+	}
+
+	if err := r.Check("severity", []string{"low", "critical"}); err != nil {
+		t.Errorf("got error %v, want none", err)
+	}
+	if err := r.Check("severity", []string{"low", "medium"}); err == nil || err.Error() != `invalid severity "medium", expected critical, high, low` {
+		t.Errorf("got error %v, want the invalid severity", err)
+	}
+
+	if got, want := r.Summary(map[string]int{"low": 2, "critical": 1, "other": 4}), "3 open alerts (1 critical, 2 low)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := r.Summary(nil), "no open alerts"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/linkheader"
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+)
+
+// Action is applied to a destination, returning a short summary of what it
+// did, such as the URL of the created issue.
+type Action func(ctx context.Context, d mkpr.Destination) (string, error)
+
+// Run applies action to every destination of the config, waiting its delay
+// between them, and prints the summary of each one. The failed destinations
+// do not stop the run, they are returned at the end as a *mkpr.BatchError.
+func Run(ctx context.Context, c Config, action Action) error {
+	delay, _ := time.ParseDuration(c.Delay)
+	owner := c.OwnerOrDefault()
+
+	var failed []*mkpr.DestinationError
+	for i, d := range c.Destinations {
+		if i > 0 {
+			time.Sleep(delay)
+		}
+
+		summary, err := action(ctx, d)
+		if err != nil {
+			failed = append(failed, &mkpr.DestinationError{Repository: owner + "/" + d.Repository, Err: err})
+			continue
+		}
+
+		if summary != "" {
+			fmt.Printf("%s/%s: %s\n", owner, d.Repository, summary)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &mkpr.BatchError{Errors: failed}
+	}
+
+	return nil
+}
+
+// Report prints err, redacted, the way every batch command does.
+func Report(err error) {
+	fmt.Printf("sorry: %s\n", redact.String(err.Error()))
+	var (
+		rate  *github.RateLimitError
+		abuse *github.AbuseRateLimitError
+	)
+	if errors.As(err, &rate) || errors.As(err, &abuse) {
+		fmt.Println("hint: the API rate limit was exceeded, raise the delay or give more tokens.")
+	}
+}
+
+// IsNotFound reports whether err is a 404 Not Found answer of the GitHub API.
+func IsNotFound(err error) bool {
+	var resp *github.ErrorResponse
+	return errors.As(err, &resp) && resp.Response != nil && resp.Response.StatusCode == http.StatusNotFound
+}
+
+// Send sends a request to the GitHub API, for the endpoints the client has no
+// method for, decoding the response into out unless it is nil.
+func Send(ctx context.Context, client *github.Client, method, path string, body, out interface{}) error {
+	req, err := client.NewRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Do(ctx, req, out)
+	return err
+}
+
+// SendAll gets every page of a list endpoint the client has no method for,
+// following the next links of the responses so the endpoints paginated by
+// cursor are covered as well, and calls each with every item.
+func SendAll(ctx context.Context, client *github.Client, path string, each func(item json.RawMessage) error) error {
+	for path != "" {
+		req, err := client.NewRequest(http.MethodGet, path, nil)
+		if err != nil {
+			return err
+		}
+
+		var page []json.RawMessage
+		resp, err := client.Do(ctx, req, &page)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range page {
+			if err := each(v); err != nil {
+				return err
+			}
+		}
+
+		path = linkheader.Next(resp.Header)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package fleet
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Table is the outcome of an audit of the destinations, one row per finding,
+// for the commands writing it to a file with WriteTable.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Add appends a row with the values of the columns of the header.
+func (t *Table) Add(values ...string) {
+	t.Rows = append(t.Rows, values)
+}
+
+// WriteTable writes the table to path as CSV or, when path ends in .json, as a
+// JSON array of objects keyed by the header. Nothing is written when path is
+// empty.
+func WriteTable(path string, t Table) error {
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		rows := make([]map[string]string, 0, len(t.Rows))
+		for _, v := range t.Rows {
+			row := make(map[string]string, len(t.Header))
+			for i, column := range t.Header {
+				if i < len(v) {
+					row[column] = v[i]
+				}
+			}
+			rows = append(rows, row)
+		}
+
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rows); err != nil {
+			return fmt.Errorf("unable to write %s: %w", path, err)
+		}
+
+		return f.Close()
+	}
+
+	w := csv.NewWriter(f)
+	if err := w.Write(t.Header); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+	if err := w.WriteAll(t.Rows); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,30 @@
+// Package linkheader reads the Link headers the REST APIs paginate their
+// listings with, for the providers and the commands calling the APIs directly.
+package linkheader
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Next returns the URL of the link with the "next" relation of the Link
+// headers, empty on the last page.
+func Next(header http.Header) string {
+	for _, v := range header.Values("Link") {
+		for _, link := range strings.Split(v, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			for _, param := range parts[1:] {
+				if strings.Replace(strings.TrimSpace(param), " ", "", -1) == `rel="next"` {
+					return strings.Trim(target, "<>")
+				}
+			}
+		}
+	}
+
+	return ""
+}
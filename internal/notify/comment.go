@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Commenter comments on issues and pull requests, such as the providers of
+// mkpr able to.
+type Commenter interface {
+	CreateComment(ctx context.Context, owner, repo string, number int, body string) error
+}
+
+// Comment posts the summary as a comment on an issue or pull request, such as
+// the tracking issue of a migration, so its thread follows every run.
+type Comment struct {
+	Commenter  Commenter
+	Owner      string // of the repository, the owner of the run when empty.
+	Repository string
+	Number     int
+}
+
+func (n Comment) Notify(ctx context.Context, s Summary) error {
+	owner := n.Owner
+	if owner == "" {
+		owner = s.Owner
+	}
+
+	return n.Commenter.CreateComment(ctx, owner, n.Repository, n.Number, markdown(s))
+}
+
+// markdown returns the summary as Markdown: the title and the facts of the
+// run, then a table of the outcome of every destination.
+func markdown(s Summary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", escapeMarkdown(s.Title()))
+	if s.ID != "" {
+		fmt.Fprintf(&b, "Run `%s`, ", s.ID)
+	}
+	fmt.Fprintf(&b, "started %s, took %s.\n", s.StartedAt.Format("2006-01-02 15:04:05 MST"), s.Duration)
+	if s.Err != "" {
+		fmt.Fprintf(&b, "\n**%s**\n", escapeMarkdown(s.Err))
+	}
+
+	if len(s.Results) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n| Repository | Outcome | Pull request |\n| --- | --- | --- |\n")
+	for _, v := range s.Results {
+		outcome := v.Outcome()
+		if v.Error != "" {
+			outcome += ": " + v.Error
+		}
+		fmt.Fprintf(&b, "| %s/%s | %s | %s |\n", s.Owner, v.Repository, escapeMarkdown(outcome), v.URL)
+	}
+
+	return b.String()
+}
+
+// escapeMarkdown keeps text from breaking the table or the formatting of a
+// comment.
+func escapeMarkdown(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "\r", "", "*", `\*`, "_", `\_`, "<", "&lt;", ">", "&gt;").Replace(text)
+}
@@ -0,0 +1,249 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Email sends the summary by email through an SMTP server, the body in plain
+// text with the summary attached as HTML and the results of the destinations
+// as CSV, for the change management processes driven by email.
+type Email struct {
+	Addr     string // host:port of the SMTP server, upgraded to TLS when it supports STARTTLS.
+	Username string // authenticates with PLAIN when set, over TLS only.
+	Password string
+	From     string
+	To       []string
+
+	// Subject is executed as text/template with the Summary, its Title when
+	// empty.
+	Subject string
+}
+
+func (n Email) Notify(ctx context.Context, s Summary) error {
+	subject := s.Title()
+	if n.Subject != "" {
+		t, err := texttemplate.New("subject").Parse(n.Subject)
+		if err != nil {
+			return fmt.Errorf("invalid subject: %w", err)
+		}
+
+		var b strings.Builder
+		if err := t.Execute(&b, s); err != nil {
+			return fmt.Errorf("unable to render the subject: %w", err)
+		}
+		subject = strings.TrimSpace(b.String())
+	}
+
+	msg, err := n.message(s, subject, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return n.send(ctx, msg)
+}
+
+// message returns the email of the summary, headers included.
+func (n Email) message(s Summary, subject string, date time.Time) ([]byte, error) {
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "From: %s\r\n", n.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	w, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qp, s.Text()+"\n"); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	name := "mkpr-summary"
+	if s.ID != "" {
+		name = "mkpr-" + s.ID
+	}
+
+	var page bytes.Buffer
+	if err := _summaryPage.Execute(&page, s); err != nil {
+		return nil, err
+	}
+	if err := attach(mw, name+".html", "text/html; charset=utf-8", page.Bytes()); err != nil {
+		return nil, err
+	}
+
+	var table bytes.Buffer
+	cw := csv.NewWriter(&table)
+	cw.Write([]string{"repository", "head", "outcome", "url", "error"})
+	for _, v := range s.Results {
+		cw.Write([]string{s.Owner + "/" + v.Repository, v.Head, v.Outcome(), v.URL, v.Error})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, err
+	}
+	if err := attach(mw, name+".csv", "text/csv; charset=utf-8", table.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// attach adds the content as a base64 encoded attachment.
+func attach(mw *multipart.Writer, name, contentType string, content []byte) error {
+	w, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+	})
+	if err != nil {
+		return err
+	}
+
+	// lines of 76 characters at most, as required by MIME.
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = io.WriteString(w, encoded+"\r\n")
+	return err
+}
+
+// send delivers the message to the recipients, giving up when ctx is done.
+func (n Email) send(ctx context.Context, msg []byte) error {
+	host, _, err := net.SplitHostPort(n.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %w", n.Addr, err)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("unable to start TLS: %w", err)
+		}
+	}
+
+	if n.Username != "" {
+		// PlainAuth refuses to send the password without TLS, but to localhost.
+		if err := c.Auth(smtp.PlainAuth("", n.Username, n.Password, host)); err != nil {
+			return fmt.Errorf("unable to authenticate: %w", err)
+		}
+	}
+
+	if err := c.Mail(n.From); err != nil {
+		return err
+	}
+	for _, v := range n.To {
+		if err := c.Rcpt(v); err != nil {
+			return fmt.Errorf("recipient %s: %w", v, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// Validate verifies the settings of the notifier, without contacting the
+// server.
+func (n Email) Validate() error {
+	switch {
+	case n.Addr == "":
+		return errors.New("smtp is required")
+	case n.From == "":
+		return errors.New("from is required")
+	case len(n.To) == 0:
+		return errors.New("to is required")
+	}
+
+	if _, _, err := net.SplitHostPort(n.Addr); err != nil {
+		return fmt.Errorf("invalid smtp %q, expected host:port", n.Addr)
+	}
+
+	if _, err := texttemplate.New("subject").Parse(n.Subject); err != nil {
+		return fmt.Errorf("invalid subject: %w", err)
+	}
+
+	return nil
+}
+
+var _summaryPage = template.Must(template.New("summary").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>
+{{- if .ID}}Run {{.ID}}, {{end}}started {{.StartedAt.Format "2006-01-02 15:04:05 MST"}}, took {{.Duration}}.
+{{- if .Subject}}<br>Subject: {{.Subject}}{{end}}
+</p>
+{{- if .Err}}
+<p><strong>{{.Err}}</strong></p>
+{{- end}}
+{{- if .Results}}
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Repository</th><th>Head</th><th>Outcome</th><th>Pull request</th><th>Error</th></tr>
+{{- range .Results}}
+<tr><td>{{$.Owner}}/{{.Repository}}</td><td>{{.Head}}</td><td>{{.Outcome}}</td><td>{{if .URL}}<a href="{{.URL}}">{{.URL}}</a>{{end}}</td><td>{{.Error}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
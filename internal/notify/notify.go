@@ -0,0 +1,146 @@
+// Package notify sends the summary of the batch runs to chat services or by
+// email, for the runs nobody watches, such as the scheduled ones.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Summary is the outcome of a run.
+type Summary struct {
+	ID        string // of the run.
+	Job       string // name of the scheduled job, empty for manual runs.
+	Owner     string
+	Subject   string // of the pull requests.
+	StartedAt time.Time
+	Duration  time.Duration
+
+	Created   []string // URLs of the pull requests.
+	Unchanged int      // destinations skipped by an incremental run.
+	Failed    []Failure
+
+	// Err stopped the run before the destinations were processed, such as a
+	// failed preflight verification.
+	Err string
+
+	// Results are the outcome of every destination, in order, for the
+	// notifiers giving the details.
+	Results []Result
+}
+
+// Result is the outcome of a destination.
+type Result struct {
+	Repository string
+	Head       string
+	URL        string // of the pull request.
+	Unchanged  bool
+	Error      string
+}
+
+// Outcome describes the result in a word: created, unchanged or failed.
+func (r Result) Outcome() string {
+	switch {
+	case r.Error != "":
+		return "failed"
+	case r.Unchanged:
+		return "unchanged"
+	default:
+		return "created"
+	}
+}
+
+// Failure is a destination that failed.
+type Failure struct {
+	Repository string
+	Error      string
+}
+
+// OK reports whether the run had no failure.
+func (s Summary) OK() bool {
+	return s.Err == "" && len(s.Failed) == 0
+}
+
+// Title returns a one line description of the run.
+func (s Summary) Title() string {
+	name := s.Subject
+	if s.Job != "" {
+		name = s.Job
+	}
+
+	if s.Err != "" {
+		return fmt.Sprintf("mkpr %s failed", name)
+	}
+
+	return fmt.Sprintf("mkpr %s: %d created, %d unchanged, %d failed", name, len(s.Created), s.Unchanged, len(s.Failed))
+}
+
+// Text returns the summary as plain text, the title followed by the pull
+// requests and the failures.
+func (s Summary) Text() string {
+	var b strings.Builder
+	b.WriteString(s.Title())
+	if s.Err != "" {
+		b.WriteString("\n" + s.Err)
+	}
+	for _, v := range s.Created {
+		b.WriteString("\n- " + v)
+	}
+	for _, v := range s.Failed {
+		fmt.Fprintf(&b, "\n- %s/%s: %s", s.Owner, v.Repository, v.Error)
+	}
+
+	return b.String()
+}
+
+// Notifier sends the summary of a run somewhere.
+type Notifier interface {
+	Notify(ctx context.Context, s Summary) error
+}
+
+// Slack posts the summary to a Slack incoming webhook.
+type Slack struct {
+	WebhookURL string
+	Client     *http.Client // http.DefaultClient when nil.
+}
+
+func (n Slack) Notify(ctx context.Context, s Summary) error {
+	return post(ctx, n.Client, n.WebhookURL, map[string]string{"text": s.Text()})
+}
+
+// post sends body as JSON to url, failing on any status other than 2xx.
+func post(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
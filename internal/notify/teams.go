@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// _cardItems is the number of pull requests, and of failures, listed in a
+// card at most, cards being limited in size.
+const _cardItems = 50
+
+// Teams posts the summary to a Microsoft Teams incoming webhook, or workflow,
+// as an adaptive card.
+type Teams struct {
+	WebhookURL string
+	Client     *http.Client // http.DefaultClient when nil.
+}
+
+func (n Teams) Notify(ctx context.Context, s Summary) error {
+	return post(ctx, n.Client, n.WebhookURL, map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card(s),
+		}},
+	})
+}
+
+// card returns the adaptive card of the summary: its title, the facts of the
+// run, then the pull requests and the failures.
+func card(s Summary) map[string]interface{} {
+	color := "good"
+	if !s.OK() {
+		color = "attention"
+	}
+
+	facts := []map[string]string{
+		{"title": "Owner", "value": s.Owner},
+		{"title": "Started", "value": s.StartedAt.Format("2006-01-02 15:04:05 MST")},
+		{"title": "Duration", "value": s.Duration.String()},
+		{"title": "Created", "value": fmt.Sprint(len(s.Created))},
+		{"title": "Unchanged", "value": fmt.Sprint(s.Unchanged)},
+		{"title": "Failed", "value": fmt.Sprint(len(s.Failed))},
+	}
+	if s.Job != "" {
+		facts = append([]map[string]string{{"title": "Job", "value": s.Job}}, facts...)
+	}
+	if s.ID != "" {
+		facts = append([]map[string]string{{"title": "Run", "value": s.ID}}, facts...)
+	}
+
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": s.Title(), "weight": "bolder", "size": "medium", "color": color, "wrap": true},
+		{"type": "FactSet", "facts": facts},
+	}
+	if s.Err != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": s.Err, "color": "attention", "wrap": true})
+	}
+
+	created := make([]string, 0, len(s.Created))
+	for _, v := range s.Created {
+		created = append(created, fmt.Sprintf("[%s](%s)", v, v))
+	}
+	body = appendList(body, "Pull requests", created)
+
+	failed := make([]string, 0, len(s.Failed))
+	for _, v := range s.Failed {
+		failed = append(failed, fmt.Sprintf("%s/%s: %s", s.Owner, v.Repository, v.Error))
+	}
+	body = appendList(body, "Failures", failed)
+
+	return map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"msteams": map[string]string{"width": "Full"},
+		"body":    body,
+	}
+}
+
+// appendList adds the items under a heading to the body of a card, at most
+// _cardItems of them, nothing when there are none.
+func appendList(body []map[string]interface{}, heading string, items []string) []map[string]interface{} {
+	if len(items) == 0 {
+		return body
+	}
+
+	body = append(body, map[string]interface{}{"type": "TextBlock", "text": heading, "weight": "bolder", "separator": true})
+	for i, v := range items {
+		if i == _cardItems {
+			body = append(body, map[string]interface{}{"type": "TextBlock", "text": fmt.Sprintf("and %d more", len(items)-i), "isSubtle": true})
+			break
+		}
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": "- " + v, "wrap": true, "spacing": "none"})
+	}
+
+	return body
+}
@@ -0,0 +1,231 @@
+// Package protection reads and sets the branch protection of GitHub
+// repositories, for the commands enforcing or copying it.
+package protection
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/sorfino/go-toolkit-cmd/internal/fleet"
+)
+
+// Protection is the protection of a branch. The zero value is an unprotected
+// branch.
+type Protection struct {
+	RequiredChecks []string `yaml:"required_checks"` // status checks that must pass before merging.
+	StrictChecks   bool     `yaml:"strict_checks"`   // requires the branches to be up to date before merging.
+
+	RequiredReviews         int  `yaml:"required_reviews"` // approving reviews required.
+	DismissStaleReviews     bool `yaml:"dismiss_stale_reviews"`
+	RequireCodeOwnerReviews bool `yaml:"require_code_owner_reviews"`
+
+	SignedCommits bool `yaml:"signed_commits"`
+	LinearHistory bool `yaml:"linear_history"`
+	EnforceAdmins bool `yaml:"enforce_admins"` // applies the protection to administrators as well.
+
+	// push restrictions of the current protection, kept as they are.
+	restrictions *restrictions
+
+	protected bool // whether the protection exists.
+}
+
+// Protected reports whether the protection was read from a protected branch.
+func (p Protection) Protected() bool {
+	return p.protected
+}
+
+// Settings returns the settings compared to report the drift.
+func (p Protection) Settings() []fleet.Setting {
+	checks := append([]string(nil), p.RequiredChecks...)
+	sort.Strings(checks)
+
+	return []fleet.Setting{
+		{Name: "required_checks", Value: "[" + strings.Join(checks, ",") + "]"},
+		{Name: "strict_checks", Value: strconv.FormatBool(p.StrictChecks)},
+		{Name: "required_reviews", Value: strconv.Itoa(p.RequiredReviews)},
+		{Name: "dismiss_stale_reviews", Value: strconv.FormatBool(p.DismissStaleReviews)},
+		{Name: "require_code_owner_reviews", Value: strconv.FormatBool(p.RequireCodeOwnerReviews)},
+		{Name: "signed_commits", Value: strconv.FormatBool(p.SignedCommits)},
+		{Name: "linear_history", Value: strconv.FormatBool(p.LinearHistory)},
+		{Name: "enforce_admins", Value: strconv.FormatBool(p.EnforceAdmins)},
+	}
+}
+
+type enabled struct {
+	Enabled bool `json:"enabled"`
+}
+
+type statusChecks struct {
+	Strict   bool     `json:"strict"`
+	Contexts []string `json:"contexts"`
+}
+
+type reviews struct {
+	DismissStaleReviews          bool `json:"dismiss_stale_reviews"`
+	RequireCodeOwnerReviews      bool `json:"require_code_owner_reviews"`
+	RequiredApprovingReviewCount int  `json:"required_approving_review_count"`
+}
+
+type restrictions struct {
+	Users []string `json:"users"`
+	Teams []string `json:"teams"`
+	Apps  []string `json:"apps"`
+}
+
+// protectionResponse is the protection as returned by the API.
+type protectionResponse struct {
+	RequiredStatusChecks       *statusChecks `json:"required_status_checks"`
+	RequiredPullRequestReviews *reviews      `json:"required_pull_request_reviews"`
+	EnforceAdmins              enabled       `json:"enforce_admins"`
+	RequiredSignatures         enabled       `json:"required_signatures"`
+	RequiredLinearHistory      enabled       `json:"required_linear_history"`
+	Restrictions               *struct {
+		Users []struct {
+			Login string `json:"login"`
+		} `json:"users"`
+		Teams []struct {
+			Slug string `json:"slug"`
+		} `json:"teams"`
+		Apps []struct {
+			Slug string `json:"slug"`
+		} `json:"apps"`
+	} `json:"restrictions"`
+}
+
+// protectionRequest is the protection as set through the API.
+type protectionRequest struct {
+	RequiredStatusChecks       *statusChecks `json:"required_status_checks"`
+	RequiredPullRequestReviews *reviews      `json:"required_pull_request_reviews"`
+	EnforceAdmins              bool          `json:"enforce_admins"`
+	RequiredLinearHistory      bool          `json:"required_linear_history"`
+	Restrictions               *restrictions `json:"restrictions"`
+}
+
+func protectionPath(owner, repo, branch string) string {
+	return fmt.Sprintf("repos/%s/%s/branches/%s/protection", owner, repo, url.PathEscape(branch))
+}
+
+// Get returns the current protection of the branch.
+func Get(ctx context.Context, client *github.Client, owner, repo, branch string) (Protection, error) {
+	req, err := client.NewRequest(http.MethodGet, protectionPath(owner, repo, branch), nil)
+	if err != nil {
+		return Protection{}, err
+	}
+
+	var out protectionResponse
+	if _, err := client.Do(ctx, req, &out); err != nil {
+		// unprotected branches are not found.
+		if fleet.IsNotFound(err) {
+			return Protection{}, nil
+		}
+		return Protection{}, fmt.Errorf("unable to get branch protection: %w", err)
+	}
+
+	p := Protection{
+		protected:     true,
+		EnforceAdmins: out.EnforceAdmins.Enabled,
+		SignedCommits: out.RequiredSignatures.Enabled,
+		LinearHistory: out.RequiredLinearHistory.Enabled,
+	}
+	if v := out.RequiredStatusChecks; v != nil {
+		p.RequiredChecks, p.StrictChecks = v.Contexts, v.Strict
+	}
+	if v := out.RequiredPullRequestReviews; v != nil {
+		p.RequiredReviews = v.RequiredApprovingReviewCount
+		p.DismissStaleReviews = v.DismissStaleReviews
+		p.RequireCodeOwnerReviews = v.RequireCodeOwnerReviews
+	}
+	if v := out.Restrictions; v != nil {
+		p.restrictions = &restrictions{Users: []string{}, Teams: []string{}, Apps: []string{}}
+		for _, u := range v.Users {
+			p.restrictions.Users = append(p.restrictions.Users, u.Login)
+		}
+		for _, t := range v.Teams {
+			p.restrictions.Teams = append(p.restrictions.Teams, t.Slug)
+		}
+		for _, a := range v.Apps {
+			p.restrictions.Apps = append(p.restrictions.Apps, a.Slug)
+		}
+	}
+
+	return p, nil
+}
+
+// Set replaces the protection of the branch with desired, keeping the push
+// restrictions of current. Signed commits have an endpoint of their own, only
+// called when they change.
+func Set(ctx context.Context, client *github.Client, owner, repo, branch string, desired, current Protection) error {
+	body := protectionRequest{
+		EnforceAdmins:         desired.EnforceAdmins,
+		RequiredLinearHistory: desired.LinearHistory,
+		Restrictions:          current.restrictions,
+	}
+	if len(desired.RequiredChecks) > 0 || desired.StrictChecks {
+		body.RequiredStatusChecks = &statusChecks{Strict: desired.StrictChecks, Contexts: append([]string{}, desired.RequiredChecks...)}
+	}
+	if desired.RequiredReviews > 0 || desired.DismissStaleReviews || desired.RequireCodeOwnerReviews {
+		body.RequiredPullRequestReviews = &reviews{
+			DismissStaleReviews:          desired.DismissStaleReviews,
+			RequireCodeOwnerReviews:      desired.RequireCodeOwnerReviews,
+			RequiredApprovingReviewCount: desired.RequiredReviews,
+		}
+	}
+
+	path := protectionPath(owner, repo, branch)
+	req, err := client.NewRequest(http.MethodPut, path, body)
+	if err != nil {
+		return err
+	}
+	if _, err := client.Do(ctx, req, nil); err != nil {
+		return fmt.Errorf("unable to set branch protection: %w", err)
+	}
+
+	if desired.SignedCommits == current.SignedCommits {
+		return nil
+	}
+
+	method := http.MethodPost
+	if !desired.SignedCommits {
+		method = http.MethodDelete
+	}
+	req, err = client.NewRequest(method, path+"/required_signatures", nil)
+	if err != nil {
+		return err
+	}
+	if _, err := client.Do(ctx, req, nil); err != nil {
+		return fmt.Errorf("unable to set signed commits: %w", err)
+	}
+
+	return nil
+}
+
+// Copy protects the branch to with the protection of from, push restrictions
+// included, reporting whether from was protected at all.
+func Copy(ctx context.Context, client *github.Client, owner, repo, from, to string) (bool, error) {
+	source, err := Get(ctx, client, owner, repo, from)
+	if err != nil || !source.protected {
+		return false, err
+	}
+
+	return true, Set(ctx, client, owner, repo, to, source, Protection{restrictions: source.restrictions})
+}
+
+// Remove removes the protection of the branch, if any.
+func Remove(ctx context.Context, client *github.Client, owner, repo, branch string) error {
+	req, err := client.NewRequest(http.MethodDelete, protectionPath(owner, repo, branch), nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.Do(ctx, req, nil); err != nil && !fleet.IsNotFound(err) {
+		return fmt.Errorf("unable to remove branch protection: %w", err)
+	}
+
+	return nil
+}
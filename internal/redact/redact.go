@@ -0,0 +1,75 @@
+// Package redact scrubs credentials from the text the commands print, log or
+// record, as batches often run in shared CI logs.
+package redact
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Mask replaces the redacted credentials.
+const Mask = "[REDACTED]"
+
+var (
+	_mu      sync.RWMutex
+	_secrets []string
+
+	// _patterns match the credentials whose value is not known, such as the
+	// tokens of the hosting services and the ones embedded in URLs.
+	_patterns = []*regexp.Regexp{
+		regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}`),
+		regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{20,}`),
+		regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`),
+		regexp.MustCompile(`(?i)\b(?:bearer|basic|token)\s+[A-Za-z0-9._~+/=-]{16,}`),
+	}
+	_userinfo = regexp.MustCompile(`(://)[^/@\s:]+(?::[^/@\s]*)?@`)
+	_query    = regexp.MustCompile(`(?i)([?&](?:access_token|private_token|token|password)=)[^&\s"]+`)
+)
+
+// Secret registers values, such as the resolved tokens, to be redacted
+// wherever they appear.
+func Secret(values ...string) {
+	_mu.Lock()
+	defer _mu.Unlock()
+	for _, v := range values {
+		// short values would redact unrelated text.
+		if len(v) >= 8 {
+			_secrets = append(_secrets, v)
+		}
+	}
+}
+
+// String returns s with the credentials replaced by Mask.
+func String(s string) string {
+	_mu.RLock()
+	for _, v := range _secrets {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+	_mu.RUnlock()
+
+	for _, v := range _patterns {
+		s = v.ReplaceAllString(s, Mask)
+	}
+	s = _userinfo.ReplaceAllString(s, "${1}"+Mask+"@")
+	return _query.ReplaceAllString(s, "${1}"+Mask)
+}
+
+// Writer returns a writer redacting what is written to w. Each write is
+// redacted on its own, which suits the line by line writes of loggers.
+func Writer(w io.Writer) io.Writer {
+	return writer{w: w}
+}
+
+type writer struct {
+	w io.Writer
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,206 @@
+// Package schedule parses cron expressions, for the commands re-running jobs
+// on a schedule.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression: minute, hour, day of month, month and
+// day of week.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // bit sets of the matching values.
+
+	// a day matches either field when both are restricted, like cron does.
+	anyDOM, anyDOW bool
+}
+
+// _macros are the expressions the macros stand for.
+var _macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the range of the values of a field of the expression, and the
+// names accepted for them.
+type field struct {
+	name     string
+	min, max int
+	names    []string // names of the values from min, if any.
+}
+
+var (
+	_minute = field{name: "minute", min: 0, max: 59}
+	_hour   = field{name: "hour", min: 0, max: 23}
+	_dom    = field{name: "day of month", min: 1, max: 31}
+	_month  = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	_dow    = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// Parse parses a cron expression of five fields, such as "0 9 * * mon-fri",
+// or one of the macros @yearly, @monthly, @weekly, @daily and @hourly. Fields
+// are lists of values, ranges (1-5) and steps (*/15 or 1-30/2); months and days
+// of week take their three-letter English names too, 0 and 7 both being
+// Sunday.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if v, ok := _macros[strings.ToLower(expr)]; ok {
+		expr = v
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("invalid schedule %q, expected 5 fields", expr)
+	}
+
+	var s Schedule
+	var err error
+	if s.minute, err = _minute.parse(fields[0]); err != nil {
+		return Schedule{}, err
+	}
+	if s.hour, err = _hour.parse(fields[1]); err != nil {
+		return Schedule{}, err
+	}
+	if s.dom, err = _dom.parse(fields[2]); err != nil {
+		return Schedule{}, err
+	}
+	if s.month, err = _month.parse(fields[3]); err != nil {
+		return Schedule{}, err
+	}
+	if s.dow, err = _dow.parse(fields[4]); err != nil {
+		return Schedule{}, err
+	}
+
+	// Sunday is both 0 and 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	// like cron, a field starting with a star, such as */2, is unrestricted.
+	s.anyDOM, s.anyDOW = strings.HasPrefix(fields[2], "*"), strings.HasPrefix(fields[4], "*")
+
+	return s, nil
+}
+
+// parse returns the bit set of the values of the field.
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		span, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			span = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q of the %s", part[i+1:], f.name)
+			}
+		}
+
+		first, last := f.min, f.max
+		switch i := strings.Index(span, "-"); {
+		case span == "*":
+		case i >= 0:
+			var err error
+			if first, err = f.value(span[:i]); err != nil {
+				return 0, err
+			}
+			if last, err = f.value(span[i+1:]); err != nil {
+				return 0, err
+			}
+			if first > last {
+				return 0, fmt.Errorf("invalid range %q of the %s", span, f.name)
+			}
+		default:
+			var err error
+			if first, err = f.value(span); err != nil {
+				return 0, err
+			}
+			// a single value with a step runs up to the end of the range.
+			if step == 1 {
+				last = first
+			}
+		}
+
+		for v := first; v <= last; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a value of the field, by number or name.
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d to %d", f.name, s, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// errNever is returned by Next for the schedules that never match, such as
+// the 30th of February.
+var errNever = errors.New("the schedule never matches")
+
+// Next returns the first time after t matching the schedule, in the location
+// of t.
+func (s Schedule) Next(t time.Time) (time.Time, error) {
+	loc := t.Location()
+	t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc))
+
+	// every combination repeats within a few years.
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = forward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+		case !s.day(t):
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc))
+		default:
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errNever
+}
+
+// forward returns next, the candidate following t. A candidate skipped by a
+// daylight saving change is normalized backward by time.Date, so it is moved
+// past the gap instead.
+func forward(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+
+	_, before := next.Zone()
+	_, after := next.Add(3 * time.Hour).Zone()
+	return next.Add(time.Duration(after-before) * time.Second)
+}
+
+// day reports whether the day of t matches the schedule.
+func (s Schedule) day(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDOM || s.anyDOW {
+		return dom && dow
+	}
+
+	return dom || dow
+}
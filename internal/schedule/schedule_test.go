@@ -0,0 +1,122 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	utc := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name, expr, from, want string
+	}{
+		{name: "every 15 minutes", expr: "*/15 * * * *", from: "2024-01-01 00:00", want: "2024-01-01 00:15"},
+		{name: "strictly after", expr: "0 0 * * *", from: "2024-01-01 00:00", want: "2024-01-02 00:00"},
+		{name: "range with step", expr: "1-30/10 * * * *", from: "2024-01-01 00:05", want: "2024-01-01 00:11"},
+		{name: "value with step", expr: "5/20 * * * *", from: "2024-01-01 00:30", want: "2024-01-01 00:45"},
+		{name: "macro", expr: "@hourly", from: "2024-01-01 00:30", want: "2024-01-01 01:00"},
+		{name: "month names", expr: "0 12 1 jan,jul *", from: "2024-02-01 00:00", want: "2024-07-01 12:00"},
+		{name: "weekdays", expr: "0 9 * * mon-fri", from: "2024-01-06 10:00", want: "2024-01-08 09:00"},
+		{name: "sunday as 0", expr: "0 0 * * 0", from: "2024-01-01 00:00", want: "2024-01-07 00:00"},
+		{name: "sunday as 7", expr: "0 0 * * 7", from: "2024-01-01 00:00", want: "2024-01-07 00:00"},
+		{name: "day of month only", expr: "0 0 13 * *", from: "2024-01-01 00:00", want: "2024-01-13 00:00"},
+		{name: "both days restricted match either", expr: "0 0 13 * fri", from: "2024-01-01 00:00", want: "2024-01-05 00:00"},
+		{name: "starred day of month with step matches both", expr: "0 0 */2 * mon", from: "2024-01-01 00:00", want: "2024-01-15 00:00"},
+		{name: "starred day of week with step matches both", expr: "0 0 13 * */7", from: "2024-01-01 00:00", want: "2024-10-13 00:00"},
+		{name: "leap day", expr: "0 0 29 2 *", from: "2024-03-01 00:00", want: "2028-02-29 00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.Next(utc(tt.from))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := utc(tt.want); !got.Equal(want) {
+				t.Errorf("got %s, want %s", got.Format(time.RFC3339), want.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, errNever) {
+		t.Errorf("got error %v, want %v", err, errNever)
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+
+	tests := []struct {
+		name, expr string
+		from, want time.Time
+	}{
+		// 02:00 to 03:00 does not exist on 2024-03-10.
+		{name: "hourly across the gap", expr: "0 * * * *", from: time.Date(2024, 3, 10, 1, 30, 0, 0, loc), want: time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)},
+		{name: "time in the gap is skipped", expr: "30 2 * * *", from: time.Date(2024, 3, 9, 3, 0, 0, 0, loc), want: time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC)},
+		{name: "daily after the change", expr: "0 9 * * *", from: time.Date(2024, 3, 9, 10, 0, 0, 0, loc), want: time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := s.Next(tt.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %s, want %s", got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+			}
+			if got.Location() != loc {
+				t.Errorf("got location %s, want %s", got.Location(), loc)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@sometimes",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("%q: got no error", expr)
+		}
+	}
+}
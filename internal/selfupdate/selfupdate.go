@@ -0,0 +1,208 @@
+// Package selfupdate finds the releases of the command, verifies their
+// artifacts and replaces the running binary with them.
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"golang.org/x/mod/semver"
+)
+
+// Checksums is the asset of every release listing the SHA-256 of the other
+// ones, in the format of sha256sum. Its signature, when the releases are
+// signed, is the Checksums+".sig" asset.
+const Checksums = "checksums.txt"
+
+// ErrNoAsset is returned when a release has no artifact for the platform.
+var ErrNoAsset = errors.New("no artifact for this platform")
+
+// Release returns the release of the repository with the given tag, the latest
+// one when tag is empty.
+func Release(ctx context.Context, client *github.Client, owner, repo, tag string) (*github.RepositoryRelease, error) {
+	var (
+		release *github.RepositoryRelease
+		err     error
+	)
+	if tag == "" {
+		release, _, err = client.Repositories.GetLatestRelease(ctx, owner, repo)
+	} else {
+		release, _, err = client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the release: %w", err)
+	}
+
+	return release, nil
+}
+
+// Newer reports whether tag is a later semantic version than current, both
+// with or without the "v" prefix.
+func Newer(current, tag string) bool {
+	return semver.Compare(canonical(tag), canonical(current)) > 0
+}
+
+func canonical(version string) string {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+
+	return version
+}
+
+// AssetName returns the name of the artifact of the command for the running
+// platform, such as "mkpr_linux_amd64" or "mkpr_windows_amd64.exe".
+func AssetName(command string) string {
+	name := command + "_" + runtime.GOOS + "_" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	return name
+}
+
+// Download returns the content of the named asset of the release. Assets are
+// served by a redirect to a storage service, which is followed by direct,
+// without the credentials of client.
+func Download(ctx context.Context, client *github.Client, direct *http.Client, owner, repo string, release *github.RepositoryRelease, name string) ([]byte, error) {
+	var asset *github.ReleaseAsset
+	for i := range release.Assets {
+		if release.Assets[i].GetName() == name {
+			asset = &release.Assets[i]
+		}
+	}
+	if asset == nil {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+
+	rc, redirect, err := client.Repositories.DownloadReleaseAsset(ctx, owner, repo, asset.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("unable to download %s: %w", name, err)
+	}
+
+	if redirect != "" {
+		req, err := http.NewRequest(http.MethodGet, redirect, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := direct.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("unable to download %s: %w", name, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unable to download %s: %s", name, resp.Status)
+		}
+		rc = resp.Body
+	}
+	defer rc.Close()
+
+	content, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download %s: %w", name, err)
+	}
+
+	return content, nil
+}
+
+// Verify verifies that signature is the Ed25519 signature of checksums by key,
+// both encoded in base64, and that the artifact is listed with its checksum in
+// checksums.
+func Verify(name string, artifact, checksums, signature []byte, key string) error {
+	if key == "" {
+		return errors.New("no public key to verify the signature with")
+	}
+
+	public, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(public) != ed25519.PublicKeySize {
+		return errors.New("invalid public key, a base64 encoded Ed25519 key expected")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil || !ed25519.Verify(public, checksums, sig) {
+		return fmt.Errorf("invalid signature of %s", Checksums)
+	}
+
+	return VerifyChecksum(name, artifact, checksums)
+}
+
+// VerifyChecksum verifies the artifact is listed with its checksum in
+// checksums, which proves its integrity but not who published it.
+func VerifyChecksum(name string, artifact, checksums []byte) error {
+	sum := sha256.Sum256(artifact)
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+
+		if fields[0] != hex.EncodeToString(sum[:]) {
+			return fmt.Errorf("checksum mismatch of %s", name)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%s is not listed in %s", name, Checksums)
+}
+
+// Replace replaces the binary at path with content, keeping its permissions.
+// The new binary is written next to it and renamed over it, so an interrupted
+// update leaves the old one in place.
+func Replace(path string, content []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".new")
+	if err != nil {
+		return fmt.Errorf("unable to write the new binary: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = io.Copy(f, bytes.NewReader(content))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), info.Mode().Perm())
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write the new binary: %w", err)
+	}
+
+	// a running binary cannot be overwritten on Windows, but it can be renamed.
+	old := path + ".old"
+	if runtime.GOOS == "windows" {
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("unable to replace the binary: %w", err)
+		}
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		if runtime.GOOS == "windows" {
+			os.Rename(old, path)
+		}
+		return fmt.Errorf("unable to replace the binary: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned by Lock when another run holds the lock.
+var ErrLocked = errors.New("state file locked")
+
+// Lock takes the lock of the state file at path, so the runs sharing it, such
+// as the jobs of several daemons, do not overwrite the records of each other.
+// The lock is a file next to the state one, removed by unlock; one left behind
+// by a killed process must be removed by hand.
+func Lock(path string) (unlock func() error, err error) {
+	lock := path + ".lock"
+	f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		owner, _ := os.ReadFile(lock)
+		return nil, fmt.Errorf("%w by %s, remove %s if no run is in progress", ErrLocked, strings.TrimSpace(string(owner)), lock)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock state: %w", err)
+	}
+
+	_, err = fmt.Fprintf(f, "pid %d since %s\n", os.Getpid(), time.Now().UTC().Format(time.RFC3339))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(lock)
+		return nil, fmt.Errorf("unable to lock state: %w", err)
+	}
+
+	return func() error { return os.Remove(lock) }, nil
+}
@@ -0,0 +1,143 @@
+// Package state keeps the local record of the batches run by mkpr in a JSON
+// file, for instance, the fingerprints of the changes pushed to each
+// destination and the outcome of every run.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultPath is the state file used when none is given.
+const DefaultPath = ".mkpr-state.json"
+
+// DB is a state file loaded in memory, written back on every change.
+type DB struct {
+	path string
+	mu   sync.Mutex
+	data file
+}
+
+type file struct {
+	Destinations map[string]Destination `json:"destinations"`
+	Runs         []Run                  `json:"runs,omitempty"`
+}
+
+// Destination is what is known of a destination of the batches, by key.
+type Destination struct {
+	Fingerprint string    `json:"fingerprint"` // of the change last pushed.
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ErrNoRun is returned by DB.Run when no run has the given ID.
+var ErrNoRun = errors.New("run not found")
+
+// Run is the record of a batch run.
+type Run struct {
+	ID         string    `json:"id,omitempty"`  // generated for every run, also found in its pull requests.
+	Job        string    `json:"job,omitempty"` // name of the scheduled job, empty for manual runs.
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Owner      string    `json:"owner"`
+	Head       string    `json:"head"`
+	Subject    string    `json:"subject"`
+	Results    []Result  `json:"results"`
+}
+
+// Result is the outcome of a run for a destination.
+type Result struct {
+	Repository string `json:"repository"`
+	Head       string `json:"head,omitempty"` // branch of the pull request, the head of the run suffixed when asked.
+	URL        string `json:"url,omitempty"`  // of the pull request.
+	Unchanged  bool   `json:"unchanged,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Open loads the state file at path, which is created on the first change
+// when missing.
+func Open(path string) (*DB, error) {
+	db := &DB{path: path, data: file{Destinations: make(map[string]Destination)}}
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return db, nil
+	case err != nil:
+		return nil, fmt.Errorf("unable to read state: %w", err)
+	}
+
+	if err := json.Unmarshal(content, &db.data); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+
+	if db.data.Destinations == nil {
+		db.data.Destinations = make(map[string]Destination)
+	}
+
+	return db, nil
+}
+
+// Fingerprint returns the fingerprint of the change last pushed to the
+// destination, empty when unknown.
+func (db *DB) Fingerprint(key string) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.data.Destinations[key].Fingerprint, nil
+}
+
+// SetFingerprint records the fingerprint of the change pushed to the
+// destination.
+func (db *DB) SetFingerprint(key, fingerprint string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.data.Destinations[key] = Destination{Fingerprint: fingerprint, UpdatedAt: time.Now().UTC()}
+	return db.save()
+}
+
+// AddRun records a finished run.
+func (db *DB) AddRun(r Run) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.data.Runs = append(db.data.Runs, r)
+	return db.save()
+}
+
+// Runs returns the recorded runs, oldest first.
+func (db *DB) Runs() []Run {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Run(nil), db.data.Runs...)
+}
+
+// Run returns the recorded run with the given ID, the latest one when id is
+// "last".
+func (db *DB) Run(id string) (Run, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i := len(db.data.Runs) - 1; i >= 0; i-- {
+		if r := db.data.Runs[i]; r.ID == id || id == "last" {
+			return r, nil
+		}
+	}
+
+	return Run{}, fmt.Errorf("%w: %s", ErrNoRun, id)
+}
+
+// save writes the state aside and renames it, so an interrupted run never
+// leaves a truncated file. The lock must be held.
+func (db *DB) save() error {
+	content, err := json.MarshalIndent(db.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := db.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return fmt.Errorf("unable to write state: %w", err)
+	}
+
+	return os.Rename(tmp, db.path)
+}
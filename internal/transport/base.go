@@ -0,0 +1,98 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Options configures the transport every request goes through, usually
+// required by corporate networks.
+type Options struct {
+	Proxy              string  `yaml:"proxy"`                // HTTP(S) proxy URL, the HTTPS_PROXY and NO_PROXY variables are honored when empty.
+	CABundle           string  `yaml:"ca_bundle"`            // PEM file with root certificates trusted on top of the system ones.
+	InsecureSkipVerify bool    `yaml:"insecure_skip_verify"` // disables the verification of server certificates.
+	Timeout            string  `yaml:"timeout"`              // time limit of each request, for instance, "30s".
+	CacheDir           string  `yaml:"cache_dir"`            // directory caching the GET responses by ETag, disabled when empty.
+	MaxRPS             float64 `yaml:"max_rps"`              // requests sent per second at most, unlimited when zero.
+	UserAgent          string  `yaml:"user_agent"`           // User-Agent of the requests, the one of the client library when empty.
+
+	MaxIdleConnsPerHost int  `yaml:"max_idle_conns_per_host"` // kept-alive connections to the API, 16 when zero.
+	DisableHTTP2        bool `yaml:"disable_http2"`           // sticks to HTTP/1.1, for proxies mishandling HTTP/2.
+}
+
+// _defaultMaxIdleConnsPerHost replaces the default of 2 of net/http, too low
+// when every request goes to the same API host.
+const _defaultMaxIdleConnsPerHost = 16
+
+// NewBase returns the base transport configured with o. It is meant to be
+// built once and shared by every request of a run, so connections are reused
+// across destinations.
+func NewBase(o Options) (*http.Transport, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.MaxIdleConnsPerHost = _defaultMaxIdleConnsPerHost
+	if o.MaxIdleConnsPerHost > 0 {
+		base.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+	}
+
+	// a custom TLS config disables HTTP/2 unless forced.
+	base.ForceAttemptHTTP2 = !o.DisableHTTP2
+	if o.DisableHTTP2 {
+		base.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	if o.Proxy != "" {
+		proxy, err := url.Parse(o.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		base.Proxy = http.ProxyURL(proxy)
+	}
+
+	if o.CABundle == "" && !o.InsecureSkipVerify {
+		return base, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.InsecureSkipVerify, //nolint:gosec // opt-in for intercepting proxies.
+	}
+
+	if o.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(o.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA bundle: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", o.CABundle)
+		}
+		config.RootCAs = pool
+	}
+
+	base.TLSClientConfig = config
+	return base, nil
+}
+
+// RequestTimeout returns the parsed Timeout, zero meaning no time limit.
+func (o Options) RequestTimeout() (time.Duration, error) {
+	if o.Timeout == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(o.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout: %w", err)
+	}
+
+	return d, nil
+}
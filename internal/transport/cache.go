@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Cache is an http.RoundTripper keeping the GET responses with an ETag on
+// disk and revalidating them with If-None-Match, so repeated reads of refs,
+// contents or repositories are answered with a 304 Not Modified, which GitHub
+// does not count against the rate limit.
+type Cache struct {
+	base http.RoundTripper
+	dir  string
+}
+
+// cacheEntry is a cached response.
+type cacheEntry struct {
+	ETag       string      `json:"etag"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// NewCache returns a Cache storing the responses in dir, created if missing,
+// and sending the requests through base.
+func NewCache(base http.RoundTripper, dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("unable to create cache directory: %w", err)
+	}
+
+	return &Cache{base: base, dir: dir}, nil
+}
+
+func (c *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return c.base.RoundTrip(req)
+	}
+
+	path := c.path(req)
+	entry, ok := c.load(path)
+	if ok {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := c.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return entry.response(req, resp.Header), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// a cache that cannot be written only costs rate limit, the response is
+	// still good.
+	_ = c.store(path, cacheEntry{ETag: etag, StatusCode: resp.StatusCode, Header: resp.Header, Body: body})
+	return resp, nil
+}
+
+// path returns the file of the request, keyed by the credentials as well, so
+// tokens with different access never share a response.
+func (c *Cache) path(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Accept") + "\n" + req.Header.Get("Authorization")))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cache) load(path string) (cacheEntry, bool) {
+	var entry cacheEntry
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return entry, false
+	}
+
+	if err := json.Unmarshal(content, &entry); err != nil || entry.ETag == "" {
+		return entry, false
+	}
+
+	return entry, true
+}
+
+func (c *Cache) store(path string, entry cacheEntry) error {
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// written aside and renamed so concurrent runs never read half an entry.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// response returns the cached response, with the headers of the 304, such
+// as the rate limit ones, taking precedence.
+func (e cacheEntry) response(req *http.Request, fresh http.Header) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	for k, v := range fresh {
+		header[k] = v
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,149 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+	"gopkg.in/yaml.v3"
+)
+
+// Cassette holds the HTTP interactions recorded by a Recorder, in order.
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is a request and the response it got. Request headers are not
+// recorded so credentials are never written.
+type Interaction struct {
+	Method      string      `yaml:"method"`
+	URL         string      `yaml:"url"`
+	RequestBody string      `yaml:"request_body,omitempty"`
+	StatusCode  int         `yaml:"status_code"`
+	Header      http.Header `yaml:"header,omitempty"`
+	Body        string      `yaml:"body,omitempty"`
+}
+
+// Recorder is an http.RoundTripper recording the interactions going through
+// it into a cassette.
+type Recorder struct {
+	base     http.RoundTripper
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder sending the requests through base.
+func NewRecorder(base http.RoundTripper) *Recorder {
+	return &Recorder{base: base}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		reqBody, _ = ioutil.ReadAll(body)
+		body.Close()
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Method:      req.Method,
+		URL:         redact.String(req.URL.String()),
+		RequestBody: redact.String(string(reqBody)),
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header,
+		Body:        redact.String(string(body)),
+	})
+
+	return resp, nil
+}
+
+// Save writes the recorded cassette to path.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	content, err := yaml.Marshal(r.cassette)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0o600)
+}
+
+// Replayer is an http.RoundTripper answering the requests with the responses
+// of a cassette instead of sending them. Each request gets the first unused
+// interaction with the same method and URL.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a Replayer of the cassette at path.
+func NewReplayer(path string) (*Replayer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cassette Cassette
+	if err := yaml.Unmarshal(content, &cassette); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+
+	return &Replayer{interactions: cassette.Interactions, used: make([]bool, len(cassette.Interactions))}, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, v := range r.interactions {
+		if r.used[i] || v.Method != req.Method || v.URL != req.URL.String() {
+			continue
+		}
+		r.used[i] = true
+
+		header := v.Header
+		if header == nil {
+			header = make(http.Header)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", v.StatusCode, http.StatusText(v.StatusCode)),
+			StatusCode:    v.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(v.Body))),
+			ContentLength: int64(len(v.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+}
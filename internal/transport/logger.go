@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/redact"
+)
+
+// _maxLoggedBody is the length of the bodies logged at most.
+const _maxLoggedBody = 64 << 10
+
+// UserAgent is an http.RoundTripper setting the User-Agent of the requests, so
+// the ones of a tool, and of a run, can be told apart by the API hosts.
+type UserAgent struct {
+	base  http.RoundTripper
+	value string
+}
+
+// NewUserAgent returns a UserAgent sending the requests through base with the
+// given User-Agent.
+func NewUserAgent(base http.RoundTripper, value string) *UserAgent {
+	return &UserAgent{base: base, value: value}
+}
+
+func (u *UserAgent) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", u.value)
+	return u.base.RoundTrip(req)
+}
+
+// Logger is an http.RoundTripper logging the method, URL, status, duration and
+// request ID of every request, and their bodies when asked, redacted. The
+// request IDs are what the support of GitHub asks for.
+type Logger struct {
+	base   http.RoundTripper
+	log    *log.Logger
+	bodies bool
+}
+
+// NewLogger returns a Logger sending the requests through base and logging
+// them to l, along with their bodies when bodies is set.
+func NewLogger(base http.RoundTripper, l *log.Logger, bodies bool) *Logger {
+	return &Logger{base: base, log: l, bodies: bodies}
+}
+
+func (l *Logger) RoundTrip(req *http.Request) (*http.Response, error) {
+	url := redact.String(req.URL.String())
+	if l.bodies && req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		l.log.Printf("%s %s request body: %s", req.Method, url, loggedBody(body))
+	}
+
+	start := time.Now()
+	resp, err := l.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		l.log.Printf("%s %s failed after %s: %s", req.Method, url, elapsed, redact.String(err.Error()))
+		return nil, err
+	}
+
+	id := resp.Header.Get("X-GitHub-Request-Id")
+	if id == "" {
+		id = resp.Header.Get("X-Request-Id")
+	}
+	if id == "" {
+		id = "-"
+	}
+	l.log.Printf("%s %s %d %s request-id=%s", req.Method, url, resp.StatusCode, elapsed, id)
+
+	if l.bodies {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		l.log.Printf("%s %s response body: %s", req.Method, url, loggedBody(body))
+	}
+
+	return resp, nil
+}
+
+// loggedBody returns the body as logged: redacted, truncated and without the
+// content of binary bodies.
+func loggedBody(body []byte) string {
+	switch {
+	case len(body) == 0:
+		return "(empty)"
+	case !utf8.Valid(body):
+		return fmt.Sprintf("(%d bytes of binary content)", len(body))
+	case len(body) > _maxLoggedBody:
+		return redact.String(string(body[:_maxLoggedBody])) + "... (truncated)"
+	default:
+		return redact.String(string(body))
+	}
+}
@@ -0,0 +1,95 @@
+// Package transport provides the http.RoundTripper middlewares of the clients
+// built by the commands.
+package transport
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Pool is an http.RoundTripper that authenticates each request with the next
+// token of the pool, round-robin, skipping the tokens whose rate limit is
+// exhausted until it is reset.
+type Pool struct {
+	base   http.RoundTripper
+	scheme string
+	mu     sync.Mutex
+	tokens []*pooledToken
+	next   int
+}
+
+type pooledToken struct {
+	value     string
+	remaining int // -1 until known.
+	reset     time.Time
+}
+
+// NewPool returns a Pool of the given tokens sending requests through base.
+// Tokens are sent as credentials of the given authorization scheme, such as
+// "Bearer".
+func NewPool(base http.RoundTripper, scheme string, tokens []string) *Pool {
+	p := &Pool{base: base, scheme: scheme}
+	for _, v := range tokens {
+		p.tokens = append(p.tokens, &pooledToken{value: v, remaining: -1})
+	}
+
+	return p
+}
+
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := p.pick()
+
+	// a RoundTripper must not modify the given request.
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", p.scheme+" "+token.value)
+	resp, err := p.base.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p.update(token, resp.Header)
+	return resp, nil
+}
+
+// pick returns the next token with budget left or, when every token is
+// exhausted, the one whose rate limit is reset first.
+func (p *Pool) pick() *pooledToken {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var earliest *pooledToken
+	for i := 0; i < len(p.tokens); i++ {
+		t := p.tokens[(p.next+i)%len(p.tokens)]
+		if t.remaining != 0 || now.After(t.reset) {
+			p.next = (p.next + i + 1) % len(p.tokens)
+			return t
+		}
+
+		if earliest == nil || t.reset.Before(earliest.reset) {
+			earliest = t
+		}
+	}
+
+	return earliest
+}
+
+// update tracks the rate limit reported by GitHub for token.
+func (p *Pool) update(token *pooledToken, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token.remaining = remaining
+	token.reset = time.Unix(reset, 0)
+}
@@ -0,0 +1,46 @@
+package transport
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Throttle is an http.RoundTripper spacing the requests so no more than a
+// given number are sent per second, staying below the secondary rate limits
+// of large batches run with a single token.
+type Throttle struct {
+	base     http.RoundTripper
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time // when the next request may be sent.
+}
+
+// NewThrottle returns a Throttle sending at most rps requests per second
+// through base.
+func NewThrottle(base http.RoundTripper, rps float64) *Throttle {
+	return &Throttle{base: base, interval: time.Duration(float64(time.Second) / rps)}
+}
+
+func (t *Throttle) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	now := time.Now()
+	at := t.next
+	if at.Before(now) {
+		at = now
+	}
+	t.next = at.Add(t.interval)
+	t.mu.Unlock()
+
+	if wait := time.Until(at); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
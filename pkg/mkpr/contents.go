@@ -0,0 +1,80 @@
+package mkpr
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// _streamSize is the size from which the files committed as is are streamed
+// from disk instead of being held in memory.
+const _streamSize = 1 << 20
+
+// contents holds the local files of a batch, read from disk once however many
+// destinations commit them.
+type contents struct {
+	mu    sync.Mutex
+	files map[string][]byte // by source path.
+}
+
+func newContents() *contents {
+	return &contents{files: make(map[string][]byte)}
+}
+
+// read returns the content of the file at path. The returned slice is shared,
+// it must not be modified.
+func (c *contents) read(path string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b, ok := c.files[path]; ok {
+		return b, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.files[path] = b
+	return b, nil
+}
+
+// stream returns the file streamed from disk when it is large and committed
+// as is, ok being false otherwise.
+func (f *pullRequestCommand) stream(file File, attrs gitAttributes) (streamed provider.File, ok bool, err error) {
+	if f.options.TemplateData != nil || len(file.Transforms) > 0 || file.Source == "" {
+		return provider.File{}, false, nil
+	}
+
+	target, source := file.Target, file.Source
+	if target == "" {
+		target = source
+	}
+
+	// the content is unknown yet, text=auto files are not streamed.
+	endings := f.lineEndings(file)
+	if attrs != nil {
+		endings = attrs.lineEndingsFor(target, endings, nil)
+	}
+	if endings != "" && endings != LineEndingsPreserve {
+		return provider.File{}, false, nil
+	}
+
+	info, err := os.Stat(file.Source)
+	if err != nil {
+		return provider.File{}, false, err
+	}
+
+	if info.Size() < _streamSize {
+		return provider.File{}, false, nil
+	}
+
+	return provider.File{
+		Path: target,
+		Size: info.Size(),
+		Open: func() (io.ReadCloser, error) { return os.Open(source) },
+	}, true, nil
+}
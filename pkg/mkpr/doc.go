@@ -0,0 +1,22 @@
+// Package mkpr creates the same pull request on a batch of repositories.
+//
+// Tools embed it by describing the change with a BatchPullRequestOption and
+// running a BatchPullRequestCommand with an authenticated HTTP client:
+//
+//	cmd, err := mkpr.NewBatchPullRequestCommand(tc, mkpr.BatchPullRequestOption{
+//		CommitMessage: "Add license",
+//		Subject:       "Add license",
+//		Head:          "feature/add-license",
+//		Owner:         "my-org",
+//		Destinations:  []mkpr.Destination{{Repository: "my-repo", Base: "master"}},
+//		Files:         []mkpr.File{{Source: "LICENSE"}},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//
+//	urls, err := cmd.Do(ctx)
+//
+// The hosting service is selected by BatchPullRequestOption.Provider, see
+// package github.com/sorfino/go-toolkit-cmd/pkg/provider.
+package mkpr
@@ -0,0 +1,106 @@
+package mkpr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// Failure categories of the batch, to be checked with errors.Is on the errors
+// returned by BatchPullRequestCommand.
+var (
+	ErrNoPushAccess = errors.New("no push access")
+	ErrBranchExists = provider.ErrBranchExists
+	ErrPRExists     = provider.ErrPullRequestExists
+	ErrRateLimited  = provider.ErrRateLimited
+)
+
+// UnresolvedPlaceholderError is returned when rendered content still holds
+// template markers. The destination is skipped instead of pushing it.
+type UnresolvedPlaceholderError struct {
+	Name   string // rendered file or field.
+	Marker string // marker found.
+	Line   int    // line of the first occurrence.
+}
+
+func (e *UnresolvedPlaceholderError) Error() string {
+	return fmt.Sprintf("unresolved placeholder %q in %s at line %d", e.Marker, e.Name, e.Line)
+}
+
+// HookError is returned when a BeforeDestination hook rejects a destination,
+// which is skipped.
+type HookError struct {
+	Err error
+}
+
+func (e *HookError) Error() string {
+	return "rejected by hook: " + e.Err.Error()
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is returned when processing a destination panics, for instance,
+// on an unexpected API response. The destination is marked failed and the rest
+// of the batch goes on.
+type PanicError struct {
+	Value interface{}
+	Stack []byte // stack trace of the panic.
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// skippable reports whether the failure of a destination lets the batch go on.
+func skippable(err error) bool {
+	var (
+		unresolved *UnresolvedPlaceholderError
+		hook       *HookError
+		panicked   *PanicError
+	)
+	return errors.As(err, &unresolved) || errors.As(err, &hook) || errors.As(err, &panicked)
+}
+
+// DestinationError is the failure of a single destination of the batch.
+type DestinationError struct {
+	Repository string
+	Err        error
+}
+
+func (e *DestinationError) Error() string {
+	return e.Repository + ": " + e.Err.Error()
+}
+
+func (e *DestinationError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is returned when some destinations failed while the rest of the
+// batch was processed.
+type BatchError struct {
+	Errors []*DestinationError
+}
+
+func (e *BatchError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, v := range e.Errors {
+		messages = append(messages, v.Error())
+	}
+
+	return fmt.Sprintf("%d destination(s) failed: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Is reports whether any of the destinations failed with target.
+func (e *BatchError) Is(target error) bool {
+	for _, v := range e.Errors {
+		if errors.Is(v, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package mkpr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ExternalTransform is a transform declared in the config and run as an
+// external program, reading the content from stdin and writing the result to
+// stdout. The destination, the path, the variables and the arguments are given
+// as MKPR_OWNER, MKPR_REPOSITORY, MKPR_BASE, MKPR_PATH, MKPR_VAR_<NAME> and
+// MKPR_ARG_<NAME> environment variables.
+type ExternalTransform struct {
+	Command string `yaml:"command"` // shell command, run with sh -c.
+	WASM    string `yaml:"wasm"`    // WASI module, run with Runtime.
+	Runtime string `yaml:"runtime"` // WASI runtime executable, "wasmtime" by default.
+}
+
+func (t ExternalTransform) validate() error {
+	if (t.Command == "") == (t.WASM == "") {
+		return errors.New("either command or wasm must be set")
+	}
+
+	return nil
+}
+
+func (t ExternalTransform) Apply(ctx context.Context, in []byte, tc TransformContext) ([]byte, error) {
+	env := transformEnv(tc)
+
+	var cmd *exec.Cmd
+	if t.Command != "" {
+		cmd = exec.CommandContext(ctx, "sh", "-c", t.Command) //nolint:gosec // the command is given by the user on purpose.
+		cmd.Env = append(os.Environ(), env...)
+	} else {
+		runtime := t.Runtime
+		if runtime == "" {
+			runtime = "wasmtime"
+		}
+
+		// WASI modules only see the variables given explicitly.
+		args := []string{"run"}
+		for _, v := range env {
+			args = append(args, "--env", v)
+		}
+		cmd = exec.CommandContext(ctx, runtime, append(args, t.WASM)...) //nolint:gosec // the module is given by the user on purpose.
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// transformEnv returns the context of the transform as environment variables.
+func transformEnv(tc TransformContext) []string {
+	env := []string{
+		"MKPR_OWNER=" + tc.Owner,
+		"MKPR_REPOSITORY=" + tc.Repository,
+		"MKPR_BASE=" + tc.Base,
+		"MKPR_PATH=" + tc.Path,
+	}
+	env = append(env, prefixed("MKPR_VAR_", tc.Vars)...)
+	return append(env, prefixed("MKPR_ARG_", tc.Args)...)
+}
+
+// prefixed returns the values as sorted variables named by the upper cased
+// keys with the given prefix.
+func prefixed(prefix string, values map[string]string) []string {
+	env := make([]string, 0, len(values))
+	for k, v := range values {
+		env = append(env, prefix+strings.ToUpper(k)+"="+v)
+	}
+	sort.Strings(env)
+
+	return env
+}
@@ -0,0 +1,93 @@
+package mkpr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// Fingerprints stores the fingerprint of the change last pushed to each
+// destination, so incremental batches skip the destinations whose desired
+// state did not change. Keys identify the repository, base and head branches.
+type Fingerprints interface {
+	Fingerprint(key string) (string, error) // empty when unknown.
+	SetFingerprint(key, fingerprint string) error
+}
+
+// errUnchanged is returned by a destination whose fingerprint matches the one
+// of the last successful run.
+var errUnchanged = errors.New("unchanged since the last run")
+
+// fingerprintKey returns the key of the destination in the Fingerprints.
+func (f *pullRequestCommand) fingerprintKey() string {
+	return f.options.SourceOwner + "/" + f.options.SourceRepo + ":" + f.options.BaseBranch + ":" + f.options.CommitBranch
+}
+
+// fingerprint returns the hash of the rendered change: the files along with the
+// commit message and the pull request subject and body.
+func (f *pullRequestCommand) fingerprint(files []provider.File) (string, error) {
+	sorted := make([]provider.File, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	h := sha256.New()
+	for _, v := range []string{f.options.CommitMessage, f.options.PullRequestSubject, f.options.PullRequestBody} {
+		h.Write([]byte(strconv.Itoa(len(v)) + ":" + v))
+	}
+
+	for _, v := range sorted {
+		h.Write([]byte(strconv.Itoa(len(v.Path)) + ":" + v.Path + strconv.FormatInt(v.Len(), 10) + ":"))
+		if err := hashContent(h, v); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashContent writes the content of the file to h, streaming it when needed.
+func hashContent(h io.Writer, file provider.File) error {
+	if file.Content != nil || file.Open == nil {
+		_, err := h.Write(file.Content)
+		return err
+	}
+
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(h, r)
+	return err
+}
+
+// checkFingerprint returns the fingerprint of the change, or errUnchanged when
+// it matches the recorded one.
+func (f *pullRequestCommand) checkFingerprint(files []provider.File) (string, error) {
+	if f.fingerprints == nil {
+		return "", nil
+	}
+
+	fingerprint, err := f.fingerprint(files)
+	if err != nil {
+		return "", fmt.Errorf("unable to compute the fingerprint: %w", err)
+	}
+
+	last, err := f.fingerprints.Fingerprint(f.fingerprintKey())
+	if err != nil {
+		return "", fmt.Errorf("unable to read the fingerprint: %w", err)
+	}
+
+	if last == fingerprint {
+		return "", errUnchanged
+	}
+
+	return fingerprint, nil
+}
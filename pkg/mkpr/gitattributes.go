@@ -0,0 +1,141 @@
+package mkpr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// gitAttributes are the rules of a .gitattributes file, in order.
+type gitAttributes []gitAttributesRule
+
+type gitAttributesRule struct {
+	pattern string
+	attrs   map[string]string // "set", "unset" or the value, by name.
+}
+
+// parseGitAttributes parses the content of a .gitattributes file, ignoring
+// the macros and the lines it does not understand.
+func parseGitAttributes(content string) gitAttributes {
+	var rules gitAttributes
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+
+		rule := gitAttributesRule{pattern: fields[0], attrs: make(map[string]string)}
+		for _, v := range fields[1:] {
+			switch {
+			case strings.HasPrefix(v, "-"):
+				rule.attrs[v[1:]] = "unset"
+			case strings.HasPrefix(v, "!"):
+				rule.attrs[v[1:]] = ""
+			case strings.Contains(v, "="):
+				i := strings.Index(v, "=")
+				rule.attrs[v[:i]] = v[i+1:]
+			case v == "binary":
+				rule.attrs["text"] = "unset"
+			default:
+				rule.attrs[v] = "set"
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// attr returns the value of the attribute for the file at target, the last
+// matching rule winning, empty when unspecified.
+func (g gitAttributes) attr(target, name string) string {
+	value := ""
+	for _, v := range g {
+		if a, ok := v.attrs[name]; ok && matchGitPattern(v.pattern, target) {
+			value = a
+		}
+	}
+
+	return value
+}
+
+// matchGitPattern reports whether the path matches the pattern: patterns
+// without a slash match the base name, the others the whole path, "**/"
+// matching any directory.
+func matchGitPattern(pattern, target string) bool {
+	target = strings.TrimPrefix(target, "/")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(target))
+		return ok
+	}
+
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasPrefix(pattern, "**/") {
+		rest := strings.TrimPrefix(pattern, "**/")
+		for dir := target; ; {
+			if matchGitPattern("/"+rest, dir) {
+				return true
+			}
+
+			i := strings.Index(dir, "/")
+			if i < 0 {
+				return false
+			}
+			dir = dir[i+1:]
+		}
+	}
+
+	if strings.HasSuffix(pattern, "/**") {
+		return strings.HasPrefix(target, strings.TrimSuffix(pattern, "**"))
+	}
+
+	ok, _ := path.Match(pattern, target)
+	return ok
+}
+
+// lineEndingsFor returns the line endings the content of the file at target
+// must be committed with: the repository stores the text files with LF, while
+// the ones with -text, or binary, are committed as is. The line endings of
+// the batch apply to the files the attributes say nothing about.
+func (g gitAttributes) lineEndingsFor(target string, configured string, content []byte) string {
+	switch text := g.attr(target, "text"); {
+	case text == "unset":
+		return LineEndingsPreserve
+	case text == "set", g.attr(target, "eol") != "":
+		return LineEndingsLF
+	case text == "auto" && provider.IsText(content):
+		return LineEndingsLF
+	case text == "auto":
+		return LineEndingsPreserve
+	default:
+		return configured
+	}
+}
+
+// gitAttributes returns the .gitattributes of the base branch, when the batch
+// honors them and the provider can read it.
+func (f *pullRequestCommand) gitAttributes(ctx context.Context) (gitAttributes, error) {
+	if !f.options.GitAttributes {
+		return nil, nil
+	}
+
+	reader, ok := f.provider.(provider.FileReader)
+	if !ok {
+		f.logger.Printf("%s: the provider cannot read .gitattributes, ignored", f.options.SourceRepo)
+		return nil, nil
+	}
+
+	content, err := reader.GetFile(ctx, f.options.SourceOwner, f.options.SourceRepo, f.options.BaseBranch, ".gitattributes")
+	switch {
+	case errors.Is(err, provider.ErrFileNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("unable to read .gitattributes: %w", err)
+	}
+
+	return parseGitAttributes(string(content)), nil
+}
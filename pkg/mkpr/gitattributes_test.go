@@ -0,0 +1,56 @@
+package mkpr
+
+import "testing"
+
+func TestLineEndingsFor(t *testing.T) {
+	attrs := parseGitAttributes(`# line endings
+*           text=auto
+*.sh        text eol=lf
+*.bat       eol=crlf
+*.png       binary
+docs/**     -text
+[attr]lfs   filter=lfs -text
+vendor/*.go !text
+`)
+
+	tests := []struct {
+		name, target, configured, content, want string
+	}{
+		{name: "eol", target: "build.bat", configured: LineEndingsCRLF, content: "a\r\n", want: LineEndingsLF},
+		{name: "text with eol", target: "scripts/run.sh", configured: LineEndingsCRLF, content: "a\n", want: LineEndingsLF},
+		{name: "binary", target: "logo.png", configured: LineEndingsLF, content: "a\n", want: LineEndingsPreserve},
+		{name: "unset text", target: "docs/a/guide.md", configured: LineEndingsLF, content: "a\n", want: LineEndingsPreserve},
+		{name: "auto text", target: "main.go", configured: LineEndingsCRLF, content: "a\r\n", want: LineEndingsLF},
+		{name: "auto binary", target: "main.go", configured: LineEndingsCRLF, content: "\x00\x01", want: LineEndingsPreserve},
+		{name: "unspecified text", target: "vendor/a.go", configured: LineEndingsCRLF, content: "a\n", want: LineEndingsCRLF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attrs.lineEndingsFor(tt.target, tt.configured, []byte(tt.content)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchGitPattern(t *testing.T) {
+	tests := []struct {
+		pattern, target string
+		want            bool
+	}{
+		{pattern: "*.png", target: "assets/logo.png", want: true},
+		{pattern: "/*.png", target: "assets/logo.png", want: false},
+		{pattern: "assets/*.png", target: "assets/logo.png", want: true},
+		{pattern: "**/fixtures/*", target: "a/b/fixtures/x.txt", want: true},
+		{pattern: "**/fixtures/*", target: "fixtures/x.txt", want: true},
+		{pattern: "docs/**", target: "docs/a/b.md", want: true},
+		{pattern: "docs/**", target: "documents/b.md", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := matchGitPattern(tt.pattern, tt.target); got != tt.want {
+			t.Errorf("%s %s: got %t, want %t", tt.pattern, tt.target, got, tt.want)
+		}
+	}
+}
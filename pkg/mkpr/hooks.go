@@ -0,0 +1,67 @@
+package mkpr
+
+import (
+	"context"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// Hooks are called along the batch, from the goroutine processing it, so they
+// must not block. Nil hooks are skipped.
+type Hooks struct {
+	OnDestinationStart func(d Destination)
+
+	// BeforeDestination runs before changing the destination, which is skipped
+	// with a *HookError when it fails.
+	BeforeDestination func(ctx context.Context, d Destination) error
+
+	OnCommitPushed func(d Destination, sha string)
+	OnPRCreated    func(d Destination, pr provider.PullRequest)
+	OnError        func(d Destination, err error)
+
+	// OnUnchanged runs for the destinations an incremental batch skips, their
+	// changes having already been pushed.
+	OnUnchanged func(d Destination)
+}
+
+func (h Hooks) destinationStart(d Destination) {
+	if h.OnDestinationStart != nil {
+		h.OnDestinationStart(d)
+	}
+}
+
+func (h Hooks) beforeDestination(ctx context.Context, d Destination) error {
+	if h.BeforeDestination == nil {
+		return nil
+	}
+
+	if err := h.BeforeDestination(ctx, d); err != nil {
+		return &HookError{Err: err}
+	}
+
+	return nil
+}
+
+func (h Hooks) commitPushed(d Destination, sha string) {
+	if h.OnCommitPushed != nil {
+		h.OnCommitPushed(d, sha)
+	}
+}
+
+func (h Hooks) prCreated(d Destination, pr provider.PullRequest) {
+	if h.OnPRCreated != nil {
+		h.OnPRCreated(d, pr)
+	}
+}
+
+func (h Hooks) failed(d Destination, err error) {
+	if h.OnError != nil {
+		h.OnError(d, err)
+	}
+}
+
+func (h Hooks) unchanged(d Destination) {
+	if h.OnUnchanged != nil {
+		h.OnUnchanged(d)
+	}
+}
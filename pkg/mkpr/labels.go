@@ -0,0 +1,114 @@
+package mkpr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// LabelRule labels the pull requests changing any file matching its paths,
+// so the automation keyed on labels, such as CI owners reviewing workflow
+// changes, keeps working for the generated pull requests.
+type LabelRule struct {
+	// Paths are matched against the target paths of the committed files like
+	// .gitattributes patterns: without a slash they match the base name,
+	// otherwise the whole path, "**/" matching any directory and "/**"
+	// everything inside one.
+	Paths  []string `yaml:"paths"`
+	Labels []string `yaml:"labels"`
+}
+
+func (r LabelRule) validate() error {
+	if len(r.Paths) == 0 || len(r.Labels) == 0 {
+		return errors.New("label rules need paths and labels")
+	}
+
+	for _, v := range r.Paths {
+		pattern := strings.TrimPrefix(strings.TrimSuffix(v, "/**"), "**/")
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid label path %q: %w", v, err)
+		}
+	}
+
+	return nil
+}
+
+// matches reports whether any of the files matches the paths of the rule.
+func (r LabelRule) matches(files []provider.File) bool {
+	for _, f := range files {
+		for _, v := range r.Paths {
+			if matchGitPattern(v, f.Path) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// labelsFor returns the labels of the rules matching the files, without
+// duplicates, in the order of the rules.
+func labelsFor(rules []LabelRule, files []provider.File) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, r := range rules {
+		if !r.matches(files) {
+			continue
+		}
+
+		for _, v := range r.Labels {
+			if !seen[v] {
+				seen[v] = true
+				out = append(out, v)
+			}
+		}
+	}
+
+	return out
+}
+
+// label adds the labels of the rules matching the committed files to the pull
+// request when supported. Failures are only logged, the pull request being
+// created.
+func (f *pullRequestCommand) label(ctx context.Context, pr provider.PullRequest, files []provider.File) {
+	labels := labelsFor(f.options.LabelRules, files)
+	if len(labels) == 0 {
+		return
+	}
+
+	labeler, ok := f.provider.(provider.Labeler)
+	if !ok {
+		f.logger.Printf("%s: the provider cannot label pull requests, %s not added", f.options.PullRequestRepo, strings.Join(labels, ", "))
+		return
+	}
+
+	if err := labeler.AddLabels(ctx, f.options.PullRequestOwner, f.options.PullRequestRepo, pr.Number, labels); err != nil {
+		f.logger.Printf("%s: unable to label %s: %v", f.options.PullRequestRepo, pr.URL, err)
+		return
+	}
+	f.logger.Printf("%s: labeled %s with %s", f.options.PullRequestRepo, pr.URL, strings.Join(labels, ", "))
+}
+
+// requestReviews requests the reviews of the reviewers of the batch when
+// supported. Failures are only logged, the pull request being created.
+func (f *pullRequestCommand) requestReviews(ctx context.Context, pr provider.PullRequest) {
+	if len(f.options.Reviewers) == 0 {
+		return
+	}
+
+	requester, ok := f.provider.(provider.ReviewRequester)
+	if !ok {
+		f.logger.Printf("%s: the provider cannot request reviews, %s not requested", f.options.PullRequestRepo, strings.Join(f.options.Reviewers, ", "))
+		return
+	}
+
+	if err := requester.RequestReviewers(ctx, f.options.PullRequestOwner, f.options.PullRequestRepo, pr.Number, f.options.Reviewers); err != nil {
+		f.logger.Printf("%s: unable to request reviews of %s: %v", f.options.PullRequestRepo, pr.URL, err)
+		return
+	}
+	f.logger.Printf("%s: requested reviews of %s from %s", f.options.PullRequestRepo, pr.URL, strings.Join(f.options.Reviewers, ", "))
+}
@@ -0,0 +1,44 @@
+package mkpr
+
+import "bytes"
+
+// Line endings the committed files are normalized to, set for the batch or
+// per file.
+const (
+	LineEndingsPreserve = "preserve" // content committed as is, the default.
+	LineEndingsLF       = "lf"       // CRLF converted to LF.
+	LineEndingsCRLF     = "crlf"     // LF converted to CRLF.
+)
+
+func validLineEndings(endings string) bool {
+	switch endings {
+	case "", LineEndingsPreserve, LineEndingsLF, LineEndingsCRLF:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizeLineEndings converts the line endings of content, returning it
+// unchanged when preserved.
+func normalizeLineEndings(content []byte, endings string) []byte {
+	switch endings {
+	case LineEndingsLF:
+		return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	case LineEndingsCRLF:
+		lf := bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+		return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+	default:
+		return content
+	}
+}
+
+// lineEndings returns the line endings of the file, the ones of the batch
+// unless set.
+func (f *pullRequestCommand) lineEndings(file File) string {
+	if file.LineEndings != "" {
+		return file.LineEndings
+	}
+
+	return f.options.LineEndings
+}
@@ -0,0 +1,24 @@
+package mkpr
+
+import "testing"
+
+func TestNormalizeLineEndings(t *testing.T) {
+	tests := []struct {
+		name, content, endings, want string
+	}{
+		{name: "preserve", content: "a\r\nb\nc", endings: LineEndingsPreserve, want: "a\r\nb\nc"},
+		{name: "default preserves", content: "a\r\nb\nc", want: "a\r\nb\nc"},
+		{name: "lf", content: "a\r\nb\nc\r\n", endings: LineEndingsLF, want: "a\nb\nc\n"},
+		{name: "lone carriage return kept by lf", content: "a\rb\r\n", endings: LineEndingsLF, want: "a\rb\n"},
+		{name: "crlf", content: "a\nb\r\nc\n", endings: LineEndingsCRLF, want: "a\r\nb\r\nc\r\n"},
+		{name: "crlf without doubling", content: "a\r\n\r\n", endings: LineEndingsCRLF, want: "a\r\n\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(normalizeLineEndings([]byte(tt.content), tt.endings)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
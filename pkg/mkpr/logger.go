@@ -0,0 +1,11 @@
+package mkpr
+
+// Logger receives the progress of the batch. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// nopLogger discards the messages when no logger is set.
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...interface{}) {}
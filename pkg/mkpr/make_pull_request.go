@@ -0,0 +1,916 @@
+package mkpr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// Destination is a repository to create a pull request against and its base
+// branch.
+type Destination struct {
+	Repository string `yaml:"repository"`
+	Base       string `yaml:"base"`
+}
+
+// File is a local file to commit and its location in the target repository.
+type File struct {
+	Source string `yaml:"source"` // path of the local file.
+	Target string `yaml:"target"` // path in the target repository, defaults to Source.
+
+	// Transforms applied in order to the content, after rendering it.
+	Transforms []TransformRef `yaml:"transforms"`
+
+	// LineEndings overrides the line endings of the batch for this file.
+	LineEndings string `yaml:"line_endings"`
+}
+
+// Suffixes appended to the head branch by BatchPullRequestOption.HeadSuffix.
+const (
+	HeadSuffixRun  = "run"  // the first characters of the run ID.
+	HeadSuffixHash = "hash" // the first characters of the hash of the change.
+)
+
+// Strategies when the head branch already exists on a destination.
+const (
+	HeadExistsReuse    = "reuse"    // commits on top of the existing branch.
+	HeadExistsFail     = "fail"     // fails with ErrBranchExists.
+	HeadExistsRecreate = "recreate" // deletes the branch and creates it from the base one.
+)
+
+// BatchPullRequestOption describes the change to apply to each destination.
+type BatchPullRequestOption struct {
+	CommitMessage string        `yaml:"commit_message"` // commit message.
+	Subject       string        `yaml:"subject"`        // pull request subject.
+	Body          string        `yaml:"body"`           // pull request body.
+	Destinations  []Destination `yaml:"destinations"`   // where to create the pull requests.
+	Files         []File        `yaml:"files"`          // files to commit.
+
+	authorName  string
+	authorEmail string
+	Head        string `yaml:"head"`  // name of the base branch, for instance, "feature/large-scale-change"
+	Delay       string `yaml:"delay"` // delay between PR creation (to avoid abuse errors from GH API)
+	Owner       string `yaml:"owner"` // owner (user or org) of the destination repositories, "mercadolibre" by default.
+
+	// Hosting service of the destinations: "github" by default,
+	// "github-graphql" (GitHub through its GraphQL API), "gitlab",
+	// "bitbucket" (Cloud), "bitbucket-server" (Server and Data Center),
+	// "azure" (Azure Repos) or "gitea" (Gitea and Forgejo).
+	Provider    string `yaml:"provider"`
+	ProviderURL string `yaml:"provider_url"` // base URL of the instance of providers other than GitHub.
+
+	// GitHub Enterprise Server API endpoints, github.com is used when empty.
+	GitHubURL string `yaml:"github_url"` // for instance, "https://github.example.com/api/v3/"
+	UploadURL string `yaml:"upload_url"` // defaults to the uploads endpoint of GitHubURL.
+
+	// When Render is set, files, commit message, subject, body and head are
+	// rendered as text/template for each destination. See TemplateData.
+	Render bool              `yaml:"render"`
+	Vars   map[string]string `yaml:"vars"` // variables available to templates as .Vars.
+
+	// Transforms run as external programs, referenced by name from the
+	// transforms of the files like the registered ones.
+	Transforms map[string]ExternalTransform `yaml:"transforms"`
+
+	// Rendered content is verified to hold no "{{", "<UNSET>" or "<no value>"
+	// markers, skipping the destination otherwise. Target paths listed here,
+	// such as GitHub workflows using ${{ }} expressions, are not verified.
+	AllowUnresolved []string `yaml:"allow_unresolved"`
+
+	// LineEndings normalizes the line endings of the committed files, after
+	// rendering and transforming them: "preserve" (the default), "lf" or
+	// "crlf". Template files authored on Windows otherwise produce whole file
+	// diffs.
+	LineEndings string `yaml:"line_endings"`
+
+	// GitAttributes reads the .gitattributes of each destination, when the
+	// provider can, and honors its text, eol and binary attributes: text
+	// files are committed with LF and the others as is, LineEndings only
+	// applying to the files it says nothing about.
+	GitAttributes bool `yaml:"gitattributes"`
+
+	// VerifyCommits looks up the signature verification of the pushed
+	// commits, when the provider reports it, so the destinations requiring
+	// signed commits that would reject the pull request are known at once.
+	VerifyCommits bool `yaml:"verify_commits"`
+
+	// HeadExists is what to do when the head branch already exists on a
+	// destination: "reuse" it, committing on top of it (the default), "fail"
+	// or "recreate" it from the base branch.
+	HeadExists string `yaml:"head_exists"`
+
+	// HeadSuffix appends a short unique suffix to the head branch, so batches
+	// sharing a head name do not push to each other's branches: "run", from
+	// RunID, or "hash", from the rendered change of each destination. None
+	// when empty.
+	HeadSuffix string `yaml:"head_suffix"`
+
+	// CommitStatus is set on every pushed commit when the provider supports
+	// it, so the generated commits stand out in dashboards and can satisfy
+	// required status checks.
+	CommitStatus *CommitStatusOption `yaml:"commit_status"`
+
+	// LabelRules label the created pull requests by the paths of the
+	// committed files, when the provider supports it.
+	LabelRules []LabelRule `yaml:"label_rules"`
+
+	// Reviewers are requested to review the created pull requests, when the
+	// provider supports it: user names or, for teams, "org/team".
+	Reviewers []string `yaml:"reviewers"`
+
+	// Split, when set, splits the changes exceeding its thresholds into
+	// several pull requests per destination.
+	Split *SplitOption `yaml:"split"`
+
+	// RunID identifies the run in the body of the created pull requests, as
+	// a hidden comment, so the pull requests of a run can be told apart. It
+	// is not part of the fingerprint of the change.
+	RunID string `yaml:"-"`
+}
+
+// CommitStatusOption describes the successful status set on the pushed
+// commits.
+type CommitStatusOption struct {
+	Context     string `yaml:"context"` // "mkpr" by default.
+	Description string `yaml:"description"`
+	TargetURL   string `yaml:"target_url"` // for instance, the report of the run.
+}
+
+// Validate reports whether the options describe a valid batch, without
+// contacting the provider.
+func (b BatchPullRequestOption) Validate() error {
+	if b.Head == "" {
+		return errors.New("base branch cannot be empty")
+	}
+
+	for name, t := range b.Transforms {
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("invalid transform %s: %w", name, err)
+		}
+	}
+
+	switch b.HeadExists {
+	case "", HeadExistsReuse, HeadExistsFail, HeadExistsRecreate:
+	default:
+		return fmt.Errorf("invalid head_exists %q, expected reuse, fail or recreate", b.HeadExists)
+	}
+
+	switch b.HeadSuffix {
+	case "", HeadSuffixRun, HeadSuffixHash:
+	default:
+		return fmt.Errorf("invalid head_suffix %q, expected run or hash", b.HeadSuffix)
+	}
+
+	for _, v := range b.LabelRules {
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+
+	if b.Split != nil {
+		if err := b.Split.validate(); err != nil {
+			return err
+		}
+	}
+
+	if !validLineEndings(b.LineEndings) {
+		return fmt.Errorf("invalid line endings %q", b.LineEndings)
+	}
+
+	for _, v := range b.Files {
+		if !validLineEndings(v.LineEndings) {
+			return fmt.Errorf("invalid line endings %q of file %s", v.LineEndings, v.Source)
+		}
+
+		for _, t := range v.Transforms {
+			if _, ok := b.Transforms[t.Name]; ok {
+				continue
+			}
+
+			if _, ok := LookupTransform(t.Name); !ok {
+				return fmt.Errorf("unknown transform %q of file %s", t.Name, v.Source)
+			}
+		}
+	}
+
+	for _, v := range b.Destinations {
+		if v.Base == "" {
+			return fmt.Errorf("head branc of destination repository %s is empty", v.Repository)
+		}
+
+		if b.Head == v.Base {
+			return fmt.Errorf("base branch cannot be the same as head at repository %s", v.Repository)
+		}
+	}
+
+	return nil
+}
+
+// OwnerOrDefault returns the owner of the destinations, "mercadolibre" when
+// not set.
+func (b BatchPullRequestOption) OwnerOrDefault() string {
+	if b.Owner == "" {
+		return "mercadolibre"
+	}
+
+	return b.Owner
+}
+
+// each calls f with the creation options of every destination, stopping at the
+// first error.
+func (b BatchPullRequestOption) each(ctx context.Context, f func(option pullRequestCreationOptions) error) error {
+	for _, v := range b.Destinations {
+		options := pullRequestCreationOptions{
+			SourceRepo:         v.Repository,
+			BaseBranch:         v.Base,
+			CommitMessage:      b.CommitMessage,
+			CommitBranch:       b.Head,
+			PullRequestRepo:    v.Repository,
+			PullRequestBranch:  v.Base,
+			PullRequestSubject: b.Subject,
+			PullRequestBody:    b.Body,
+			Files:              b.Files,
+			AuthorName:         b.authorName,
+			AuthorEmail:        b.authorEmail,
+			SourceOwner:        b.OwnerOrDefault(),
+			PullRequestOwner:   b.OwnerOrDefault(),
+			Vars:               b.Vars,
+			Transforms:         b.Transforms,
+			LineEndings:        b.LineEndings,
+			GitAttributes:      b.GitAttributes,
+			VerifyCommits:      b.VerifyCommits,
+			HeadExists:         b.HeadExists,
+			HeadSuffix:         b.HeadSuffix,
+			CommitStatus:       b.CommitStatus,
+			LabelRules:         b.LabelRules,
+			Reviewers:          b.Reviewers,
+			Split:              b.Split,
+			RunID:              b.RunID,
+		}
+
+		if b.Render {
+			options.TemplateData = &TemplateData{Owner: options.SourceOwner, Repository: v.Repository, Base: v.Base, Vars: b.Vars}
+			options.AllowUnresolved = b.AllowUnresolved
+		}
+
+		if err := f(options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type pullRequestCreationOptions struct {
+	SourceOwner        string // Name of the owner (user or org) of the repo to create the commit in
+	PullRequestOwner   string // Name of the owner (user or org) of the repo to create the PR against.
+	SourceRepo         string // same as PullRequestRepo
+	BaseBranch         string // develop or master
+	CommitMessage      string // "Automatic Large Scale Change"
+	CommitBranch       string // always options.Base
+	PullRequestRepo    string // destination Repository
+	PullRequestBranch  string // develop or master
+	PullRequestSubject string // your option
+	PullRequestBody    string // your option
+	Files              []File // list of files
+	AuthorName         string // f.client.Users.Get(ctx,"") gets the authenticated user.
+	AuthorEmail        string
+	TemplateData       *TemplateData // when not nil, files are rendered with it.
+	AllowUnresolved    []string      // target paths whose rendered content is not verified.
+	Vars               map[string]string
+	Transforms         map[string]ExternalTransform // transforms declared in the config.
+	LineEndings        string
+	GitAttributes      bool
+	VerifyCommits      bool
+	HeadExists         string
+	HeadSuffix         string
+	CommitStatus       *CommitStatusOption
+	LabelRules         []LabelRule
+	Reviewers          []string
+	Split              *SplitOption
+	RunID              string
+}
+
+type pullRequestCommand struct {
+	options  pullRequestCreationOptions
+	provider provider.Provider
+	hooks    Hooks
+	logger   Logger
+	contents *contents
+
+	fingerprints Fingerprints
+	verification *provider.Verification // of the pushed commit, when verified.
+	parts        []Part                 // pull requests created for a split change.
+}
+
+// BatchPullRequestCommand creates the same pull request on every destination.
+type BatchPullRequestCommand struct {
+	Hooks  Hooks  // called as each destination progresses.
+	Logger Logger // logs every step of the batch when set.
+
+	// Fingerprints makes the batch incremental when set, skipping the
+	// destinations whose change was already pushed by a previous run.
+	Fingerprints Fingerprints
+
+	options  BatchPullRequestOption
+	provider provider.Provider
+}
+
+// NewBatchPullRequestCommand returns a command creating the pull requests
+// described by options on the provider selected by them, authenticating with
+// tc.
+func NewBatchPullRequestCommand(tc *http.Client, options BatchPullRequestOption) (*BatchPullRequestCommand, error) {
+	// We consider that an error means the branch has not been found and needs to
+	// be created.
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	p, err := NewProvider(tc, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BatchPullRequestCommand{
+		options:  options,
+		provider: p,
+	}, nil
+}
+
+// NewBatchPullRequestCommandWithProvider returns a command creating the pull
+// requests described by options on p, ignoring the provider settings of
+// options. It allows running a batch against a fake provider, see package
+// github.com/sorfino/go-toolkit-cmd/pkg/provider/fake.
+func NewBatchPullRequestCommandWithProvider(p provider.Provider, options BatchPullRequestOption) (*BatchPullRequestCommand, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &BatchPullRequestCommand{
+		options:  options,
+		provider: p,
+	}, nil
+}
+
+// Result is the outcome of a destination of the batch.
+type Result struct {
+	Repository string
+	Head       string // head branch of the pull request, suffixed when asked.
+	URL        string // URL of the pull request, empty when none was created.
+	Unchanged  bool   // skipped by an incremental batch, URL is empty.
+	Err        error
+
+	// Verification of the pushed commit, when VerifyCommits is set and the
+	// provider reports it.
+	Verification *provider.Verification
+
+	// Parts are the pull requests created after the first one, Head and URL,
+	// for a change split by SplitOption.
+	Parts []Part
+}
+
+// Do creates the pull requests and returns their URLs. Destinations whose
+// content holds unresolved placeholders, rejected by a BeforeDestination hook
+// or panicking are skipped and reported as a *BatchError once every other
+// destination is done.
+func (f *BatchPullRequestCommand) Do(ctx context.Context) ([]string, error) {
+	results, err := f.DoStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0)
+	var skipped []*DestinationError
+	for r := range results {
+		if r.URL != "" {
+			urls = append(urls, r.URL)
+		}
+		for _, v := range r.Parts {
+			urls = append(urls, v.URL)
+		}
+
+		switch {
+		case r.Err == nil:
+		case skippable(r.Err):
+			skipped = append(skipped, &DestinationError{Repository: r.Repository, Err: r.Err})
+		default:
+			err = r.Err
+		}
+	}
+
+	if err == nil && len(skipped) > 0 {
+		err = &BatchError{Errors: skipped}
+	}
+
+	return urls, err
+}
+
+// DoStream checks the access to the destinations and creates the pull requests
+// in the background, sending the result of each destination as soon as it is
+// done. The batch stops after the first failure other than the skipped
+// destinations of Do, or when ctx is done, closing the channel, which must be
+// drained.
+func (f *BatchPullRequestCommand) DoStream(ctx context.Context) (<-chan Result, error) {
+	logger := f.logger()
+	u, err := f.provider.CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	f.prefetch(ctx, logger)
+
+	logger.Printf("verifying the access of %s to %d destination(s)", u.Name, len(f.options.Destinations))
+	if err := f.preflight(ctx, u); err != nil {
+		return nil, err
+	}
+
+	f.options.authorName = u.Name
+	f.options.authorEmail = u.Email
+
+	delay, _ := time.ParseDuration(f.options.Delay)
+
+	results := make(chan Result)
+	files := newContents()
+	go func() {
+		defer close(results)
+		_ = f.options.each(ctx, func(option pullRequestCreationOptions) error {
+			cmd := pullRequestCommand{
+				options:  option,
+				provider: f.provider,
+				hooks:    f.Hooks,
+				logger:   logger,
+				contents: files,
+
+				fingerprints: f.Fingerprints,
+			}
+
+			time.Sleep(delay)
+			logger.Printf("%s: creating the pull request on %s", option.PullRequestRepo, option.PullRequestBranch)
+			f.Hooks.destinationStart(cmd.destination())
+			pr, err := cmd.safeDo(ctx)
+			unchanged := errors.Is(err, errUnchanged)
+			if unchanged {
+				logger.Printf("%s: %v, skipped", option.PullRequestRepo, err)
+				f.Hooks.unchanged(cmd.destination())
+				err = nil
+			}
+			if err != nil {
+				logger.Printf("%s: %v", option.PullRequestRepo, err)
+				f.Hooks.failed(cmd.destination(), err)
+			}
+			result := Result{Repository: option.PullRequestRepo, Head: cmd.options.CommitBranch, URL: pr.URL, Unchanged: unchanged, Err: err, Verification: cmd.verification}
+			if len(cmd.parts) > 0 {
+				result.Head, result.URL, result.Parts = cmd.parts[0].Head, cmd.parts[0].URL, cmd.parts[1:]
+			}
+
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if skippable(err) {
+				return nil
+			}
+
+			return err
+		})
+	}()
+
+	return results, nil
+}
+
+// prefetch looks up the destinations at once when the provider supports it.
+// Failures are only logged, the lookups being made again one by one.
+func (f *BatchPullRequestCommand) prefetch(ctx context.Context, logger Logger) {
+	p, ok := f.provider.(provider.Prefetcher)
+	if !ok {
+		return
+	}
+
+	queries := make([]provider.PrefetchQuery, 0, len(f.options.Destinations))
+	for _, v := range f.options.Destinations {
+		branches := []string{v.Base}
+		if !f.options.Render {
+			// rendered heads are only known per destination.
+			branches = append(branches, f.options.Head)
+		}
+		queries = append(queries, provider.PrefetchQuery{Repository: v.Repository, Branches: branches})
+	}
+
+	if err := p.Prefetch(ctx, f.options.OwnerOrDefault(), queries); err != nil {
+		logger.Printf("unable to prefetch the destinations: %v", err)
+	}
+}
+
+func (f *BatchPullRequestCommand) logger() Logger {
+	if f.Logger == nil {
+		return nopLogger{}
+	}
+
+	return f.Logger
+}
+
+// safeDo runs the BeforeDestination hook and creates the pull request,
+// recovering from panics so a destination cannot stop the rest of the batch.
+func (f *pullRequestCommand) safeDo(ctx context.Context) (pr provider.PullRequest, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			pr, err = provider.PullRequest{}, &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	if err := f.hooks.beforeDestination(ctx, f.destination()); err != nil {
+		return provider.PullRequest{}, err
+	}
+
+	return f.do(ctx)
+}
+
+func (f *pullRequestCommand) do(ctx context.Context) (provider.PullRequest, error) {
+	if f.options.TemplateData != nil {
+		if err := f.render(); err != nil {
+			return provider.PullRequest{}, err
+		}
+	}
+
+	// the files are ready before touching the repository, so destinations
+	// skipped for their content leave no branch behind.
+	files, err := f.getFiles(ctx)
+	if err != nil {
+		return provider.PullRequest{}, fmt.Errorf("unable to create the tree based on the provided files: %w", err)
+	}
+
+	fingerprint, err := f.checkFingerprint(files)
+	if err != nil {
+		return provider.PullRequest{}, err
+	}
+
+	// the fingerprint is keyed by the head before suffixing, which changes
+	// from run to run.
+	key := f.fingerprintKey()
+	if err := f.suffixHead(files, fingerprint); err != nil {
+		return provider.PullRequest{}, err
+	}
+
+	parts := [][]provider.File{files}
+	if f.options.Split != nil {
+		if parts, err = f.options.Split.split(files); err != nil {
+			return provider.PullRequest{}, err
+		}
+	}
+
+	if len(parts) == 1 {
+		pr, err := f.push(ctx, files)
+		if err != nil {
+			return provider.PullRequest{}, err
+		}
+		f.recordFingerprint(key, fingerprint)
+
+		return pr, nil
+	}
+
+	// every part is a pull request of its own from the base branch, the
+	// first one standing for the destination.
+	head, subject := f.options.CommitBranch, f.options.PullRequestSubject
+	f.logger.Printf("%s: splitting the change of %d file(s) into %d pull requests", f.options.PullRequestRepo, len(files), len(parts))
+	var first provider.PullRequest
+	for i, v := range parts {
+		f.options.CommitBranch = fmt.Sprintf("%s-part-%d", head, i+1)
+		f.options.PullRequestSubject = fmt.Sprintf("%s (%d/%d)", subject, i+1, len(parts))
+		pr, err := f.push(ctx, v)
+		if err != nil {
+			return provider.PullRequest{}, fmt.Errorf("part %d/%d: %w", i+1, len(parts), err)
+		}
+
+		if i == 0 {
+			first = pr
+		}
+		f.parts = append(f.parts, Part{Head: f.options.CommitBranch, URL: pr.URL})
+	}
+	f.recordFingerprint(key, fingerprint)
+
+	return first, nil
+}
+
+// push commits the files on the commit branch and opens the pull request from
+// it.
+func (f *pullRequestCommand) push(ctx context.Context, files []provider.File) (provider.PullRequest, error) {
+	sha, err := f.getRef(ctx)
+	if err != nil {
+		return provider.PullRequest{}, err
+	}
+	if sha == "" {
+		return provider.PullRequest{}, errors.New("no error where returned but the reference is empty")
+	}
+
+	commit, err := f.pushCommit(ctx, sha, files)
+	if err != nil {
+		return provider.PullRequest{}, fmt.Errorf("unable to create the commit: %w", err)
+	}
+	f.logger.Printf("%s: committed %d file(s) to %s as %s", f.options.SourceRepo, len(files), f.options.CommitBranch, commit)
+	f.hooks.commitPushed(f.destination(), commit)
+	f.verifyCommit(ctx, commit)
+	f.setCommitStatus(ctx, commit)
+
+	pr, err := f.createPR(ctx)
+	if err != nil {
+		return provider.PullRequest{}, err
+	}
+	f.logger.Printf("%s: created %s", f.options.PullRequestRepo, pr.URL)
+	f.label(ctx, pr, files)
+	f.requestReviews(ctx, pr)
+	f.hooks.prCreated(f.destination(), pr)
+
+	return pr, nil
+}
+
+// recordFingerprint records the fingerprint of the pushed change when the
+// batch is incremental. Failures are only logged, the change being pushed.
+func (f *pullRequestCommand) recordFingerprint(key, fingerprint string) {
+	if f.fingerprints == nil {
+		return
+	}
+
+	if err := f.fingerprints.SetFingerprint(key, fingerprint); err != nil {
+		f.logger.Printf("%s: unable to record the fingerprint: %v", f.options.PullRequestRepo, err)
+	}
+}
+
+// _headSuffixLength is the length of the suffixes of the head branches.
+const _headSuffixLength = 8
+
+// suffixHead appends the suffix asked by HeadSuffix to the head branch. hash
+// is the fingerprint of the files, computed from them when empty.
+func (f *pullRequestCommand) suffixHead(files []provider.File, hash string) error {
+	var suffix string
+	switch f.options.HeadSuffix {
+	case "":
+		return nil
+	case HeadSuffixRun:
+		if f.options.RunID == "" {
+			return errors.New("head_suffix run requires a run ID")
+		}
+		suffix = f.options.RunID
+	case HeadSuffixHash:
+		if hash == "" {
+			var err error
+			if hash, err = f.fingerprint(files); err != nil {
+				return fmt.Errorf("unable to compute the hash of the change: %w", err)
+			}
+		}
+		suffix = hash
+	}
+
+	if len(suffix) > _headSuffixLength {
+		suffix = suffix[:_headSuffixLength]
+	}
+	f.options.CommitBranch += "-" + suffix
+	return nil
+}
+
+// destination returns the destination the command creates the pull request
+// on.
+func (f *pullRequestCommand) destination() Destination {
+	return Destination{Repository: f.options.PullRequestRepo, Base: f.options.PullRequestBranch}
+}
+
+// getRef returns the SHA the commit branch points to if it exists, unless
+// HeadExists says otherwise, or creates it from the base branch before
+// returning it.
+func (f *pullRequestCommand) getRef(ctx context.Context) (string, error) {
+	sha, err := f.provider.GetRef(ctx, f.options.SourceOwner, f.options.SourceRepo, f.options.CommitBranch)
+	switch {
+	case errors.Is(err, provider.ErrBranchNotFound):
+		// created below.
+	case err != nil:
+		return "", fmt.Errorf("unable to get head ref: %w", err)
+	default:
+		f.logger.Printf("%s: branch %s found at %s", f.options.SourceRepo, f.options.CommitBranch, sha)
+		switch f.options.HeadExists {
+		case HeadExistsFail:
+			return "", fmt.Errorf("%w: %s in %s", ErrBranchExists, f.options.CommitBranch, f.options.SourceRepo)
+		case HeadExistsRecreate:
+			if err := f.deleteHead(ctx); err != nil {
+				return "", err
+			}
+		default:
+			return sha, nil
+		}
+	}
+
+	sha, err = f.provider.GetRef(ctx, f.options.SourceOwner, f.options.SourceRepo, f.options.BaseBranch)
+	if err != nil {
+		return "", fmt.Errorf("unable to get base ref: %w", err)
+	}
+
+	if err := f.provider.CreateRef(ctx, f.options.SourceOwner, f.options.SourceRepo, f.options.CommitBranch, sha); err != nil {
+		return "", err
+	}
+	f.logger.Printf("%s: branch %s created from %s at %s", f.options.SourceRepo, f.options.CommitBranch, f.options.BaseBranch, sha)
+
+	return sha, nil
+}
+
+// deleteHead deletes the commit branch so it is recreated from the base one.
+func (f *pullRequestCommand) deleteHead(ctx context.Context) error {
+	deleter, ok := f.provider.(provider.BranchDeleter)
+	if !ok {
+		return errors.New("the provider cannot delete branches, head_exists: recreate is not supported")
+	}
+
+	if err := deleter.DeleteRef(ctx, f.options.SourceOwner, f.options.SourceRepo, f.options.CommitBranch); err != nil {
+		return fmt.Errorf("unable to delete branch %s: %w", f.options.CommitBranch, err)
+	}
+	f.logger.Printf("%s: branch %s deleted to recreate it", f.options.SourceRepo, f.options.CommitBranch)
+
+	return nil
+}
+
+// getFiles loads, and renders and transforms when needed, the content of the
+// files to commit.
+func (f *pullRequestCommand) getFiles(ctx context.Context) ([]provider.File, error) {
+	attrs, err := f.gitAttributes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]provider.File, 0, len(f.options.Files))
+	for _, v := range f.options.Files {
+		streamed, ok, err := f.stream(v, attrs)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			files = append(files, streamed)
+			continue
+		}
+
+		file, content, err := f.getFileContent(v)
+		if err != nil {
+			return nil, err
+		}
+
+		// binary files, such as images, are committed as is.
+		binary := !provider.IsText(content)
+		endings := f.lineEndings(v)
+		if attrs != nil {
+			endings = attrs.lineEndingsFor(file, endings, content)
+		}
+
+		if binary && (f.options.TemplateData != nil || endings != "" && endings != LineEndingsPreserve) {
+			f.logger.Printf("%s: %s is binary, committed without rendering it nor converting its line endings", f.options.SourceRepo, v.Source)
+		}
+
+		if f.options.TemplateData != nil && !binary {
+			rendered, err := render(file, string(content), *f.options.TemplateData)
+			if err != nil {
+				return nil, fmt.Errorf("unable to render %s: %w", v.Source, err)
+			}
+
+			if !f.allowUnresolved(file) {
+				if err := checkResolved(file, rendered); err != nil {
+					return nil, err
+				}
+			}
+			content = []byte(rendered)
+		}
+
+		if len(v.Transforms) > 0 {
+			content, err = transform(ctx, content, v.Transforms, f.options.Transforms, f.transformContext(file))
+			if err != nil {
+				return nil, fmt.Errorf("unable to transform %s: %w", v.Source, err)
+			}
+		}
+
+		if !binary {
+			content = normalizeLineEndings(content, endings)
+		}
+		files = append(files, provider.File{Path: file, Content: content})
+	}
+
+	return files, nil
+}
+
+// transformContext returns the context of the transforms of the file at the
+// target path.
+func (f *pullRequestCommand) transformContext(path string) TransformContext {
+	return TransformContext{
+		TemplateData: TemplateData{
+			Owner:      f.options.SourceOwner,
+			Repository: f.options.SourceRepo,
+			Base:       f.options.BaseBranch,
+			Vars:       f.options.Vars,
+		},
+		Path: path,
+	}
+}
+
+// getFileContent loads the local content of a file and return the target name
+// of the file in the target repository and its contents, shared by every
+// destination.
+func (f *pullRequestCommand) getFileContent(file File) (targetName string, b []byte, err error) {
+	if file.Source == "" {
+		return "", nil, errors.New("empty files")
+	}
+
+	targetName = file.Target
+	if targetName == "" {
+		targetName = file.Source
+	}
+
+	if f.contents == nil {
+		b, err = ioutil.ReadFile(file.Source)
+		return targetName, b, err
+	}
+
+	b, err = f.contents.read(file.Source)
+	return targetName, b, err
+}
+
+// pushCommit commits the files on top of the given parent in the commit branch.
+func (f *pullRequestCommand) pushCommit(ctx context.Context, parent string, files []provider.File) (string, error) {
+	sha, err := f.provider.CreateCommit(ctx, f.options.SourceOwner, f.options.SourceRepo, provider.Commit{
+		Branch:  f.options.CommitBranch,
+		Parent:  parent,
+		Message: f.options.CommitMessage,
+		Author:  provider.User{Name: f.options.AuthorName, Email: f.options.AuthorEmail},
+		Files:   files,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to commit: %w", err)
+	}
+
+	return sha, nil
+}
+
+// verifyCommit looks up the signature verification of the pushed commit when
+// asked and supported. Failures are only logged, the commit being pushed.
+func (f *pullRequestCommand) verifyCommit(ctx context.Context, sha string) {
+	verifier, ok := f.provider.(provider.CommitVerifier)
+	if !f.options.VerifyCommits || !ok {
+		return
+	}
+
+	v, err := verifier.VerifyCommit(ctx, f.options.SourceOwner, f.options.SourceRepo, sha)
+	if err != nil {
+		f.logger.Printf("%s: unable to verify commit %s: %v", f.options.SourceRepo, sha, err)
+		return
+	}
+
+	f.logger.Printf("%s: commit %s verified: %t (%s)", f.options.SourceRepo, sha, v.Verified, v.Reason)
+	f.verification = &v
+}
+
+// setCommitStatus sets the configured status on the pushed commit when
+// supported. Failures are only logged, the status being informative.
+func (f *pullRequestCommand) setCommitStatus(ctx context.Context, sha string) {
+	setter, ok := f.provider.(provider.StatusSetter)
+	o := f.options.CommitStatus
+	if o == nil || !ok {
+		return
+	}
+
+	status := provider.CommitStatus{State: provider.StatusSuccess, Context: o.Context, Description: o.Description, TargetURL: o.TargetURL}
+	if status.Context == "" {
+		status.Context = "mkpr"
+	}
+
+	if err := setter.SetCommitStatus(ctx, f.options.SourceOwner, f.options.SourceRepo, sha, status); err != nil {
+		f.logger.Printf("%s: unable to set the status of commit %s: %v", f.options.SourceRepo, sha, err)
+		return
+	}
+	f.logger.Printf("%s: status %s set on commit %s", f.options.SourceRepo, status.Context, sha)
+}
+
+// RunMarker returns the hidden comment identifying the run in the body of the
+// pull requests it created.
+func RunMarker(id string) string {
+	return "<!-- mkpr-run: " + id + " -->"
+}
+
+// createPR creates a pull request, marking its body with the run ID when set.
+func (f *pullRequestCommand) createPR(ctx context.Context) (provider.PullRequest, error) {
+	body := f.options.PullRequestBody
+	if f.options.RunID != "" {
+		body += "\n\n" + RunMarker(f.options.RunID)
+	}
+
+	pr, err := f.provider.CreatePullRequest(ctx, f.options.PullRequestOwner, f.options.PullRequestRepo, provider.NewPullRequest{
+		Title: f.options.PullRequestSubject,
+		Head:  f.options.CommitBranch,
+		Base:  f.options.PullRequestBranch,
+		Body:  body,
+	})
+	if err != nil {
+		return provider.PullRequest{}, fmt.Errorf("unable to create PR: %w", err)
+	}
+
+	return pr, nil
+}
@@ -0,0 +1,427 @@
+package mkpr_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/mkpr"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider/fake"
+)
+
+const _owner = "acme"
+
+// writeFiles writes the files, content by target path, in a temporary
+// directory and returns them as the files of a batch.
+func writeFiles(t *testing.T, contents map[string]string) []mkpr.File {
+	t.Helper()
+
+	dir := t.TempDir()
+	var files []mkpr.File
+	for target, content := range contents {
+		source := filepath.Join(dir, filepath.FromSlash(target))
+		if err := os.MkdirAll(filepath.Dir(source), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(source, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, mkpr.File{Source: source, Target: target})
+	}
+
+	return files
+}
+
+// newBatch returns the options of a batch committing the files on the "lsc"
+// head branch of the repositories, against their main branch.
+func newBatch(files []mkpr.File, repositories ...string) mkpr.BatchPullRequestOption {
+	option := mkpr.BatchPullRequestOption{
+		CommitMessage: "chore: large scale change",
+		Subject:       "Large scale change",
+		Head:          "lsc",
+		Owner:         _owner,
+		Files:         files,
+	}
+	for _, v := range repositories {
+		option.Destinations = append(option.Destinations, mkpr.Destination{Repository: v, Base: "main"})
+	}
+
+	return option
+}
+
+func newProvider(repositories ...string) *fake.Provider {
+	p := fake.New(provider.User{Name: "bot", Email: "bot@example.com"})
+	for _, v := range repositories {
+		p.AddRepository(_owner, v, "main")
+	}
+
+	return p
+}
+
+func do(t *testing.T, p provider.Provider, option mkpr.BatchPullRequestOption, setup func(*mkpr.BatchPullRequestCommand)) ([]string, error) {
+	t.Helper()
+
+	cmd, err := mkpr.NewBatchPullRequestCommandWithProvider(p, option)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if setup != nil {
+		setup(cmd)
+	}
+
+	return cmd.Do(context.Background())
+}
+
+func TestHooks(t *testing.T) {
+	p := newProvider("api", "web")
+	errDown := errors.New("down")
+	p.Err = map[string]error{}
+
+	var events []string
+	record := func(cmd *mkpr.BatchPullRequestCommand) {
+		cmd.Hooks = mkpr.Hooks{
+			OnDestinationStart: func(d mkpr.Destination) { events = append(events, "start "+d.Repository) },
+			OnCommitPushed:     func(d mkpr.Destination, sha string) { events = append(events, "commit "+d.Repository) },
+			OnPRCreated: func(d mkpr.Destination, pr provider.PullRequest) {
+				events = append(events, "pr "+d.Repository)
+				// the next destination fails to open its pull request.
+				p.Err["CreatePullRequest"] = errDown
+			},
+			OnError: func(d mkpr.Destination, err error) {
+				if errors.Is(err, errDown) {
+					events = append(events, "error "+d.Repository)
+				}
+			},
+		}
+	}
+
+	if _, err := do(t, p, newBatch(writeFiles(t, map[string]string{"README.md": "hello\n"}), "api", "web"), record); !errors.Is(err, errDown) {
+		t.Fatalf("got error %v, want %v", err, errDown)
+	}
+
+	want := []string{"start api", "commit api", "pr api", "start web", "commit web", "error web"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("got events %v, want %v", events, want)
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	p := newProvider("api", "web", "worker", "jobs")
+	errFrozen := errors.New("frozen")
+	isolate := func(cmd *mkpr.BatchPullRequestCommand) {
+		cmd.Hooks.BeforeDestination = func(ctx context.Context, d mkpr.Destination) error {
+			switch d.Repository {
+			case "web":
+				return errFrozen
+			case "worker":
+				panic("unexpected response")
+			}
+			return nil
+		}
+	}
+
+	urls, err := do(t, p, newBatch(writeFiles(t, map[string]string{"README.md": "hello\n"}), "api", "web", "worker", "jobs"), isolate)
+
+	var batch *mkpr.BatchError
+	if !errors.As(err, &batch) {
+		t.Fatalf("got error %v, want a *BatchError", err)
+	}
+	if len(batch.Errors) != 2 || batch.Errors[0].Repository != "web" || batch.Errors[1].Repository != "worker" {
+		t.Fatalf("got destination errors %v, want web and worker", batch.Errors)
+	}
+	if !errors.Is(err, errFrozen) {
+		t.Errorf("got error %v, want it to wrap the error of the hook", err)
+	}
+
+	var panicked *mkpr.PanicError
+	if !errors.As(batch.Errors[1], &panicked) || panicked.Value != "unexpected response" {
+		t.Errorf("got error %v for worker, want a *PanicError", batch.Errors[1])
+	}
+
+	// the other destinations went on.
+	if len(urls) != 2 {
+		t.Errorf("got %d URL(s), want 2", len(urls))
+	}
+	for _, v := range []string{"web", "worker"} {
+		if repo, _ := p.Repository(_owner, v); len(repo.Commits) != 0 || len(repo.Branches) != 1 {
+			t.Errorf("%s: got %d commit(s) and %d branch(es), want none pushed", v, len(repo.Commits), len(repo.Branches))
+		}
+	}
+}
+
+// fingerprints records the fingerprints in memory.
+type fingerprints map[string]string
+
+func (f fingerprints) Fingerprint(key string) (string, error) {
+	return f[key], nil
+}
+
+func (f fingerprints) SetFingerprint(key, fingerprint string) error {
+	f[key] = fingerprint
+	return nil
+}
+
+func TestFingerprintsUnchanged(t *testing.T) {
+	p := newProvider("api", "web")
+	files := writeFiles(t, map[string]string{"README.md": "hello\n"})
+	fps := make(fingerprints)
+	incremental := func(cmd *mkpr.BatchPullRequestCommand) { cmd.Fingerprints = fps }
+
+	urls, err := do(t, p, newBatch(files, "api", "web"), incremental)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 || len(fps) != 2 {
+		t.Fatalf("got %d URL(s) and %d fingerprint(s), want 2 of each", len(urls), len(fps))
+	}
+
+	// a new destination is the only one pushed to.
+	p.AddRepository(_owner, "worker", "main")
+	cmd, err := mkpr.NewBatchPullRequestCommandWithProvider(p, newBatch(files, "api", "web", "worker"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	incremental(cmd)
+	var hooked []string
+	cmd.Hooks.OnUnchanged = func(d mkpr.Destination) { hooked = append(hooked, d.Repository) }
+
+	results, err := cmd.DoStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unchanged := make(map[string]bool)
+	for r := range results {
+		if r.Err != nil {
+			t.Fatalf("%s: %v", r.Repository, r.Err)
+		}
+		if r.Unchanged != (r.URL == "") {
+			t.Errorf("%s: unchanged %t with URL %q", r.Repository, r.Unchanged, r.URL)
+		}
+		unchanged[r.Repository] = r.Unchanged
+	}
+
+	if !unchanged["api"] || !unchanged["web"] || unchanged["worker"] {
+		t.Errorf("got unchanged destinations %v, want api and web", unchanged)
+	}
+	if want := []string{"api", "web"}; !reflect.DeepEqual(hooked, want) {
+		t.Errorf("got the unchanged hook run for %v, want %v", hooked, want)
+	}
+
+	for _, v := range []string{"api", "web"} {
+		if repo, _ := p.Repository(_owner, v); len(repo.Commits) != 1 {
+			t.Errorf("%s: got %d commit(s), want 1", v, len(repo.Commits))
+		}
+	}
+}
+
+func TestHeadExists(t *testing.T) {
+	tests := []struct {
+		name       string
+		headExists string
+		wantErr    error
+		wantParent func(base, head string) string // parent of the pushed commit.
+	}{
+		{name: "reuse", headExists: mkpr.HeadExistsReuse, wantParent: func(base, head string) string { return head }},
+		{name: "default", wantParent: func(base, head string) string { return head }},
+		{name: "fail", headExists: mkpr.HeadExistsFail, wantErr: mkpr.ErrBranchExists},
+		{name: "recreate", headExists: mkpr.HeadExistsRecreate, wantParent: func(base, head string) string { return base }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newProvider()
+			repo := p.AddRepository(_owner, "api", "main", "lsc")
+			base, head := repo.Branches["main"], repo.Branches["lsc"]
+
+			option := newBatch(writeFiles(t, map[string]string{"README.md": "hello\n"}), "api")
+			option.HeadExists = tt.headExists
+			urls, err := do(t, p, option, nil)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.Commits) != 0 || repo.Branches["lsc"] != head {
+					t.Errorf("the existing branch was changed: %d commit(s), head at %s", len(repo.Commits), repo.Branches["lsc"])
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(urls) != 1 || len(repo.Commits) != 1 {
+				t.Fatalf("got %d URL(s) and %d commit(s), want 1 of each", len(urls), len(repo.Commits))
+			}
+			if got, want := repo.Commits[0].Parent, tt.wantParent(base, head); got != want {
+				t.Errorf("got commit parent %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	p := newProvider("api")
+	option := newBatch(writeFiles(t, map[string]string{
+		"a/one.txt": "1\n",
+		"a/two.txt": "2\n",
+		"b/one.txt": "1\n",
+	}), "api")
+	option.Split = &mkpr.SplitOption{MaxFiles: 2}
+
+	cmd, err := mkpr.NewBatchPullRequestCommandWithProvider(p, option)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := cmd.DoStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []mkpr.Result
+	for r := range results {
+		got = append(got, r)
+	}
+	if len(got) != 1 || got[0].Err != nil {
+		t.Fatalf("got results %+v, want a single successful one", got)
+	}
+	if r := got[0]; r.Head != "lsc-part-1" || len(r.Parts) != 1 || r.Parts[0].Head != "lsc-part-2" {
+		t.Fatalf("got head %s and parts %+v, want lsc-part-1 then lsc-part-2", r.Head, r.Parts)
+	}
+
+	repo, _ := p.Repository(_owner, "api")
+	if len(repo.PullRequests) != 2 {
+		t.Fatalf("got %d pull request(s), want 2", len(repo.PullRequests))
+	}
+
+	// the files of a directory are kept together, in path order.
+	wantFiles := [][]string{{"a/one.txt", "a/two.txt"}, {"b/one.txt"}}
+	for i, pr := range repo.PullRequests {
+		if want := []string{"Large scale change (1/2)", "Large scale change (2/2)"}[i]; pr.Title != want {
+			t.Errorf("got title %q, want %q", pr.Title, want)
+		}
+
+		var paths []string
+		for _, v := range repo.Commits[i].Files {
+			paths = append(paths, v.Path)
+		}
+		if got, want := strings.Join(paths, ","), strings.Join(wantFiles[i], ","); got != want {
+			t.Errorf("part %d: got files %s, want %s", i+1, got, want)
+		}
+	}
+}
+
+func TestPullRequestExists(t *testing.T) {
+	p := newProvider("api")
+	option := newBatch(writeFiles(t, map[string]string{"README.md": "hello\n"}), "api")
+	if _, err := do(t, p, option, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the branch is reused, the pull request already being open.
+	_, err := do(t, p, option, nil)
+	if !errors.Is(err, mkpr.ErrPRExists) {
+		t.Fatalf("got error %v, want %v", err, mkpr.ErrPRExists)
+	}
+}
+
+// committed returns the content of the files of the only commit of the
+// repository, by path.
+func committed(t *testing.T, p *fake.Provider, repository string) map[string]string {
+	t.Helper()
+
+	repo, _ := p.Repository(_owner, repository)
+	if len(repo.Commits) != 1 {
+		t.Fatalf("got %d commit(s), want 1", len(repo.Commits))
+	}
+
+	contents := make(map[string]string)
+	for _, v := range repo.Commits[0].Files {
+		contents[v.Path] = string(v.Content)
+	}
+
+	return contents
+}
+
+func TestLineEndings(t *testing.T) {
+	p := newProvider("api")
+	files := writeFiles(t, map[string]string{
+		"batch.txt":    "a\r\nb\n",
+		"crlf.txt":     "a\r\nb\n",
+		"preserve.txt": "a\r\nb\n",
+		"image.bin":    "\x00\r\n\x01\n",
+	})
+	for i := range files {
+		switch files[i].Target {
+		case "crlf.txt":
+			files[i].LineEndings = mkpr.LineEndingsCRLF
+		case "preserve.txt":
+			files[i].LineEndings = mkpr.LineEndingsPreserve
+		}
+	}
+
+	option := newBatch(files, "api")
+	option.LineEndings = mkpr.LineEndingsLF
+	if _, err := do(t, p, option, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"batch.txt":    "a\nb\n",
+		"crlf.txt":     "a\r\nb\r\n",
+		"preserve.txt": "a\r\nb\n",
+		"image.bin":    "\x00\r\n\x01\n", // binary files are committed as is.
+	}
+	if got := committed(t, p, "api"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGitAttributes(t *testing.T) {
+	p := newProvider("api")
+	repo, _ := p.Repository(_owner, "api")
+	repo.Files = map[string][]byte{".gitattributes": []byte("*.bat eol=crlf\n*.dat binary\n")}
+
+	option := newBatch(writeFiles(t, map[string]string{
+		"build.bat":  "a\r\nb\r\n",
+		"table.dat":  "a\r\nb\n",
+		"README.txt": "a\nb\n",
+	}), "api")
+	option.GitAttributes = true
+	option.LineEndings = mkpr.LineEndingsCRLF
+	if _, err := do(t, p, option, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the repository stores the text files with LF, the checkout converting
+	// them to the eol attribute.
+	want := map[string]string{
+		"build.bat":  "a\nb\n",
+		"table.dat":  "a\r\nb\n",
+		"README.txt": "a\r\nb\r\n",
+	}
+	if got := committed(t, p, "api"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHeadLookupError(t *testing.T) {
+	p := newProvider("api")
+	errDown := errors.New("down")
+	p.Err = map[string]error{"GetRef": errDown}
+
+	// only a missing head is created, the other failures stop the destination.
+	if _, err := do(t, p, newBatch(writeFiles(t, map[string]string{"README.md": "hello\n"}), "api"), nil); !errors.Is(err, errDown) {
+		t.Fatalf("got error %v, want %v", err, errDown)
+	}
+	if repo, _ := p.Repository(_owner, "api"); len(repo.Branches) != 1 {
+		t.Errorf("got %d branch(es), want the head not created", len(repo.Branches))
+	}
+}
@@ -0,0 +1,110 @@
+package mkpr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// PreflightError reports the missing token scopes and the destinations the
+// authenticated user cannot push to, found before creating any pull request.
+type PreflightError struct {
+	MissingScopes []string
+	Destinations  []*DestinationError
+}
+
+func (e *PreflightError) Error() string {
+	var b strings.Builder
+	b.WriteString("preflight verification failed")
+	if len(e.MissingScopes) > 0 {
+		fmt.Fprintf(&b, "\n  token is missing the scopes: %s", strings.Join(e.MissingScopes, ", "))
+	}
+
+	for _, v := range e.Destinations {
+		fmt.Fprintf(&b, "\n  %s", v.Error())
+	}
+
+	return b.String()
+}
+
+// Is reports whether any of the destinations failed with target.
+func (e *PreflightError) Is(target error) bool {
+	for _, v := range e.Destinations {
+		if errors.Is(v, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// _narrowerScopes are the scopes accepted in place of a required one. A token
+// with public_repo only sees the public repositories, so the private
+// destinations fail the push access verification instead.
+var _narrowerScopes = map[string]string{"repo": "public_repo"}
+
+// preflight verifies the token scopes, when the provider reports them, and the
+// push access to every destination. Fine-grained and GitHub App tokens report
+// no scopes, their permissions only showing in the push access.
+func (f *BatchPullRequestCommand) preflight(ctx context.Context, u provider.User) error {
+	var report PreflightError
+	if u.Scopes != nil {
+		report.MissingScopes = missingScopes(u.Scopes, f.requiredScopes())
+	}
+
+	for _, v := range f.options.Destinations {
+		ok, err := f.provider.CanPush(ctx, f.options.OwnerOrDefault(), v.Repository)
+		if err != nil {
+			report.Destinations = append(report.Destinations, &DestinationError{Repository: v.Repository, Err: err})
+			continue
+		}
+
+		if !ok {
+			report.Destinations = append(report.Destinations, &DestinationError{Repository: v.Repository, Err: ErrNoPushAccess})
+		}
+	}
+
+	if len(report.MissingScopes) > 0 || len(report.Destinations) > 0 {
+		return &report
+	}
+
+	return nil
+}
+
+// requiredScopes returns the classic token scopes needed to commit the files,
+// changes to GitHub Actions workflows requiring the workflow scope.
+func (f *BatchPullRequestCommand) requiredScopes() []string {
+	scopes := []string{"repo"}
+	for _, v := range f.options.Files {
+		target := v.Target
+		if target == "" {
+			target = v.Source
+		}
+
+		if strings.HasPrefix(target, ".github/workflows/") {
+			return append(scopes, "workflow")
+		}
+	}
+
+	return scopes
+}
+
+// missingScopes returns the required scopes not granted, nor any narrower one.
+func missingScopes(scopes, required []string) []string {
+	granted := make(map[string]bool)
+	for _, v := range scopes {
+		granted[v] = true
+	}
+
+	var missing []string
+	for _, v := range required {
+		if !granted[v] && !granted[_narrowerScopes[v]] {
+			missing = append(missing, v)
+		}
+	}
+
+	return missing
+}
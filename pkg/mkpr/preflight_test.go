@@ -0,0 +1,26 @@
+package mkpr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissingScopes(t *testing.T) {
+	tests := []struct {
+		name             string
+		granted, missing []string
+	}{
+		{name: "granted", granted: []string{"repo", "workflow"}},
+		{name: "public repositories", granted: []string{"public_repo", "workflow"}},
+		{name: "missing", granted: []string{"read:org"}, missing: []string{"repo", "workflow"}},
+		{name: "none", granted: []string{}, missing: []string{"repo", "workflow"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := missingScopes(tt.granted, []string{"repo", "workflow"}); !reflect.DeepEqual(got, tt.missing) {
+				t.Errorf("got %v, want %v", got, tt.missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package mkpr
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// Providers supported by BatchPullRequestOption.Provider, on top of the ones
+// registered with provider.Register.
+const (
+	ProviderGitHub          = "github"
+	ProviderGitHubGraphQL   = "github-graphql"
+	ProviderGitLab          = "gitlab"
+	ProviderBitbucket       = "bitbucket"
+	ProviderBitbucketServer = "bitbucket-server"
+	ProviderAzure           = "azure"
+	ProviderGitea           = "gitea"
+)
+
+// Host returns the host of the provider instance, for instance, to look up
+// its credentials.
+func (b BatchPullRequestOption) Host() string {
+	rawURL, host := b.ProviderURL, ""
+	switch b.Provider {
+	case "", ProviderGitHub, ProviderGitHubGraphQL:
+		rawURL, host = b.GitHubURL, "github.com"
+	case ProviderGitLab:
+		host = "gitlab.com"
+	case ProviderBitbucket:
+		host = "bitbucket.org"
+	}
+
+	if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+
+	return host
+}
+
+// BasicAuth reports whether the provider expects the token through basic
+// authentication, with an empty user name, instead of as a bearer token.
+func (b BatchPullRequestOption) BasicAuth() bool {
+	return b.Provider == ProviderAzure
+}
+
+// NewProvider returns the provider selected by the options, authenticating
+// with tc. It allows wrapping it, for instance, in a dry run provider.
+func NewProvider(tc *http.Client, options BatchPullRequestOption) (provider.Provider, error) {
+	switch options.Provider {
+	case "", ProviderGitHub:
+		client, err := provider.NewGitHubClient(tc, options.GitHubURL, options.UploadURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub URL: %w", err)
+		}
+		return provider.NewGitHub(client), nil
+	case ProviderGitHubGraphQL:
+		client, err := provider.NewGitHubClient(tc, options.GitHubURL, options.UploadURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub URL: %w", err)
+		}
+		return provider.NewGitHubGraphQL(client), nil
+	case ProviderGitLab:
+		return provider.NewGitLab(tc, options.ProviderURL)
+	case ProviderBitbucket:
+		return provider.NewBitbucketCloud(tc, options.ProviderURL), nil
+	case ProviderBitbucketServer:
+		return provider.NewBitbucketServer(tc, options.ProviderURL)
+	case ProviderAzure:
+		return provider.NewAzure(tc, options.ProviderURL)
+	case ProviderGitea:
+		return provider.NewGitea(tc, options.ProviderURL)
+	}
+
+	f, ok := provider.Lookup(options.Provider)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", options.Provider)
+	}
+
+	return f(tc, options.ProviderURL)
+}
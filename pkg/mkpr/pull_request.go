@@ -0,0 +1,74 @@
+package mkpr
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// PullRequestOptions describes a pull request on a single repository.
+type PullRequestOptions struct {
+	Owner         string // owner (user or org) of the repository.
+	Repository    string
+	Base          string // branch the pull request is merged into.
+	Head          string // branch holding the commit, created from Base when missing.
+	CommitMessage string
+	Subject       string
+	Body          string
+	Files         []File
+
+	// Author of the commit, the authenticated user when empty.
+	Author provider.User
+
+	// When not nil, files, commit message, subject, body and head are rendered
+	// with it, skipping the target paths of AllowUnresolved when verifying the
+	// rendered content.
+	TemplateData    *TemplateData
+	AllowUnresolved []string
+}
+
+// CreatePullRequest commits the files on the head branch of the repository and
+// opens a pull request from it, without the batch machinery: no preflight
+// verification, delay nor hooks.
+func CreatePullRequest(ctx context.Context, p provider.Provider, o PullRequestOptions) (provider.PullRequest, error) {
+	switch {
+	case o.Head == "":
+		return provider.PullRequest{}, errors.New("head branch cannot be empty")
+	case o.Head == o.Base:
+		return provider.PullRequest{}, errors.New("base branch cannot be the same as head")
+	}
+
+	author := o.Author
+	if author.Name == "" {
+		u, err := p.CurrentUser(ctx)
+		if err != nil {
+			return provider.PullRequest{}, err
+		}
+		author = u
+	}
+
+	cmd := pullRequestCommand{
+		options: pullRequestCreationOptions{
+			SourceOwner:        o.Owner,
+			PullRequestOwner:   o.Owner,
+			SourceRepo:         o.Repository,
+			BaseBranch:         o.Base,
+			CommitMessage:      o.CommitMessage,
+			CommitBranch:       o.Head,
+			PullRequestRepo:    o.Repository,
+			PullRequestBranch:  o.Base,
+			PullRequestSubject: o.Subject,
+			PullRequestBody:    o.Body,
+			Files:              o.Files,
+			AuthorName:         author.Name,
+			AuthorEmail:        author.Email,
+			TemplateData:       o.TemplateData,
+			AllowUnresolved:    o.AllowUnresolved,
+		},
+		provider: p,
+		logger:   nopLogger{},
+	}
+
+	return cmd.do(ctx)
+}
@@ -0,0 +1,111 @@
+package mkpr
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data available to the templates rendered for each
+// destination when rendering is enabled.
+type TemplateData struct {
+	Owner      string            // owner of the destination repository.
+	Repository string            // name of the destination repository.
+	Base       string            // branch the pull request is created against.
+	Vars       map[string]string // user defined variables.
+}
+
+// _unresolvedMarkers are the leftovers of a template that was not fully
+// rendered: raw opening delimiters, the conventional marker of variables the
+// user must supply and the output of text/template for missing keys. Closing
+// braces are left out, being common in rendered code such as nested maps.
+var _unresolvedMarkers = []string{"{{", "<UNSET>", "<no value>"}
+
+// render executes text as a template with the given data.
+func render(name, text string, data interface{}) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// Render executes text as a template with the data of a destination, or of a
+// struct embedding it, and verifies the result like the batch does, returning
+// an *UnresolvedPlaceholderError when it still holds any unresolved marker. It
+// lets other tools template their own content, such as issues, per
+// destination.
+func Render(name, text string, data interface{}) (string, error) {
+	rendered, err := render(name, text, data)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkResolved(name, rendered); err != nil {
+		return "", err
+	}
+
+	return rendered, nil
+}
+
+// checkResolved returns an *UnresolvedPlaceholderError when the rendered text
+// still holds any unresolved marker.
+func checkResolved(name, rendered string) error {
+	for _, marker := range _unresolvedMarkers {
+		if i := strings.Index(rendered, marker); i >= 0 {
+			return &UnresolvedPlaceholderError{
+				Name:   name,
+				Marker: marker,
+				Line:   strings.Count(rendered[:i], "\n") + 1,
+			}
+		}
+	}
+
+	return nil
+}
+
+// render renders the commit message, pull request subject and body and the
+// head branch of the destination in place.
+func (f *pullRequestCommand) render() error {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"commit_message", &f.options.CommitMessage},
+		{"subject", &f.options.PullRequestSubject},
+		{"body", &f.options.PullRequestBody},
+		{"head", &f.options.CommitBranch},
+	}
+
+	for _, v := range fields {
+		rendered, err := render(v.name, *v.value, *f.options.TemplateData)
+		if err != nil {
+			return err
+		}
+
+		if err := checkResolved(v.name, rendered); err != nil {
+			return err
+		}
+		*v.value = rendered
+	}
+
+	return nil
+}
+
+// allowUnresolved reports whether the rendered content of the target path is
+// exempt from the unresolved markers verification.
+func (f *pullRequestCommand) allowUnresolved(target string) bool {
+	for _, v := range f.options.AllowUnresolved {
+		if v == target {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,35 @@
+package mkpr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckResolved(t *testing.T) {
+	tests := []struct {
+		name, rendered, wantMarker string
+	}{
+		{name: "resolved", rendered: "name: api\n"},
+		{name: "closing braces", rendered: "x := map[string]map[string]int{\"a\": {\"b\": 1}}\n"},
+		{name: "raw delimiter", rendered: "name: {{ .Name\n", wantMarker: "{{"},
+		{name: "unset variable", rendered: "owner: <UNSET>\n", wantMarker: "<UNSET>"},
+		{name: "missing key", rendered: "owner: <no value>\n", wantMarker: "<no value>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkResolved("config.yml", tt.rendered)
+			if tt.wantMarker == "" {
+				if err != nil {
+					t.Errorf("got error %v, want none", err)
+				}
+				return
+			}
+
+			var unresolved *UnresolvedPlaceholderError
+			if !errors.As(err, &unresolved) || unresolved.Marker != tt.wantMarker {
+				t.Errorf("got error %v, want the %q marker", err, tt.wantMarker)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package mkpr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// Groupings of the files of a split change.
+const (
+	SplitByDirectory = "directory" // keeps the files of a directory together when they fit.
+	SplitByFiles     = "files"     // fills the parts with the files in path order.
+)
+
+// SplitOption splits the change of a destination exceeding its thresholds into
+// several pull requests, each within them, so every one stays reviewable. The
+// parts are committed on the head branch followed by "-part-<n>" and their
+// subjects end with "(n/total)". A single file exceeding the thresholds is a
+// part of its own.
+type SplitOption struct {
+	MaxFiles int    `yaml:"max_files"` // files per pull request, unlimited when 0.
+	MaxLines int    `yaml:"max_lines"` // lines of the committed files per pull request, unlimited when 0.
+	By       string `yaml:"by"`        // SplitByDirectory, the default, or SplitByFiles.
+}
+
+// Part is one of the pull requests of a split change.
+type Part struct {
+	Head string
+	URL  string
+}
+
+func (o SplitOption) validate() error {
+	switch {
+	case o.MaxFiles < 0 || o.MaxLines < 0:
+		return errors.New("split thresholds cannot be negative")
+	case o.MaxFiles == 0 && o.MaxLines == 0:
+		return errors.New("split needs max_files or max_lines")
+	}
+
+	switch o.By {
+	case "", SplitByDirectory, SplitByFiles:
+	default:
+		return fmt.Errorf("invalid split by %q, expected directory or files", o.By)
+	}
+
+	return nil
+}
+
+// exceeds reports whether a part of the given size exceeds the thresholds.
+func (o SplitOption) exceeds(files, lines int) bool {
+	return o.MaxFiles > 0 && files > o.MaxFiles || o.MaxLines > 0 && lines > o.MaxLines
+}
+
+// chunk is a set of files along with their number of lines.
+type chunk struct {
+	files []provider.File
+	lines int
+}
+
+func (c *chunk) add(o chunk) {
+	c.files = append(c.files, o.files...)
+	c.lines += o.lines
+}
+
+// split returns the parts of the change, a single one when it is within the
+// thresholds.
+func (o SplitOption) split(files []provider.File) ([][]provider.File, error) {
+	sorted := make([]provider.File, len(files))
+	copy(sorted, files)
+	sort.Slice(sorted, func(i, j int) bool {
+		if a, b := path.Dir(sorted[i].Path), path.Dir(sorted[j].Path); o.By != SplitByFiles && a != b {
+			return a < b
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+
+	// groups of files are kept together when they fit, in path order.
+	var (
+		groups [][]chunk
+		total  int
+	)
+	for _, v := range sorted {
+		lines, err := countLines(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to count the lines of %s: %w", v.Path, err)
+		}
+		total += lines
+
+		file := chunk{files: []provider.File{v}, lines: lines}
+		last := len(groups) - 1
+		if o.By == SplitByFiles || last < 0 || path.Dir(v.Path) != path.Dir(groups[last][0].files[0].Path) {
+			groups = append(groups, []chunk{file})
+			continue
+		}
+		groups[last] = append(groups[last], file)
+	}
+
+	if !o.exceeds(len(files), total) {
+		return [][]provider.File{files}, nil
+	}
+
+	var (
+		parts   [][]provider.File
+		current chunk
+	)
+	flush := func() {
+		if len(current.files) > 0 {
+			parts = append(parts, current.files)
+			current = chunk{}
+		}
+	}
+	fill := func(c chunk) {
+		if o.exceeds(len(current.files)+len(c.files), current.lines+c.lines) {
+			flush()
+		}
+		current.add(c)
+	}
+
+	for _, g := range groups {
+		var whole chunk
+		for _, v := range g {
+			whole.add(v)
+		}
+
+		if !o.exceeds(len(whole.files), whole.lines) {
+			fill(whole)
+			continue
+		}
+
+		// a directory too large for a part is split by files.
+		flush()
+		for _, v := range g {
+			fill(v)
+		}
+		flush()
+	}
+	flush()
+
+	return parts, nil
+}
+
+// countLines returns the number of lines of the content of the file, streaming
+// it when needed.
+func countLines(file provider.File) (int, error) {
+	if file.Content != nil || file.Open == nil {
+		return lineCount(file.Content), nil
+	}
+
+	r, err := file.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	var (
+		n       int
+		partial bool // the last line has no trailing newline.
+		buf     = make([]byte, 32*1024)
+	)
+	for {
+		read, err := r.Read(buf)
+		if read > 0 {
+			n += bytes.Count(buf[:read], []byte{'\n'})
+			partial = buf[read-1] != '\n'
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if partial {
+		n++
+	}
+
+	return n, nil
+}
+
+// lineCount returns the number of lines of the content, the last one counting
+// even without a trailing newline.
+func lineCount(content []byte) int {
+	n := bytes.Count(content, []byte{'\n'})
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		n++
+	}
+
+	return n
+}
@@ -0,0 +1,99 @@
+package mkpr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// TransformRef applies the transform registered by Name to the content of a
+// file, with the given arguments.
+type TransformRef struct {
+	Name string            `yaml:"name"`
+	Args map[string]string `yaml:"args"`
+}
+
+// TransformContext is the destination and the file a transform is applied for.
+type TransformContext struct {
+	TemplateData                   // destination and user defined variables.
+	Path         string            // target path of the file.
+	Args         map[string]string // arguments of the transform in the config.
+}
+
+// Transform changes the content of a file for a destination.
+type Transform interface {
+	Apply(ctx context.Context, in []byte, tc TransformContext) ([]byte, error)
+}
+
+// TransformFunc adapts a function to the Transform interface.
+type TransformFunc func(ctx context.Context, in []byte, tc TransformContext) ([]byte, error)
+
+func (f TransformFunc) Apply(ctx context.Context, in []byte, tc TransformContext) ([]byte, error) {
+	return f(ctx, in, tc)
+}
+
+var (
+	_transformsMu sync.RWMutex
+	_transforms   = map[string]Transform{
+		"regex": TransformFunc(regexTransform),
+	}
+)
+
+// RegisterTransform makes a transform available by name to the files of the
+// configs. It panics if a transform is registered twice under the same name.
+func RegisterTransform(name string, t Transform) {
+	_transformsMu.Lock()
+	defer _transformsMu.Unlock()
+	if _, ok := _transforms[name]; ok {
+		panic(fmt.Sprintf("transform %s registered twice", name))
+	}
+	_transforms[name] = t
+}
+
+// LookupTransform returns the transform registered by name.
+func LookupTransform(name string) (Transform, bool) {
+	_transformsMu.RLock()
+	defer _transformsMu.RUnlock()
+	t, ok := _transforms[name]
+	return t, ok
+}
+
+// transform applies the transforms of a file in order, the external ones
+// declared in the config taking precedence over the registered ones.
+func transform(ctx context.Context, content []byte, refs []TransformRef, external map[string]ExternalTransform, tc TransformContext) ([]byte, error) {
+	for _, v := range refs {
+		var t Transform
+		if e, ok := external[v.Name]; ok {
+			t = e
+		} else if t, ok = LookupTransform(v.Name); !ok {
+			return nil, fmt.Errorf("unknown transform %q", v.Name)
+		}
+
+		tc.Args = v.Args
+		out, err := t.Apply(ctx, content, tc)
+		if err != nil {
+			return nil, fmt.Errorf("unable to apply transform %s: %w", v.Name, err)
+		}
+		content = out
+	}
+
+	return content, nil
+}
+
+// regexTransform replaces the matches of the "pattern" argument with the
+// "replace" one, which can refer to submatches as $1 or ${name}.
+func regexTransform(_ context.Context, in []byte, tc TransformContext) ([]byte, error) {
+	pattern, ok := tc.Args["pattern"]
+	if !ok {
+		return nil, errors.New("missing pattern argument")
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return re.ReplaceAll(in, []byte(tc.Args["replace"])), nil
+}
@@ -0,0 +1,316 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// azureAPIVersion is the version of the Azure DevOps REST API used.
+const azureAPIVersion = "7.0"
+
+// azureGitContribute is the GenericContribute permission of the Git
+// Repositories security namespace, required to push.
+const (
+	azureGitNamespace  = "2e9eb7ed-3c0a-47d4-87c1-0ffdcd7b3ff7"
+	azureGitContribute = 4
+)
+
+// Azure creates pull requests through the Azure Repos REST API. The
+// base URL is the organization (or collection) URL and owners are projects.
+type Azure struct {
+	rest restClient
+}
+
+// NewAzure returns a provider for the organization at baseURL, for
+// instance, https://dev.azure.com/my-org. The personal access token is sent
+// with basic authentication by tc.
+func NewAzure(tc *http.Client, baseURL string) (*Azure, error) {
+	if baseURL == "" {
+		return nil, errors.New("the organization URL is required by Azure Repos")
+	}
+
+	return &Azure{rest: restClient{client: tc, baseURL: strings.TrimSuffix(baseURL, "/")}}, nil
+}
+
+func (p *Azure) repository(owner, repo string) string {
+	return url.PathEscape(owner) + "/_apis/git/repositories/" + url.PathEscape(repo)
+}
+
+// query returns the encoded values with the API version.
+func (p *Azure) query(values url.Values) string {
+	if values == nil {
+		values = url.Values{}
+	}
+	values.Set("api-version", azureAPIVersion)
+	return "?" + values.Encode()
+}
+
+func (p *Azure) CurrentUser(ctx context.Context) (User, error) {
+	var data struct {
+		AuthenticatedUser struct {
+			ProviderDisplayName string `json:"providerDisplayName"`
+			Properties          struct {
+				Account struct {
+					Value string `json:"$value"`
+				} `json:"Account"`
+			} `json:"properties"`
+		} `json:"authenticatedUser"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, "_apis/connectionData", nil, &data); err != nil {
+		return User{}, err
+	}
+
+	return User{Name: data.AuthenticatedUser.ProviderDisplayName, Email: data.AuthenticatedUser.Properties.Account.Value}, nil
+}
+
+func (p *Azure) CanPush(ctx context.Context, owner, repo string) (bool, error) {
+	var r struct {
+		ID      string `json:"id"`
+		Project struct {
+			ID string `json:"id"`
+		} `json:"project"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+p.query(nil), nil, &r); err != nil {
+		return false, err
+	}
+
+	var permissions struct {
+		Value []bool `json:"value"`
+	}
+	query := p.query(url.Values{"tokens": {"repoV2/" + r.Project.ID + "/" + r.ID}})
+	if err := p.rest.do(ctx, http.MethodGet, fmt.Sprintf("_apis/permissions/%s/%d%s", azureGitNamespace, azureGitContribute, query), nil, &permissions); err != nil {
+		return false, err
+	}
+
+	return len(permissions.Value) > 0 && permissions.Value[0], nil
+}
+
+func (p *Azure) GetRef(ctx context.Context, owner, repo, branch string) (string, error) {
+	var refs struct {
+		Value []struct {
+			Name     string `json:"name"`
+			ObjectID string `json:"objectId"`
+		} `json:"value"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/refs"+p.query(url.Values{"filter": {"heads/" + branch}}), nil, &refs); err != nil {
+		return "", err
+	}
+
+	for _, v := range refs.Value {
+		if v.Name == "refs/heads/"+branch {
+			return v.ObjectID, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrBranchNotFound, branch)
+}
+
+func (p *Azure) CreateRef(ctx context.Context, owner, repo, branch, sha string) error {
+	in := []map[string]string{{
+		"name":        "refs/heads/" + branch,
+		"oldObjectId": strings.Repeat("0", 40),
+		"newObjectId": sha,
+	}}
+	err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/refs"+p.query(nil), in, nil)
+	return classify(err, ErrBranchExists, http.StatusConflict, "")
+}
+
+// CreateCommit pushes a single commit adding or editing each file depending on
+// whether it exists on the branch.
+func (p *Azure) CreateCommit(ctx context.Context, owner, repo string, c Commit) (string, error) {
+	changes := make([]map[string]interface{}, 0, len(c.Files))
+	for _, v := range c.Files {
+		path := "/" + strings.TrimPrefix(v.Path, "/")
+		exists, err := p.fileExists(ctx, owner, repo, c.Branch, path)
+		if err != nil {
+			return "", err
+		}
+
+		content, err := v.Bytes()
+		if err != nil {
+			return "", err
+		}
+
+		changeType := "add"
+		if exists {
+			changeType = "edit"
+		}
+
+		changes = append(changes, map[string]interface{}{
+			"changeType": changeType,
+			"item":       map[string]string{"path": path},
+			"newContent": map[string]string{
+				"content":     base64.StdEncoding.EncodeToString(content),
+				"contentType": "base64encoded",
+			},
+		})
+	}
+
+	in := map[string]interface{}{
+		"refUpdates": []map[string]string{{"name": "refs/heads/" + c.Branch, "oldObjectId": c.Parent}},
+		"commits": []map[string]interface{}{{
+			"comment": c.Message,
+			"author":  map[string]string{"name": c.Author.Name, "email": c.Author.Email},
+			"changes": changes,
+		}},
+	}
+
+	var out struct {
+		Commits []struct {
+			CommitID string `json:"commitId"`
+		} `json:"commits"`
+	}
+	if err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/pushes"+p.query(nil), in, &out); err != nil {
+		return "", err
+	}
+
+	if len(out.Commits) == 0 {
+		return "", nil
+	}
+
+	return out.Commits[0].CommitID, nil
+}
+
+func (p *Azure) fileExists(ctx context.Context, owner, repo, branch, path string) (bool, error) {
+	query := p.query(url.Values{
+		"path":                          {path},
+		"versionDescriptor.version":     {branch},
+		"versionDescriptor.versionType": {"branch"},
+	})
+	err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/items"+query, nil, nil)
+	switch {
+	case err == nil:
+		return true, nil
+	case isStatus(err, http.StatusNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (p *Azure) CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (PullRequest, error) {
+	in := map[string]string{
+		"sourceRefName": "refs/heads/" + pr.Head,
+		"targetRefName": "refs/heads/" + pr.Base,
+		"title":         pr.Title,
+		"description":   pr.Body,
+	}
+
+	var out azurePullRequest
+	if err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/pullrequests"+p.query(nil), in, &out); err != nil {
+		return PullRequest{}, classify(err, ErrPullRequestExists, http.StatusConflict, "")
+	}
+
+	return out.pullRequest(), nil
+}
+
+// _azureStates maps the pull request states to the Azure Repos ones.
+var _azureStates = map[string]string{
+	StateOpen:   "active",
+	StateClosed: "abandoned",
+	StateMerged: "completed",
+	StateAll:    "all",
+}
+
+func (p *Azure) ListPullRequests(ctx context.Context, owner, repo string, opt ListOptions) ([]PullRequest, error) {
+	const top = 100
+	query := url.Values{"searchCriteria.status": {_azureStates[opt.state()]}, "$top": {strconv.Itoa(top)}}
+	if opt.Head != "" {
+		query.Set("searchCriteria.sourceRefName", "refs/heads/"+opt.Head)
+	}
+
+	if opt.Base != "" {
+		query.Set("searchCriteria.targetRefName", "refs/heads/"+opt.Base)
+	}
+
+	var pulls []PullRequest
+	for skip := 0; ; skip += top {
+		query.Set("$skip", strconv.Itoa(skip))
+		var page struct {
+			Value []azurePullRequest `json:"value"`
+		}
+		if err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/pullrequests"+p.query(query), nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, v := range page.Value {
+			pulls = append(pulls, v.pullRequest())
+		}
+
+		if len(page.Value) < top {
+			return pulls, nil
+		}
+	}
+}
+
+// _azureStrategies maps the merge methods to the merge strategies.
+var _azureStrategies = map[MergeMethod]string{
+	MergeCommit: "noFastForward",
+	MergeSquash: "squash",
+	MergeRebase: "rebase",
+}
+
+// MergePullRequest completes the pull request at its last merge source
+// commit, as required by Azure Repos.
+func (p *Azure) MergePullRequest(ctx context.Context, owner, repo string, number int, method MergeMethod) error {
+	path := p.repository(owner, repo) + "/pullrequests/" + strconv.Itoa(number) + p.query(nil)
+	var pr struct {
+		LastMergeSourceCommit struct {
+			CommitID string `json:"commitId"`
+		} `json:"lastMergeSourceCommit"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, path, nil, &pr); err != nil {
+		return err
+	}
+
+	completion := make(map[string]string)
+	if strategy, ok := _azureStrategies[method]; ok {
+		completion["mergeStrategy"] = strategy
+	}
+
+	in := map[string]interface{}{
+		"status":                "completed",
+		"lastMergeSourceCommit": map[string]string{"commitId": pr.LastMergeSourceCommit.CommitID},
+		"completionOptions":     completion,
+	}
+	return p.rest.do(ctx, http.MethodPatch, path, in, nil)
+}
+
+type azurePullRequest struct {
+	PullRequestID int       `json:"pullRequestId"`
+	Title         string    `json:"title"`
+	Status        string    `json:"status"`
+	SourceRefName string    `json:"sourceRefName"`
+	TargetRefName string    `json:"targetRefName"`
+	CreationDate  time.Time `json:"creationDate"`
+	Repository    struct {
+		WebURL string `json:"webUrl"`
+	} `json:"repository"`
+}
+
+func (pr azurePullRequest) pullRequest() PullRequest {
+	state := StateClosed
+	switch pr.Status {
+	case "active":
+		state = StateOpen
+	case "completed":
+		state = StateMerged
+	}
+
+	return PullRequest{
+		Number:    pr.PullRequestID,
+		URL:       fmt.Sprintf("%s/pullrequest/%d", pr.Repository.WebURL, pr.PullRequestID),
+		Title:     pr.Title,
+		State:     state,
+		Head:      strings.TrimPrefix(pr.SourceRefName, "refs/heads/"),
+		Base:      strings.TrimPrefix(pr.TargetRefName, "refs/heads/"),
+		CreatedAt: pr.CreationDate,
+	}
+}
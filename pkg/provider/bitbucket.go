@@ -0,0 +1,543 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BitbucketCloud creates pull requests through the Bitbucket Cloud
+// REST API. Owners are workspaces.
+type BitbucketCloud struct {
+	rest restClient
+}
+
+// NewBitbucketCloud returns a provider for the Bitbucket Cloud API at
+// baseURL, api.bitbucket.org when empty. The access token is sent as a bearer
+// token by tc.
+func NewBitbucketCloud(tc *http.Client, baseURL string) *BitbucketCloud {
+	if baseURL == "" {
+		baseURL = "https://api.bitbucket.org/2.0"
+	}
+
+	return &BitbucketCloud{rest: restClient{client: tc, baseURL: strings.TrimSuffix(baseURL, "/")}}
+}
+
+func (p *BitbucketCloud) repository(owner, repo string) string {
+	return "repositories/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+func (p *BitbucketCloud) CurrentUser(ctx context.Context) (User, error) {
+	var u struct {
+		DisplayName string `json:"display_name"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, "user", nil, &u); err != nil {
+		return User{}, err
+	}
+
+	var emails struct {
+		Values []struct {
+			Email     string `json:"email"`
+			IsPrimary bool   `json:"is_primary"`
+		} `json:"values"`
+	}
+	// the email scope is optional, commits are authored with the name only.
+	_ = p.rest.do(ctx, http.MethodGet, "user/emails", nil, &emails)
+
+	current := User{Name: u.DisplayName}
+	for _, v := range emails.Values {
+		if v.IsPrimary {
+			current.Email = v.Email
+		}
+	}
+
+	return current, nil
+}
+
+func (p *BitbucketCloud) CanPush(ctx context.Context, owner, repo string) (bool, error) {
+	query := url.Values{"q": {fmt.Sprintf("repository.full_name=%q", owner+"/"+repo)}}
+	var permissions struct {
+		Values []struct {
+			Permission string `json:"permission"`
+		} `json:"values"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, "user/permissions/repositories?"+query.Encode(), nil, &permissions); err != nil {
+		return false, err
+	}
+
+	for _, v := range permissions.Values {
+		if v.Permission == "write" || v.Permission == "admin" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (p *BitbucketCloud) GetRef(ctx context.Context, owner, repo, branch string) (string, error) {
+	var b struct {
+		Target struct {
+			Hash string `json:"hash"`
+		} `json:"target"`
+	}
+	err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/refs/branches/"+url.PathEscape(branch), nil, &b)
+	return b.Target.Hash, classify(err, ErrBranchNotFound, http.StatusNotFound, "")
+}
+
+func (p *BitbucketCloud) CreateRef(ctx context.Context, owner, repo, branch, sha string) error {
+	in := map[string]interface{}{
+		"name":   branch,
+		"target": map[string]string{"hash": sha},
+	}
+	err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/refs/branches", in, nil)
+	return classify(err, ErrBranchExists, http.StatusBadRequest, "already exists")
+}
+
+// CreateCommit uploads the files through the src endpoint, which commits them all
+// on top of the parent commit.
+func (p *BitbucketCloud) CreateCommit(ctx context.Context, owner, repo string, c Commit) (string, error) {
+	author := c.Author.Name
+	if c.Author.Email != "" {
+		author += " <" + c.Author.Email + ">"
+	}
+
+	fields := []formField{
+		{Name: "message", Value: []byte(c.Message)},
+		{Name: "branch", Value: []byte(c.Branch)},
+		{Name: "parents", Value: []byte(c.Parent)},
+		{Name: "author", Value: []byte(author)},
+	}
+	for _, v := range c.Files {
+		content, err := v.Bytes()
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, formField{Name: v.Path, Value: content, File: true})
+	}
+
+	if err := p.rest.form(ctx, http.MethodPost, p.repository(owner, repo)+"/src", fields, nil); err != nil {
+		return "", err
+	}
+
+	// the new commit is only given in the Location header of the response.
+	return p.GetRef(ctx, owner, repo, c.Branch)
+}
+
+func (p *BitbucketCloud) CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (PullRequest, error) {
+	in := map[string]interface{}{
+		"title":       pr.Title,
+		"description": pr.Body,
+		"source":      map[string]interface{}{"branch": map[string]string{"name": pr.Head}},
+		"destination": map[string]interface{}{"branch": map[string]string{"name": pr.Base}},
+	}
+
+	var out bitbucketCloudPullRequest
+	if err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/pullrequests", in, &out); err != nil {
+		return PullRequest{}, classify(err, ErrPullRequestExists, http.StatusBadRequest, "already")
+	}
+
+	return out.pullRequest(), nil
+}
+
+// _bitbucketCloudStates maps the pull request states to the Bitbucket Cloud
+// ones.
+var _bitbucketCloudStates = map[string][]string{
+	StateOpen:   {"OPEN"},
+	StateClosed: {"DECLINED", "SUPERSEDED"},
+	StateMerged: {"MERGED"},
+	StateAll:    {"OPEN", "DECLINED", "SUPERSEDED", "MERGED"},
+}
+
+func (p *BitbucketCloud) ListPullRequests(ctx context.Context, owner, repo string, opt ListOptions) ([]PullRequest, error) {
+	query := url.Values{"state": _bitbucketCloudStates[opt.state()], "pagelen": {"50"}}
+	var filters []string
+	if opt.Head != "" {
+		filters = append(filters, fmt.Sprintf("source.branch.name=%q", opt.Head))
+	}
+
+	if opt.Base != "" {
+		filters = append(filters, fmt.Sprintf("destination.branch.name=%q", opt.Base))
+	}
+
+	if len(filters) > 0 {
+		query.Set("q", strings.Join(filters, " AND "))
+	}
+
+	var pulls []PullRequest
+	next := p.repository(owner, repo) + "/pullrequests?" + query.Encode()
+	for next != "" {
+		var page struct {
+			Values []bitbucketCloudPullRequest `json:"values"`
+			Next   string                      `json:"next"`
+		}
+		if err := p.rest.do(ctx, http.MethodGet, next, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, v := range page.Values {
+			pulls = append(pulls, v.pullRequest())
+		}
+		next = page.Next
+	}
+
+	return pulls, nil
+}
+
+// _bitbucketCloudStrategies maps the merge methods to the merge strategies.
+var _bitbucketCloudStrategies = map[MergeMethod]string{
+	MergeCommit: "merge_commit",
+	MergeSquash: "squash",
+	MergeRebase: "fast_forward",
+}
+
+func (p *BitbucketCloud) MergePullRequest(ctx context.Context, owner, repo string, number int, method MergeMethod) error {
+	in := make(map[string]string)
+	if strategy, ok := _bitbucketCloudStrategies[method]; ok {
+		in["merge_strategy"] = strategy
+	}
+
+	return p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/pullrequests/"+strconv.Itoa(number)+"/merge", in, nil)
+}
+
+type bitbucketCloudPullRequest struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Source struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"source"`
+	Destination struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"destination"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+	CreatedOn time.Time `json:"created_on"`
+}
+
+func (pr bitbucketCloudPullRequest) pullRequest() PullRequest {
+	state := StateClosed
+	switch pr.State {
+	case "OPEN":
+		state = StateOpen
+	case "MERGED":
+		state = StateMerged
+	}
+
+	return PullRequest{
+		Number:    pr.ID,
+		URL:       pr.Links.HTML.Href,
+		Title:     pr.Title,
+		State:     state,
+		Head:      pr.Source.Branch.Name,
+		Base:      pr.Destination.Branch.Name,
+		CreatedAt: pr.CreatedOn,
+	}
+}
+
+// BitbucketServer creates pull requests through the REST API of
+// Bitbucket Server and Data Center. Owners are project keys.
+type BitbucketServer struct {
+	rest restClient
+}
+
+// NewBitbucketServer returns a provider for the instance at baseURL.
+// The personal access token is sent as a bearer token by tc.
+func NewBitbucketServer(tc *http.Client, baseURL string) (*BitbucketServer, error) {
+	if baseURL == "" {
+		return nil, errors.New("the instance URL is required by Bitbucket Server")
+	}
+
+	return &BitbucketServer{rest: restClient{client: tc, baseURL: strings.TrimSuffix(baseURL, "/") + "/rest"}}, nil
+}
+
+func (p *BitbucketServer) repository(owner, repo string) string {
+	return "api/1.0/projects/" + url.PathEscape(owner) + "/repos/" + url.PathEscape(repo)
+}
+
+func (p *BitbucketServer) CurrentUser(ctx context.Context) (User, error) {
+	// the whoami endpoint of the web application returns the user slug.
+	slug, err := p.whoami(ctx)
+	if err != nil {
+		return User{}, err
+	}
+
+	var u struct {
+		DisplayName  string `json:"displayName"`
+		EmailAddress string `json:"emailAddress"`
+	}
+	err = p.rest.do(ctx, http.MethodGet, "api/1.0/users/"+url.PathEscape(slug), nil, &u)
+	return User{Name: u.DisplayName, Email: u.EmailAddress}, err
+}
+
+func (p *BitbucketServer) whoami(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.rest.baseURL, "/rest")+"/plugins/servlet/applinks/whoami", http.NoBody)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.rest.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	slug := resp.Header.Get("X-Ausername")
+	if slug == "" {
+		return "", fmt.Errorf("unable to get the authenticated user: %s", resp.Status)
+	}
+
+	return slug, nil
+}
+
+func (p *BitbucketServer) CanPush(ctx context.Context, owner, repo string) (bool, error) {
+	query := url.Values{"projectkey": {owner}, "name": {repo}, "permission": {"REPO_WRITE"}}
+	var repos struct {
+		Values []struct {
+			Slug string `json:"slug"`
+		} `json:"values"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, "api/1.0/repos?"+query.Encode(), nil, &repos); err != nil {
+		return false, err
+	}
+
+	for _, v := range repos.Values {
+		if strings.EqualFold(v.Slug, repo) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetRef pages through the branches matching the name, which is matched as a
+// substring, for the exact one, boosted to the first page when it exists.
+func (p *BitbucketServer) GetRef(ctx context.Context, owner, repo, branch string) (string, error) {
+	query := url.Values{"filterText": {branch}, "boostMatches": {"true"}, "limit": {"100"}}
+	for start := 0; ; {
+		query.Set("start", strconv.Itoa(start))
+		var page struct {
+			Values []struct {
+				ID           string `json:"id"`
+				LatestCommit string `json:"latestCommit"`
+			} `json:"values"`
+			IsLastPage    bool `json:"isLastPage"`
+			NextPageStart int  `json:"nextPageStart"`
+		}
+		if err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/branches?"+query.Encode(), nil, &page); err != nil {
+			return "", err
+		}
+
+		for _, v := range page.Values {
+			if v.ID == "refs/heads/"+branch {
+				return v.LatestCommit, nil
+			}
+		}
+
+		if page.IsLastPage {
+			return "", fmt.Errorf("%w: %s", ErrBranchNotFound, branch)
+		}
+		start = page.NextPageStart
+	}
+}
+
+func (p *BitbucketServer) CreateRef(ctx context.Context, owner, repo, branch, sha string) error {
+	in := map[string]string{"name": branch, "startPoint": sha}
+	err := p.rest.do(ctx, http.MethodPost, "branch-utils/1.0/projects/"+url.PathEscape(owner)+"/repos/"+url.PathEscape(repo)+"/branches", in, nil)
+	return classify(err, ErrBranchExists, http.StatusConflict, "")
+}
+
+// CreateCommit edits one file at a time as Bitbucket Server has no API to commit
+// several files at once, so the change is made of one commit per file.
+func (p *BitbucketServer) CreateCommit(ctx context.Context, owner, repo string, c Commit) (string, error) {
+	head := c.Parent
+	for _, v := range c.Files {
+		exists, err := p.fileExists(ctx, owner, repo, c.Branch, v.Path)
+		if err != nil {
+			return "", err
+		}
+
+		content, err := v.Bytes()
+		if err != nil {
+			return "", err
+		}
+
+		fields := []formField{
+			{Name: "branch", Value: []byte(c.Branch)},
+			{Name: "message", Value: []byte(c.Message)},
+			{Name: "content", Value: content, File: true},
+		}
+		if exists {
+			fields = append(fields, formField{Name: "sourceCommitId", Value: []byte(head)})
+		}
+
+		var out struct {
+			ID string `json:"id"`
+		}
+		if err := p.rest.form(ctx, http.MethodPut, p.repository(owner, repo)+"/browse/"+escapePath(v.Path), fields, &out); err != nil {
+			return "", err
+		}
+		head = out.ID
+	}
+
+	return head, nil
+}
+
+func (p *BitbucketServer) fileExists(ctx context.Context, owner, repo, branch, path string) (bool, error) {
+	query := url.Values{"at": {"refs/heads/" + branch}}
+	err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/browse/"+escapePath(path)+"?"+query.Encode(), nil, nil)
+	switch {
+	case err == nil:
+		return true, nil
+	case isStatus(err, http.StatusNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (p *BitbucketServer) CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (PullRequest, error) {
+	in := map[string]interface{}{
+		"title":       pr.Title,
+		"description": pr.Body,
+		"fromRef":     map[string]string{"id": "refs/heads/" + pr.Head},
+		"toRef":       map[string]string{"id": "refs/heads/" + pr.Base},
+	}
+
+	var out bitbucketServerPullRequest
+	if err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/pull-requests", in, &out); err != nil {
+		return PullRequest{}, classify(err, ErrPullRequestExists, http.StatusConflict, "")
+	}
+
+	return out.pullRequest(), nil
+}
+
+// _bitbucketServerStates maps the pull request states to the Bitbucket
+// Server ones.
+var _bitbucketServerStates = map[string]string{
+	StateOpen:   "OPEN",
+	StateClosed: "DECLINED",
+	StateMerged: "MERGED",
+	StateAll:    "ALL",
+}
+
+func (p *BitbucketServer) ListPullRequests(ctx context.Context, owner, repo string, opt ListOptions) ([]PullRequest, error) {
+	query := url.Values{"state": {_bitbucketServerStates[opt.state()]}, "limit": {"100"}}
+	if opt.Base != "" {
+		query.Set("at", "refs/heads/"+opt.Base)
+	}
+
+	var pulls []PullRequest
+	for start := 0; ; {
+		query.Set("start", strconv.Itoa(start))
+		var page struct {
+			Values        []bitbucketServerPullRequest `json:"values"`
+			IsLastPage    bool                         `json:"isLastPage"`
+			NextPageStart int                          `json:"nextPageStart"`
+		}
+		if err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/pull-requests?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, v := range page.Values {
+			if pr := v.pullRequest(); opt.Matches(pr) {
+				pulls = append(pulls, pr)
+			}
+		}
+
+		if page.IsLastPage {
+			return pulls, nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+// _bitbucketServerStrategies maps the merge methods to the merge strategies.
+var _bitbucketServerStrategies = map[MergeMethod]string{
+	MergeCommit: "no-ff",
+	MergeSquash: "squash",
+	MergeRebase: "rebase-no-ff",
+}
+
+// MergePullRequest merges the current version of the pull request, which is
+// required by Bitbucket Server to prevent merging unseen changes.
+func (p *BitbucketServer) MergePullRequest(ctx context.Context, owner, repo string, number int, method MergeMethod) error {
+	path := p.repository(owner, repo) + "/pull-requests/" + strconv.Itoa(number)
+	var pr bitbucketServerPullRequest
+	if err := p.rest.do(ctx, http.MethodGet, path, nil, &pr); err != nil {
+		return err
+	}
+
+	in := make(map[string]string)
+	if strategy, ok := _bitbucketServerStrategies[method]; ok {
+		in["strategyId"] = strategy
+	}
+
+	query := url.Values{"version": {strconv.Itoa(pr.Version)}}
+	return p.rest.do(ctx, http.MethodPost, path+"/merge?"+query.Encode(), in, nil)
+}
+
+type bitbucketServerPullRequest struct {
+	ID      int    `json:"id"`
+	Version int    `json:"version"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	FromRef struct {
+		DisplayID string `json:"displayId"`
+	} `json:"fromRef"`
+	ToRef struct {
+		DisplayID string `json:"displayId"`
+	} `json:"toRef"`
+	CreatedDate int64 `json:"createdDate"` // in milliseconds.
+	Links       struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+func (pr bitbucketServerPullRequest) pullRequest() PullRequest {
+	state := StateClosed
+	switch pr.State {
+	case "OPEN":
+		state = StateOpen
+	case "MERGED":
+		state = StateMerged
+	}
+
+	var prURL string
+	if len(pr.Links.Self) > 0 {
+		prURL = pr.Links.Self[0].Href
+	}
+
+	return PullRequest{
+		Number:    pr.ID,
+		URL:       prURL,
+		Title:     pr.Title,
+		State:     state,
+		Head:      pr.FromRef.DisplayID,
+		Base:      pr.ToRef.DisplayID,
+		CreatedAt: time.Unix(0, pr.CreatedDate*int64(time.Millisecond)),
+	}
+}
+
+// escapePath escapes each segment of a repository path.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// BranchDeleter is implemented by the providers able to delete branches, for
+// instance, to recreate a stale head branch or clean up after a batch.
+type BranchDeleter interface {
+	DeleteRef(ctx context.Context, owner, repo, branch string) error
+}
+
+func (p *GitHub) DeleteRef(ctx context.Context, owner, repo, branch string) error {
+	_, err := p.client.Git.DeleteRef(ctx, owner, repo, "heads/"+branch)
+	return classifyGitHub(err, nil)
+}
+
+// DeleteRef deletes the branch, forgetting its prefetched SHA.
+func (p *GitHubGraphQL) DeleteRef(ctx context.Context, owner, repo, branch string) error {
+	if err := p.GitHub.DeleteRef(ctx, owner, repo, branch); err != nil {
+		return err
+	}
+
+	p.cacheRef(owner, repo, branch, "")
+	return nil
+}
+
+func (p *GitLab) DeleteRef(ctx context.Context, owner, repo, branch string) error {
+	return p.rest.do(ctx, http.MethodDelete, p.project(owner, repo)+"/repository/branches/"+url.PathEscape(branch), nil, nil)
+}
+
+func (p *Gitea) DeleteRef(ctx context.Context, owner, repo, branch string) error {
+	return p.rest.do(ctx, http.MethodDelete, p.repository(owner, repo)+"/branches/"+escapePath(branch), nil, nil)
+}
+
+func (p *BitbucketCloud) DeleteRef(ctx context.Context, owner, repo, branch string) error {
+	return p.rest.do(ctx, http.MethodDelete, p.repository(owner, repo)+"/refs/branches/"+escapePath(branch), nil, nil)
+}
+
+// DeleteRef updates the branch to the zero object ID, which Azure Repos
+// requires along with its current one.
+func (p *Azure) DeleteRef(ctx context.Context, owner, repo, branch string) error {
+	sha, err := p.GetRef(ctx, owner, repo, branch)
+	if err != nil {
+		return err
+	}
+
+	in := []map[string]string{{
+		"name":        "refs/heads/" + branch,
+		"oldObjectId": sha,
+		"newObjectId": strings.Repeat("0", 40),
+	}}
+	return p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/refs"+p.query(nil), in, nil)
+}
+
+var (
+	_ BranchDeleter = (*GitHub)(nil)
+	_ BranchDeleter = (*GitHubGraphQL)(nil)
+	_ BranchDeleter = (*GitLab)(nil)
+	_ BranchDeleter = (*Gitea)(nil)
+	_ BranchDeleter = (*BitbucketCloud)(nil)
+	_ BranchDeleter = (*Azure)(nil)
+)
@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/google/go-github/github"
+)
+
+// PullRequestCloser is implemented by the providers able to close pull
+// requests without merging them, for instance, to abandon a rollout.
+type PullRequestCloser interface {
+	ClosePullRequest(ctx context.Context, owner, repo string, number int) error
+}
+
+func (p *GitHub) ClosePullRequest(ctx context.Context, owner, repo string, number int) error {
+	_, _, err := p.client.PullRequests.Edit(ctx, owner, repo, number, &github.PullRequest{State: github.String("closed")})
+	return classifyGitHub(err, nil)
+}
+
+func (p *GitLab) ClosePullRequest(ctx context.Context, owner, repo string, number int) error {
+	in := map[string]string{"state_event": "close"}
+	return p.rest.do(ctx, http.MethodPut, p.project(owner, repo)+"/merge_requests/"+strconv.Itoa(number), in, nil)
+}
+
+func (p *Gitea) ClosePullRequest(ctx context.Context, owner, repo string, number int) error {
+	in := map[string]string{"state": "closed"}
+	return p.rest.do(ctx, http.MethodPatch, p.repository(owner, repo)+"/pulls/"+strconv.Itoa(number), in, nil)
+}
+
+// ClosePullRequest declines the pull request, Bitbucket Cloud's closing.
+func (p *BitbucketCloud) ClosePullRequest(ctx context.Context, owner, repo string, number int) error {
+	return p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/pullrequests/"+strconv.Itoa(number)+"/decline", nil, nil)
+}
+
+// ClosePullRequest abandons the pull request, Azure Repos' closing.
+func (p *Azure) ClosePullRequest(ctx context.Context, owner, repo string, number int) error {
+	in := map[string]string{"status": "abandoned"}
+	return p.rest.do(ctx, http.MethodPatch, p.repository(owner, repo)+"/pullrequests/"+strconv.Itoa(number)+p.query(nil), in, nil)
+}
+
+var (
+	_ PullRequestCloser = (*GitHub)(nil)
+	_ PullRequestCloser = (*GitHubGraphQL)(nil)
+	_ PullRequestCloser = (*GitLab)(nil)
+	_ PullRequestCloser = (*Gitea)(nil)
+	_ PullRequestCloser = (*BitbucketCloud)(nil)
+	_ PullRequestCloser = (*Azure)(nil)
+)
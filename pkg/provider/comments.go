@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/google/go-github/github"
+)
+
+// Commenter is implemented by the providers able to comment on issues and
+// pull requests, which share their numbers on these hosts.
+type Commenter interface {
+	CreateComment(ctx context.Context, owner, repo string, number int, body string) error
+}
+
+func (p *GitHub) CreateComment(ctx context.Context, owner, repo string, number int, body string) error {
+	_, _, err := p.client.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: &body})
+	return classifyGitHub(err, nil)
+}
+
+func (p *Gitea) CreateComment(ctx context.Context, owner, repo string, number int, body string) error {
+	in := map[string]string{"body": body}
+	return p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/issues/"+strconv.Itoa(number)+"/comments", in, nil)
+}
+
+var (
+	_ Commenter = (*GitHub)(nil)
+	_ Commenter = (*GitHubGraphQL)(nil)
+	_ Commenter = (*Gitea)(nil)
+)
@@ -0,0 +1,206 @@
+// Package dryrun implements a provider.Provider recording the changes a batch
+// would make instead of making them, to preview it.
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// Kinds of the recorded operations.
+const (
+	KindCreateRef   = "create-ref"
+	KindDeleteRef   = "delete-ref"
+	KindCommit      = "commit"
+	KindPullRequest = "pull-request"
+	KindMerge       = "merge"
+	KindLabel       = "label"
+	KindReviewers   = "reviewers"
+)
+
+// Operation is a change the batch would have made.
+type Operation struct {
+	Kind  string
+	Owner string
+	Repo  string
+
+	Branch      string                   // KindCreateRef and KindDeleteRef.
+	SHA         string                   // KindCreateRef, commit the branch would point to.
+	Commit      *provider.Commit         // KindCommit.
+	PullRequest *provider.NewPullRequest // KindPullRequest.
+	Number      int                      // KindMerge, KindLabel and KindReviewers.
+	Method      provider.MergeMethod     // KindMerge.
+	Labels      []string                 // KindLabel.
+	Reviewers   []string                 // KindReviewers.
+}
+
+// Provider reads through the wrapped provider, so the access to the
+// repositories is verified, and records the writes. Branches created and
+// commits made are visible to later reads of the same provider.
+type Provider struct {
+	reader provider.Provider
+
+	mu      sync.Mutex
+	ops     []Operation
+	refs    map[string]string // SHA of the recorded branches by owner/repo/branch.
+	commits int
+}
+
+// New returns a dry run provider reading from p.
+func New(p provider.Provider) *Provider {
+	return &Provider{reader: p, refs: make(map[string]string)}
+}
+
+// Operations returns the recorded operations, in order.
+func (p *Provider) Operations() []Operation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Operation(nil), p.ops...)
+}
+
+// Render writes the recorded operations in a human readable form.
+func (p *Provider) Render(w io.Writer) error {
+	for _, v := range p.Operations() {
+		var err error
+		repo := v.Owner + "/" + v.Repo
+		switch v.Kind {
+		case KindCreateRef:
+			_, err = fmt.Fprintf(w, "%s: create branch %s at %s\n", repo, v.Branch, v.SHA)
+		case KindDeleteRef:
+			_, err = fmt.Fprintf(w, "%s: delete branch %s\n", repo, v.Branch)
+		case KindCommit:
+			_, err = fmt.Fprintf(w, "%s: commit %q to %s\n", repo, v.Commit.Message, v.Commit.Branch)
+			for _, f := range v.Commit.Files {
+				if err == nil {
+					_, err = fmt.Fprintf(w, "    %s (%d bytes)\n", f.Path, f.Len())
+				}
+			}
+		case KindPullRequest:
+			_, err = fmt.Fprintf(w, "%s: open pull request %q from %s to %s\n", repo, v.PullRequest.Title, v.PullRequest.Head, v.PullRequest.Base)
+		case KindMerge:
+			_, err = fmt.Fprintf(w, "%s: merge pull request #%d\n", repo, v.Number)
+		case KindLabel:
+			_, err = fmt.Fprintf(w, "%s: label pull request with %s\n", repo, strings.Join(v.Labels, ", "))
+		case KindReviewers:
+			_, err = fmt.Fprintf(w, "%s: request reviews from %s\n", repo, strings.Join(v.Reviewers, ", "))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) CurrentUser(ctx context.Context) (provider.User, error) {
+	return p.reader.CurrentUser(ctx)
+}
+
+func (p *Provider) CanPush(ctx context.Context, owner, repo string) (bool, error) {
+	return p.reader.CanPush(ctx, owner, repo)
+}
+
+func (p *Provider) GetRef(ctx context.Context, owner, repo, branch string) (string, error) {
+	p.mu.Lock()
+	sha, ok := p.refs[owner+"/"+repo+"/"+branch]
+	p.mu.Unlock()
+	switch {
+	case ok && sha == "":
+		return "", fmt.Errorf("%w: %s deleted by the dry run", provider.ErrBranchNotFound, branch)
+	case ok:
+		return sha, nil
+	}
+
+	return p.reader.GetRef(ctx, owner, repo, branch)
+}
+
+func (p *Provider) CreateRef(ctx context.Context, owner, repo, branch, sha string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refs[owner+"/"+repo+"/"+branch] = sha
+	p.ops = append(p.ops, Operation{Kind: KindCreateRef, Owner: owner, Repo: repo, Branch: branch, SHA: sha})
+	return nil
+}
+
+// DeleteRef records the deletion, the branch being missing for the later
+// reads of the provider.
+func (p *Provider) DeleteRef(ctx context.Context, owner, repo, branch string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refs[owner+"/"+repo+"/"+branch] = ""
+	p.ops = append(p.ops, Operation{Kind: KindDeleteRef, Owner: owner, Repo: repo, Branch: branch})
+	return nil
+}
+
+func (p *Provider) CreateCommit(ctx context.Context, owner, repo string, c provider.Commit) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.commits++
+	sha := fmt.Sprintf("dry-run-%d", p.commits)
+	p.refs[owner+"/"+repo+"/"+c.Branch] = sha
+	p.ops = append(p.ops, Operation{Kind: KindCommit, Owner: owner, Repo: repo, Commit: &c})
+	return sha, nil
+}
+
+// CreatePullRequest records the pull request, which is returned without number
+// nor URL.
+func (p *Provider) CreatePullRequest(ctx context.Context, owner, repo string, pr provider.NewPullRequest) (provider.PullRequest, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ops = append(p.ops, Operation{Kind: KindPullRequest, Owner: owner, Repo: repo, PullRequest: &pr})
+	return provider.PullRequest{Title: pr.Title, State: provider.StateOpen, Head: pr.Head, Base: pr.Base}, nil
+}
+
+func (p *Provider) ListPullRequests(ctx context.Context, owner, repo string, opt provider.ListOptions) ([]provider.PullRequest, error) {
+	return p.reader.ListPullRequests(ctx, owner, repo, opt)
+}
+
+// GetFile reads through the wrapped provider, files being reported missing
+// when it cannot read them.
+func (p *Provider) GetFile(ctx context.Context, owner, repo, branch, path string) ([]byte, error) {
+	r, ok := p.reader.(provider.FileReader)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, provider.ErrFileNotFound)
+	}
+
+	return r.GetFile(ctx, owner, repo, branch, path)
+}
+
+func (p *Provider) MergePullRequest(ctx context.Context, owner, repo string, number int, method provider.MergeMethod) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ops = append(p.ops, Operation{Kind: KindMerge, Owner: owner, Repo: repo, Number: number, Method: method})
+	return nil
+}
+
+// AddLabels records the labels of the pull request, whose number is the zero
+// one returned by CreatePullRequest.
+func (p *Provider) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ops = append(p.ops, Operation{Kind: KindLabel, Owner: owner, Repo: repo, Number: number, Labels: labels})
+	return nil
+}
+
+// RequestReviewers records the reviewers of the pull request, whose number is
+// the zero one returned by CreatePullRequest.
+func (p *Provider) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ops = append(p.ops, Operation{Kind: KindReviewers, Owner: owner, Repo: repo, Number: number, Reviewers: reviewers})
+	return nil
+}
+
+var (
+	_ provider.Provider        = (*Provider)(nil)
+	_ provider.FileReader      = (*Provider)(nil)
+	_ provider.BranchDeleter   = (*Provider)(nil)
+	_ provider.Labeler         = (*Provider)(nil)
+	_ provider.ReviewRequester = (*Provider)(nil)
+)
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// Failure categories of the provider operations, to be checked with errors.Is.
+// The original error is still available through errors.As.
+var (
+	ErrBranchExists      = errors.New("branch already exists")
+	ErrPullRequestExists = errors.New("pull request already exists")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrFileNotFound      = errors.New("file not found")
+	ErrBranchNotFound    = errors.New("branch not found")
+)
+
+// kindError categorizes err as one of the failure categories.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() error {
+	return e.err
+}
+
+func (e *kindError) Is(target error) bool {
+	return target == e.kind
+}
+
+// classify categorizes err as kind when it is a *StatusError with the given
+// status code and, if text is not empty, a body containing it.
+func classify(err error, kind error, code int, text string) error {
+	var e *StatusError
+	if !errors.As(err, &e) || e.StatusCode != code {
+		return err
+	}
+
+	if text != "" && !strings.Contains(strings.ToLower(e.Body), text) {
+		return err
+	}
+
+	return &kindError{kind: kind, err: err}
+}
+
+// classifyGitHub categorizes the errors of the GitHub client, kind being the
+// category of the validation failures reporting that something already exists.
+func classifyGitHub(err error, kind error) error {
+	var (
+		rate  *github.RateLimitError
+		abuse *github.AbuseRateLimitError
+		resp  *github.ErrorResponse
+	)
+
+	switch {
+	case errors.As(err, &rate), errors.As(err, &abuse):
+		return &kindError{kind: ErrRateLimited, err: err}
+	case kind != nil && errors.As(err, &resp) && resp.Response != nil &&
+		resp.Response.StatusCode == http.StatusUnprocessableEntity && strings.Contains(resp.Error(), "already exists"):
+		return &kindError{kind: kind, err: err}
+	default:
+		return err
+	}
+}
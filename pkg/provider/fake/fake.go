@@ -0,0 +1,314 @@
+// Package fake implements an in-memory provider.Provider to test the batch
+// engine, or a config, without a hosting service.
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sorfino/go-toolkit-cmd/pkg/provider"
+)
+
+// ErrNotFound is returned for unknown repositories, branches and pull requests,
+// GetRef returning provider.ErrBranchNotFound instead.
+var ErrNotFound = errors.New("not found")
+
+// Repository is the state of a fake repository.
+type Repository struct {
+	Branches     map[string]string // SHA of the head of each branch.
+	Commits      []provider.Commit // commits created, in order.
+	PullRequests []provider.PullRequest
+	ReadOnly     bool              // CanPush reports false when set.
+	Files        map[string][]byte // content by path, the same on every branch, read by GetFile.
+}
+
+// Provider is an in-memory provider. Repositories must be added before being
+// used, and every commit gets a new sequential SHA.
+type Provider struct {
+	User provider.User // returned by CurrentUser.
+
+	// Err, when set, is returned by the call of the given method name, for
+	// instance, "CreatePullRequest".
+	Err map[string]error
+
+	mu      sync.Mutex
+	repos   map[string]*Repository
+	commits int
+}
+
+// New returns a provider authenticated as user, holding no repositories.
+func New(user provider.User) *Provider {
+	return &Provider{User: user, repos: make(map[string]*Repository)}
+}
+
+// AddRepository adds a repository with a single commit on each of the given
+// branches and returns it.
+func (p *Provider) AddRepository(owner, repo string, branches ...string) *Repository {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r := &Repository{Branches: make(map[string]string, len(branches))}
+	for _, v := range branches {
+		r.Branches[v] = p.nextSHA()
+	}
+	p.repos[owner+"/"+repo] = r
+
+	return r
+}
+
+// Repository returns the repository, if added.
+func (p *Provider) Repository(owner, repo string) (*Repository, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.repos[owner+"/"+repo]
+	return r, ok
+}
+
+func (p *Provider) CurrentUser(ctx context.Context) (provider.User, error) {
+	if err := p.err("CurrentUser"); err != nil {
+		return provider.User{}, err
+	}
+
+	return p.User, nil
+}
+
+func (p *Provider) CanPush(ctx context.Context, owner, repo string) (bool, error) {
+	if err := p.err("CanPush"); err != nil {
+		return false, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, err := p.repository(owner, repo)
+	if err != nil {
+		return false, err
+	}
+
+	return !r.ReadOnly, nil
+}
+
+func (p *Provider) GetRef(ctx context.Context, owner, repo, branch string) (string, error) {
+	if err := p.err("GetRef"); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, err := p.repository(owner, repo)
+	if err != nil {
+		return "", err
+	}
+
+	sha, ok := r.Branches[branch]
+	if !ok {
+		return "", fmt.Errorf("branch %s: %w", branch, provider.ErrBranchNotFound)
+	}
+
+	return sha, nil
+}
+
+func (p *Provider) GetFile(ctx context.Context, owner, repo, branch, path string) ([]byte, error) {
+	if err := p.err("GetFile"); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, err := p.repository(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := r.Branches[branch]; !ok {
+		return nil, fmt.Errorf("branch %s: %w", branch, ErrNotFound)
+	}
+
+	content, ok := r.Files[path]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, provider.ErrFileNotFound)
+	}
+
+	return content, nil
+}
+
+func (p *Provider) CreateRef(ctx context.Context, owner, repo, branch, sha string) error {
+	if err := p.err("CreateRef"); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, err := p.repository(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := r.Branches[branch]; ok {
+		return fmt.Errorf("branch %s: %w", branch, provider.ErrBranchExists)
+	}
+	r.Branches[branch] = sha
+
+	return nil
+}
+
+func (p *Provider) DeleteRef(ctx context.Context, owner, repo, branch string) error {
+	if err := p.err("DeleteRef"); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, err := p.repository(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := r.Branches[branch]; !ok {
+		return fmt.Errorf("branch %s: %w", branch, ErrNotFound)
+	}
+	delete(r.Branches, branch)
+
+	return nil
+}
+
+func (p *Provider) CreateCommit(ctx context.Context, owner, repo string, c provider.Commit) (string, error) {
+	if err := p.err("CreateCommit"); err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, err := p.repository(owner, repo)
+	if err != nil {
+		return "", err
+	}
+
+	if head, ok := r.Branches[c.Branch]; !ok || head != c.Parent {
+		return "", fmt.Errorf("branch %s is not at %s", c.Branch, c.Parent)
+	}
+
+	sha := p.nextSHA()
+	r.Branches[c.Branch] = sha
+	r.Commits = append(r.Commits, c)
+
+	return sha, nil
+}
+
+func (p *Provider) CreatePullRequest(ctx context.Context, owner, repo string, pr provider.NewPullRequest) (provider.PullRequest, error) {
+	if err := p.err("CreatePullRequest"); err != nil {
+		return provider.PullRequest{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, err := p.repository(owner, repo)
+	if err != nil {
+		return provider.PullRequest{}, err
+	}
+
+	for _, v := range []string{pr.Head, pr.Base} {
+		if _, ok := r.Branches[v]; !ok {
+			return provider.PullRequest{}, fmt.Errorf("branch %s: %w", v, ErrNotFound)
+		}
+	}
+
+	// like the hosting services, a single open pull request per head and base.
+	for _, v := range r.PullRequests {
+		if v.State == provider.StateOpen && v.Head == pr.Head && v.Base == pr.Base {
+			return provider.PullRequest{}, fmt.Errorf("pull request %d from %s to %s: %w", v.Number, pr.Head, pr.Base, provider.ErrPullRequestExists)
+		}
+	}
+
+	number := len(r.PullRequests) + 1
+	created := provider.PullRequest{
+		Number:    number,
+		URL:       fmt.Sprintf("https://fake.invalid/%s/%s/pull/%d", owner, repo, number),
+		Title:     pr.Title,
+		State:     provider.StateOpen,
+		Head:      pr.Head,
+		Base:      pr.Base,
+		CreatedAt: time.Now(),
+	}
+	r.PullRequests = append(r.PullRequests, created)
+
+	return created, nil
+}
+
+func (p *Provider) ListPullRequests(ctx context.Context, owner, repo string, opt provider.ListOptions) ([]provider.PullRequest, error) {
+	if err := p.err("ListPullRequests"); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, err := p.repository(owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []provider.PullRequest
+	for _, v := range r.PullRequests {
+		if opt.Matches(v) {
+			prs = append(prs, v)
+		}
+	}
+
+	return prs, nil
+}
+
+func (p *Provider) MergePullRequest(ctx context.Context, owner, repo string, number int, method provider.MergeMethod) error {
+	if err := p.err("MergePullRequest"); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, err := p.repository(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	if number < 1 || number > len(r.PullRequests) {
+		return fmt.Errorf("pull request %d: %w", number, ErrNotFound)
+	}
+
+	pr := &r.PullRequests[number-1]
+	if pr.State != provider.StateOpen {
+		return fmt.Errorf("pull request %d is %s", number, pr.State)
+	}
+	pr.State = provider.StateMerged
+	r.Branches[pr.Base] = p.nextSHA()
+
+	return nil
+}
+
+func (p *Provider) err(method string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Err[method]
+}
+
+// repository must be called holding mu.
+func (p *Provider) repository(owner, repo string) (*Repository, error) {
+	r, ok := p.repos[owner+"/"+repo]
+	if !ok {
+		return nil, fmt.Errorf("repository %s/%s: %w", owner, repo, ErrNotFound)
+	}
+
+	return r, nil
+}
+
+// nextSHA must be called holding mu.
+func (p *Provider) nextSHA() string {
+	p.commits++
+	return fmt.Sprintf("%040x", p.commits)
+}
+
+var (
+	_ provider.Provider      = (*Provider)(nil)
+	_ provider.FileReader    = (*Provider)(nil)
+	_ provider.BranchDeleter = (*Provider)(nil)
+)
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-github/github"
+)
+
+// FileReader is implemented by the providers able to read a file of a
+// repository, for instance, the .gitattributes of the destinations.
+type FileReader interface {
+	// GetFile returns the content of the file at path on the branch, or an
+	// error matching ErrFileNotFound.
+	GetFile(ctx context.Context, owner, repo, branch, path string) ([]byte, error)
+}
+
+func (p *GitHub) GetFile(ctx context.Context, owner, repo, branch, path string) ([]byte, error) {
+	file, _, resp, err := p.client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: branch})
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		return nil, &kindError{kind: ErrFileNotFound, err: err}
+	case err != nil:
+		return nil, classifyGitHub(err, nil)
+	case file == nil:
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	content, err := file.GetContent()
+	return []byte(content), err
+}
+
+func (p *GitLab) GetFile(ctx context.Context, owner, repo, branch, path string) ([]byte, error) {
+	var out struct {
+		Content string `json:"content"`
+	}
+	query := url.Values{"ref": {branch}}
+	err := p.rest.do(ctx, http.MethodGet, p.project(owner, repo)+"/repository/files/"+url.PathEscape(path)+"?"+query.Encode(), nil, &out)
+	if err != nil {
+		return nil, classify(err, ErrFileNotFound, http.StatusNotFound, "")
+	}
+
+	return base64.StdEncoding.DecodeString(out.Content)
+}
+
+func (p *Gitea) GetFile(ctx context.Context, owner, repo, branch, path string) ([]byte, error) {
+	var out struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	}
+	query := url.Values{"ref": {branch}}
+	err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/contents/"+escapePath(path)+"?"+query.Encode(), nil, &out)
+	if err != nil {
+		return nil, classify(err, ErrFileNotFound, http.StatusNotFound, "")
+	}
+
+	if out.Type != "file" {
+		return nil, errors.New(path + " is not a file")
+	}
+
+	return base64.StdEncoding.DecodeString(out.Content)
+}
+
+var (
+	_ FileReader = (*GitHub)(nil)
+	_ FileReader = (*GitLab)(nil)
+	_ FileReader = (*Gitea)(nil)
+)
@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Gitea creates pull requests through the API of Gitea and Forgejo
+// instances, which follows the one of GitHub. Committing several files at once
+// requires Gitea 1.20 or Forgejo 1.20.
+type Gitea struct {
+	rest restClient
+}
+
+// NewGitea returns a provider for the instance at baseURL. The token
+// is sent as a bearer token by tc.
+func NewGitea(tc *http.Client, baseURL string) (*Gitea, error) {
+	if baseURL == "" {
+		return nil, errors.New("the instance URL is required by Gitea")
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(u.Path, "/api/v1") {
+		u.Path += "/api/v1"
+	}
+
+	return &Gitea{rest: restClient{client: tc, baseURL: u.String()}}, nil
+}
+
+func (p *Gitea) repository(owner, repo string) string {
+	return "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+func (p *Gitea) CurrentUser(ctx context.Context) (User, error) {
+	var u struct {
+		Login    string `json:"login"`
+		FullName string `json:"full_name"`
+		Email    string `json:"email"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, "user", nil, &u); err != nil {
+		return User{}, err
+	}
+
+	name := u.FullName
+	if name == "" {
+		name = u.Login
+	}
+
+	return User{Name: name, Email: u.Email}, nil
+}
+
+func (p *Gitea) CanPush(ctx context.Context, owner, repo string) (bool, error) {
+	var r struct {
+		Permissions struct {
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo), nil, &r); err != nil {
+		return false, err
+	}
+
+	return r.Permissions.Push, nil
+}
+
+func (p *Gitea) GetRef(ctx context.Context, owner, repo, branch string) (string, error) {
+	var b struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/branches/"+url.PathEscape(branch), nil, &b)
+	return b.Commit.ID, classify(err, ErrBranchNotFound, http.StatusNotFound, "")
+}
+
+func (p *Gitea) CreateRef(ctx context.Context, owner, repo, branch, sha string) error {
+	in := map[string]string{"new_branch_name": branch, "old_ref_name": sha}
+	err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/branches", in, nil)
+	return classify(err, ErrBranchExists, http.StatusConflict, "")
+}
+
+// CreateCommit creates or updates every file in a single commit. Updates require
+// the SHA of the blob being replaced.
+func (p *Gitea) CreateCommit(ctx context.Context, owner, repo string, c Commit) (string, error) {
+	files := make([]map[string]string, 0, len(c.Files))
+	for _, v := range c.Files {
+		sha, err := p.blobSHA(ctx, owner, repo, c.Branch, v.Path)
+		if err != nil {
+			return "", err
+		}
+
+		content, err := v.Bytes()
+		if err != nil {
+			return "", err
+		}
+
+		file := map[string]string{
+			"operation": "create",
+			"path":      v.Path,
+			"content":   base64.StdEncoding.EncodeToString(content),
+		}
+		if sha != "" {
+			file["operation"], file["sha"] = "update", sha
+		}
+		files = append(files, file)
+	}
+
+	in := map[string]interface{}{
+		"branch":  c.Branch,
+		"message": c.Message,
+		"author":  map[string]string{"name": c.Author.Name, "email": c.Author.Email},
+		"files":   files,
+	}
+
+	var out struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/contents", in, &out)
+	return out.Commit.SHA, err
+}
+
+// blobSHA returns the SHA of the file at path, empty when it does not exist.
+func (p *Gitea) blobSHA(ctx context.Context, owner, repo, branch, path string) (string, error) {
+	var content struct {
+		SHA string `json:"sha"`
+	}
+	query := url.Values{"ref": {branch}}
+	err := p.rest.do(ctx, http.MethodGet, p.repository(owner, repo)+"/contents/"+escapePath(path)+"?"+query.Encode(), nil, &content)
+	switch {
+	case err == nil:
+		return content.SHA, nil
+	case isStatus(err, http.StatusNotFound):
+		return "", nil
+	default:
+		return "", err
+	}
+}
+
+func (p *Gitea) CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (PullRequest, error) {
+	in := map[string]string{
+		"head":  pr.Head,
+		"base":  pr.Base,
+		"title": pr.Title,
+		"body":  pr.Body,
+	}
+
+	var out giteaPullRequest
+	if err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/pulls", in, &out); err != nil {
+		return PullRequest{}, classify(err, ErrPullRequestExists, http.StatusConflict, "")
+	}
+
+	return out.pullRequest(), nil
+}
+
+func (p *Gitea) ListPullRequests(ctx context.Context, owner, repo string, opt ListOptions) ([]PullRequest, error) {
+	// merged pull requests are closed ones flagged as merged.
+	state := opt.state()
+	if state == StateMerged {
+		state = StateClosed
+	}
+
+	// the instance may cap the limit, the pages are followed by their links.
+	query := url.Values{"state": {state}, "limit": {"50"}}
+	var pulls []PullRequest
+	for next := p.repository(owner, repo) + "/pulls?" + query.Encode(); next != ""; {
+		var out []giteaPullRequest
+		var err error
+		if next, err = p.rest.page(ctx, next, &out); err != nil {
+			return nil, err
+		}
+
+		for _, v := range out {
+			if pr := v.pullRequest(); opt.Matches(pr) {
+				pulls = append(pulls, pr)
+			}
+		}
+	}
+
+	return pulls, nil
+}
+
+func (p *Gitea) MergePullRequest(ctx context.Context, owner, repo string, number int, method MergeMethod) error {
+	if method == "" {
+		method = MergeCommit
+	}
+
+	in := map[string]string{"Do": string(method)}
+	return p.rest.do(ctx, http.MethodPost, fmt.Sprintf("%s/pulls/%d/merge", p.repository(owner, repo), number), in, nil)
+}
+
+type giteaPullRequest struct {
+	Number  int       `json:"number"`
+	HTMLURL string    `json:"html_url"`
+	Title   string    `json:"title"`
+	State   string    `json:"state"`
+	Merged  bool      `json:"merged"`
+	Created time.Time `json:"created_at"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (pr giteaPullRequest) pullRequest() PullRequest {
+	state := pr.State
+	if pr.Merged {
+		state = StateMerged
+	}
+
+	return PullRequest{
+		Number:    pr.Number,
+		URL:       pr.HTMLURL,
+		Title:     pr.Title,
+		State:     state,
+		Head:      pr.Head.Ref,
+		Base:      pr.Base.Ref,
+		CreatedAt: pr.Created,
+	}
+}
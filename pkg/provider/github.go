@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// GitHub creates the pull requests through the GitHub git data API.
+type GitHub struct {
+	client   *github.Client
+	payloads payloads
+}
+
+// NewGitHub returns a provider using the given client.
+func NewGitHub(client *github.Client) *GitHub {
+	return &GitHub{client: client}
+}
+
+// NewGitHubClient returns a GitHub client for github.com when baseURL is
+// empty, or for the GitHub Enterprise Server instance at baseURL otherwise. A
+// bare host such as https://github.example.com is completed with the default
+// API paths, and uploadURL defaults to the uploads endpoint of the same host.
+func NewGitHubClient(tc *http.Client, baseURL, uploadURL string) (*github.Client, error) {
+	if baseURL == "" {
+		return github.NewClient(tc), nil
+	}
+
+	base, err := enterpriseURL(baseURL, "api/v3/")
+	if err != nil {
+		return nil, err
+	}
+
+	if uploadURL == "" {
+		u := *base
+		u.Path = strings.TrimSuffix(u.Path, "api/v3/") + "api/uploads/"
+		uploadURL = u.String()
+	}
+
+	return github.NewEnterpriseClient(base.String(), uploadURL, tc)
+}
+
+// enterpriseURL parses rawURL appending the given API path when it has none.
+func enterpriseURL(rawURL, path string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/" + path
+	}
+
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	return u, nil
+}
+
+func (p *GitHub) CurrentUser(ctx context.Context) (User, error) {
+	u, resp, err := p.client.Users.Get(ctx, "")
+	if err != nil {
+		return User{}, classifyGitHub(err, nil)
+	}
+
+	current := User{Name: u.GetName(), Email: u.GetEmail()}
+	if resp != nil && resp.Response != nil {
+		// fine-grained and GitHub App tokens do not report their scopes.
+		if header, ok := resp.Header["X-Oauth-Scopes"]; ok {
+			for _, v := range strings.Split(strings.Join(header, ","), ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					current.Scopes = append(current.Scopes, v)
+				}
+			}
+
+			if current.Scopes == nil {
+				current.Scopes = []string{}
+			}
+		}
+	}
+
+	return current, nil
+}
+
+func (p *GitHub) CanPush(ctx context.Context, owner, repo string) (bool, error) {
+	r, _, err := p.client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return false, classifyGitHub(err, nil)
+	}
+
+	return r.Permissions != nil && (*r.Permissions)["push"], nil
+}
+
+func (p *GitHub) GetRef(ctx context.Context, owner, repo, branch string) (string, error) {
+	ref, resp, err := p.client.Git.GetRef(ctx, owner, repo, "refs/heads/"+branch)
+	switch {
+	// a missing branch prefixing others is listed with them, not found.
+	case err != nil && resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusOK):
+		return "", &kindError{kind: ErrBranchNotFound, err: err}
+	case err != nil:
+		return "", classifyGitHub(err, nil)
+	}
+
+	return ref.GetObject().GetSHA(), nil
+}
+
+func (p *GitHub) CreateRef(ctx context.Context, owner, repo, branch, sha string) error {
+	newRef := &github.Reference{Ref: github.String("refs/heads/" + branch), Object: &github.GitObject{SHA: github.String(sha)}}
+	_, _, err := p.client.Git.CreateRef(ctx, owner, repo, newRef)
+	return classifyGitHub(err, ErrBranchExists)
+}
+
+// CreateCommit creates a tree with the files on top of the parent commit, the
+// commit using that tree and then moves the branch to it.
+func (p *GitHub) CreateCommit(ctx context.Context, owner, repo string, c Commit) (string, error) {
+	entries, err := p.treeEntries(ctx, owner, repo, c.Files)
+	if err != nil {
+		return "", err
+	}
+
+	tree, _, err := p.client.Git.CreateTree(ctx, owner, repo, c.Parent, entries)
+	if err != nil {
+		return "", classifyGitHub(err, nil)
+	}
+
+	// Get the parent commit to attach the commit to.
+	parent, _, err := p.client.Repositories.GetCommit(ctx, owner, repo, c.Parent)
+	if err != nil {
+		return "", classifyGitHub(err, nil)
+	}
+	// This is not always populated, but is needed.
+	parent.Commit.SHA = parent.SHA
+
+	// Create the commit using the tree.
+	date := time.Now()
+	author := &github.CommitAuthor{Date: &date, Name: &c.Author.Name, Email: &c.Author.Email}
+	newCommit, _, err := p.client.Git.CreateCommit(ctx, owner, repo, &github.Commit{Author: author, Message: &c.Message, Tree: tree, Parents: []github.Commit{*parent.Commit}})
+	if err != nil {
+		return "", classifyGitHub(err, nil)
+	}
+
+	// Attach the commit to the branch.
+	ref := &github.Reference{Ref: github.String("refs/heads/" + c.Branch), Object: &github.GitObject{SHA: newCommit.SHA}}
+	if _, _, err := p.client.Git.UpdateRef(ctx, owner, repo, ref, false); err != nil {
+		return "", classifyGitHub(err, nil)
+	}
+
+	return newCommit.GetSHA(), nil
+}
+
+// CreatePullRequest is based on: https://godoc.org/github.com/google/go-github/github#example-PullRequestsService-Create
+func (p *GitHub) CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (PullRequest, error) {
+	newPR := &github.NewPullRequest{
+		Title:               &pr.Title,
+		Head:                &pr.Head,
+		Base:                &pr.Base,
+		Body:                &pr.Body,
+		MaintainerCanModify: github.Bool(true),
+	}
+
+	created, _, err := p.client.PullRequests.Create(ctx, owner, repo, newPR)
+	if err != nil {
+		return PullRequest{}, classifyGitHub(err, ErrPullRequestExists)
+	}
+
+	return gitHubPullRequest(created), nil
+}
+
+func (p *GitHub) ListPullRequests(ctx context.Context, owner, repo string, opt ListOptions) ([]PullRequest, error) {
+	// merged pull requests are closed ones with a merge date.
+	state := opt.state()
+	if state == StateMerged {
+		state = StateClosed
+	}
+
+	listOptions := &github.PullRequestListOptions{
+		State:       state,
+		Base:        opt.Base,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	if opt.Head != "" {
+		listOptions.Head = owner + ":" + opt.Head
+	}
+
+	var pulls []PullRequest
+	for {
+		page, resp, err := p.client.PullRequests.List(ctx, owner, repo, listOptions)
+		if err != nil {
+			return nil, classifyGitHub(err, nil)
+		}
+
+		for _, v := range page {
+			if pr := gitHubPullRequest(v); opt.Matches(pr) {
+				pulls = append(pulls, pr)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return pulls, nil
+		}
+		listOptions.Page = resp.NextPage
+	}
+}
+
+func (p *GitHub) MergePullRequest(ctx context.Context, owner, repo string, number int, method MergeMethod) error {
+	_, _, err := p.client.PullRequests.Merge(ctx, owner, repo, number, "", &github.PullRequestOptions{MergeMethod: string(method)})
+	return classifyGitHub(err, nil)
+}
+
+func gitHubPullRequest(pr *github.PullRequest) PullRequest {
+	state := pr.GetState()
+	if pr.MergedAt != nil {
+		state = StateMerged
+	}
+
+	return PullRequest{
+		Number:    pr.GetNumber(),
+		URL:       pr.GetHTMLURL(),
+		Title:     pr.GetTitle(),
+		State:     state,
+		Head:      pr.GetHead().GetRef(),
+		Base:      pr.GetBase().GetRef(),
+		CreatedAt: pr.GetCreatedAt(),
+	}
+}
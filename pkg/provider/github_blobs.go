@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	// _inlineFiles is the number of files up to which their content is sent
+	// inline with the tree, larger change sets upload their blobs first.
+	_inlineFiles = 4
+	// _blobUploads bounds the blobs uploaded at the same time.
+	_blobUploads = 8
+)
+
+// treeEntries returns the tree entries of the files, uploading their blobs
+// concurrently when there are many of them or some are streamed.
+func (p *GitHub) treeEntries(ctx context.Context, owner, repo string, files []File) ([]github.TreeEntry, error) {
+	entries := make([]github.TreeEntry, len(files))
+	if inline(files) {
+		for i, v := range files {
+			entries[i] = github.TreeEntry{Path: github.String(v.Path), Type: github.String("blob"), Content: github.String(string(v.Content)), Mode: github.String("100644")}
+		}
+		return entries, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		slots    = make(chan struct{}, _blobUploads)
+	)
+	for i, v := range files {
+		slots <- struct{}{}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, f File) {
+			defer func() { <-slots; wg.Done() }()
+			sha, err := p.createBlob(ctx, owner, repo, f)
+			if err != nil {
+				once.Do(func() { firstErr = err; cancel() })
+				return
+			}
+
+			entries[i] = github.TreeEntry{Path: github.String(f.Path), Type: github.String("blob"), SHA: github.String(sha), Mode: github.String("100644")}
+		}(i, v)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return entries, nil
+}
+
+// inline reports whether the files are few enough, all in memory and text,
+// to be sent along with the tree, whose entries only take strings.
+func inline(files []File) bool {
+	if len(files) > _inlineFiles {
+		return false
+	}
+
+	for _, v := range files {
+		if v.Content == nil && v.Open != nil || !IsText(v.Content) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// createBlob uploads the content of the file and returns the SHA of the blob.
+// Streamed files are read and base64 encoded while being sent.
+func (p *GitHub) createBlob(ctx context.Context, owner, repo string, f File) (string, error) {
+	if f.Content != nil || f.Open == nil {
+		blob, _, err := p.client.Git.CreateBlob(ctx, owner, repo, &github.Blob{
+			Content:  github.String(p.payloads.encode(f.Content)),
+			Encoding: github.String("base64"),
+		})
+		if err != nil {
+			return "", classifyGitHub(err, nil)
+		}
+
+		return blob.GetSHA(), nil
+	}
+
+	content, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := p.client.NewRequest(http.MethodPost, fmt.Sprintf("repos/%v/%v/git/blobs", owner, repo), nil)
+	if err != nil {
+		content.Close()
+		return "", err
+	}
+
+	const prefix, suffix = `{"encoding":"base64","content":"`, `"}`
+	body, w := io.Pipe()
+	defer body.Close()
+	go func() {
+		defer content.Close()
+		enc := base64.NewEncoder(base64.StdEncoding, w)
+		_, err := io.WriteString(w, prefix)
+		if err == nil {
+			_, err = io.Copy(enc, content)
+		}
+		if err == nil {
+			err = enc.Close()
+		}
+		if err == nil {
+			_, err = io.WriteString(w, suffix)
+		}
+		w.CloseWithError(err)
+	}()
+
+	req.Body = body
+	req.ContentLength = int64(len(prefix)+len(suffix)) + int64(base64.StdEncoding.EncodedLen(int(f.Size)))
+	req.Header.Set("Content-Type", "application/json")
+
+	var blob github.Blob
+	if _, err := p.client.Do(ctx, req, &blob); err != nil {
+		return "", classifyGitHub(err, nil)
+	}
+
+	return blob.GetSHA(), nil
+}
+
+// payloads caches the base64 encoding of the committed contents by hash, the
+// same files being usually committed to every destination.
+type payloads struct {
+	mu      sync.Mutex
+	encoded map[[sha256.Size]byte]string
+}
+
+func (p *payloads) encode(content []byte) string {
+	sum := sha256.Sum256(content)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if encoded, ok := p.encoded[sum]; ok {
+		return encoded
+	}
+
+	if p.encoded == nil {
+		p.encoded = make(map[[sha256.Size]byte]string)
+	}
+	encoded := base64.StdEncoding.EncodeToString(content)
+	p.encoded[sum] = encoded
+	return encoded
+}
@@ -0,0 +1,297 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// GitHubGraphQL is a GitHub provider reading refs and creating branches,
+// commits and pull requests through the GraphQL API, which takes about half
+// the requests of the REST one per destination. Commits are created with
+// createCommitOnBranch, so they are authored and signed by the token user
+// regardless of Commit.Author.
+type GitHubGraphQL struct {
+	*GitHub
+	endpoint string
+
+	// repository node IDs by owner/repo, and the data found by Prefetch.
+	mu        sync.Mutex
+	ids       map[string]string
+	refs      map[string]string        // SHA by owner/repo/branch, empty when missing.
+	canPush   map[string]bool          // by owner/repo.
+	defaults  map[string]string        // default branch by owner/repo.
+	openPulls map[string][]PullRequest // open pull requests by owner/repo/head.
+}
+
+// NewGitHubGraphQL returns a provider using the GraphQL API of the instance
+// the client is configured for, and its REST API for the rest.
+func NewGitHubGraphQL(client *github.Client) *GitHubGraphQL {
+	endpoint := *client.BaseURL
+	if strings.HasSuffix(endpoint.Path, "/api/v3/") {
+		endpoint.Path = strings.TrimSuffix(endpoint.Path, "v3/") + "graphql"
+	} else {
+		endpoint.Path += "graphql"
+	}
+
+	return &GitHubGraphQL{
+		GitHub:    NewGitHub(client),
+		endpoint:  endpoint.String(),
+		ids:       make(map[string]string),
+		refs:      make(map[string]string),
+		canPush:   make(map[string]bool),
+		defaults:  make(map[string]string),
+		openPulls: make(map[string][]PullRequest),
+	}
+}
+
+// graphQLError is an error of a GraphQL response.
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// send sends a GraphQL query, or mutation, decoding its data, which may be
+// partial, into out and returning the errors of the response.
+func (p *GitHubGraphQL) send(ctx context.Context, query string, vars map[string]interface{}, out interface{}) ([]graphQLError, error) {
+	req, err := p.client.NewRequest(http.MethodPost, p.endpoint, map[string]interface{}{"query": query, "variables": vars})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data   interface{}    `json:"data"`
+		Errors []graphQLError `json:"errors"`
+	}
+	resp.Data = out
+	if _, err := p.client.Do(ctx, req, &resp); err != nil {
+		return nil, classifyGitHub(err, nil)
+	}
+
+	return resp.Errors, nil
+}
+
+// query sends a GraphQL query, or mutation, decoding its data into out and
+// failing on any error of the response.
+func (p *GitHubGraphQL) query(ctx context.Context, query string, vars map[string]interface{}, out interface{}) error {
+	errs, err := p.send(ctx, query, vars, out)
+	if err != nil || len(errs) == 0 {
+		return err
+	}
+
+	messages := make([]string, 0, len(errs))
+	for _, v := range errs {
+		messages = append(messages, v.Message)
+	}
+	err = errors.New(strings.Join(messages, "; "))
+
+	switch first := errs[0]; {
+	case first.Type == "RATE_LIMITED":
+		return &kindError{kind: ErrRateLimited, err: err}
+	case strings.Contains(first.Message, "A ref named"):
+		return &kindError{kind: ErrBranchExists, err: err}
+	case strings.Contains(first.Message, "A pull request already exists"):
+		return &kindError{kind: ErrPullRequestExists, err: err}
+	default:
+		return err
+	}
+}
+
+// repositoryID returns the cached node ID of the repository, looking it up
+// when missing.
+func (p *GitHubGraphQL) repositoryID(ctx context.Context, owner, repo string) (string, error) {
+	p.mu.Lock()
+	id, ok := p.ids[owner+"/"+repo]
+	p.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var out struct {
+		Repository struct {
+			ID string `json:"id"`
+		} `json:"repository"`
+	}
+	if err := p.query(ctx, `query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { id } }`,
+		map[string]interface{}{"owner": owner, "name": repo}, &out); err != nil {
+		return "", err
+	}
+
+	p.cacheID(owner, repo, out.Repository.ID)
+	return out.Repository.ID, nil
+}
+
+func (p *GitHubGraphQL) cacheID(owner, repo, id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ids[owner+"/"+repo] = id
+}
+
+// GetRef looks up the branch along with the repository ID, needed by the
+// mutations, unless it was prefetched.
+func (p *GitHubGraphQL) GetRef(ctx context.Context, owner, repo, branch string) (string, error) {
+	p.mu.Lock()
+	sha, ok := p.refs[owner+"/"+repo+"/"+branch]
+	p.mu.Unlock()
+	switch {
+	case ok && sha == "":
+		return "", fmt.Errorf("%w: %s in %s/%s", ErrBranchNotFound, branch, owner, repo)
+	case ok:
+		return sha, nil
+	}
+
+	var out struct {
+		Repository struct {
+			ID  string `json:"id"`
+			Ref *struct {
+				Target struct {
+					OID string `json:"oid"`
+				} `json:"target"`
+			} `json:"ref"`
+		} `json:"repository"`
+	}
+	if err := p.query(ctx, `query($owner: String!, $name: String!, $ref: String!) {
+  repository(owner: $owner, name: $name) { id ref(qualifiedName: $ref) { target { oid } } }
+}`, map[string]interface{}{"owner": owner, "name": repo, "ref": "refs/heads/" + branch}, &out); err != nil {
+		return "", err
+	}
+
+	p.cacheID(owner, repo, out.Repository.ID)
+	if out.Repository.Ref == nil {
+		return "", fmt.Errorf("%w: %s in %s/%s", ErrBranchNotFound, branch, owner, repo)
+	}
+
+	return out.Repository.Ref.Target.OID, nil
+}
+
+func (p *GitHubGraphQL) CreateRef(ctx context.Context, owner, repo, branch, sha string) error {
+	id, err := p.repositoryID(ctx, owner, repo)
+	if err != nil {
+		return err
+	}
+
+	if err := p.query(ctx, `mutation($input: CreateRefInput!) { createRef(input: $input) { clientMutationId } }`,
+		map[string]interface{}{"input": map[string]string{"repositoryId": id, "name": "refs/heads/" + branch, "oid": sha}}, nil); err != nil {
+		return err
+	}
+
+	p.cacheRef(owner, repo, branch, sha)
+	return nil
+}
+
+// cacheRef records the SHA of a branch when it is known to the cache, so the
+// prefetched refs stay current.
+func (p *GitHubGraphQL) cacheRef(owner, repo, branch, sha string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.refs[owner+"/"+repo+"/"+branch]; ok {
+		p.refs[owner+"/"+repo+"/"+branch] = sha
+	}
+}
+
+// CreateCommit creates the commit in a single request with
+// createCommitOnBranch, which fails if the branch moved from the parent.
+func (p *GitHubGraphQL) CreateCommit(ctx context.Context, owner, repo string, c Commit) (string, error) {
+	additions := make([]map[string]string, 0, len(c.Files))
+	for _, v := range c.Files {
+		content, err := v.Bytes()
+		if err != nil {
+			return "", err
+		}
+		additions = append(additions, map[string]string{"path": v.Path, "contents": p.payloads.encode(content)})
+	}
+
+	headline, body := c.Message, ""
+	if i := strings.Index(c.Message, "\n"); i >= 0 {
+		headline, body = c.Message[:i], strings.TrimSpace(c.Message[i+1:])
+	}
+
+	input := map[string]interface{}{
+		"branch":          map[string]string{"repositoryNameWithOwner": owner + "/" + repo, "branchName": c.Branch},
+		"expectedHeadOid": c.Parent,
+		"message":         map[string]string{"headline": headline, "body": body},
+		"fileChanges":     map[string]interface{}{"additions": additions},
+	}
+
+	var out struct {
+		CreateCommitOnBranch struct {
+			Commit struct {
+				OID string `json:"oid"`
+			} `json:"commit"`
+		} `json:"createCommitOnBranch"`
+	}
+	if err := p.query(ctx, `mutation($input: CreateCommitOnBranchInput!) { createCommitOnBranch(input: $input) { commit { oid } } }`,
+		map[string]interface{}{"input": input}, &out); err != nil {
+		return "", err
+	}
+
+	p.cacheRef(owner, repo, c.Branch, out.CreateCommitOnBranch.Commit.OID)
+	return out.CreateCommitOnBranch.Commit.OID, nil
+}
+
+func (p *GitHubGraphQL) CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (PullRequest, error) {
+	id, err := p.repositoryID(ctx, owner, repo)
+	if err != nil {
+		return PullRequest{}, err
+	}
+
+	input := map[string]interface{}{
+		"repositoryId":        id,
+		"baseRefName":         pr.Base,
+		"headRefName":         pr.Head,
+		"title":               pr.Title,
+		"body":                pr.Body,
+		"maintainerCanModify": true,
+	}
+
+	var out struct {
+		CreatePullRequest struct {
+			PullRequest graphQLPullRequest `json:"pullRequest"`
+		} `json:"createPullRequest"`
+	}
+	if err := p.query(ctx, `mutation($input: CreatePullRequestInput!) {
+  createPullRequest(input: $input) { pullRequest { `+_graphQLPullRequestFields+` } }
+}`, map[string]interface{}{"input": input}, &out); err != nil {
+		return PullRequest{}, err
+	}
+
+	created := out.CreatePullRequest.PullRequest.pullRequest()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pulls, ok := p.openPulls[owner+"/"+repo+"/"+pr.Head]; ok {
+		p.openPulls[owner+"/"+repo+"/"+pr.Head] = append(pulls, created)
+	}
+
+	return created, nil
+}
+
+// _graphQLPullRequestFields are the fields decoded into graphQLPullRequest.
+const _graphQLPullRequestFields = "number url title state headRefName baseRefName createdAt"
+
+type graphQLPullRequest struct {
+	Number      int       `json:"number"`
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	State       string    `json:"state"`
+	HeadRefName string    `json:"headRefName"`
+	BaseRefName string    `json:"baseRefName"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (pr graphQLPullRequest) pullRequest() PullRequest {
+	return PullRequest{
+		Number:    pr.Number,
+		URL:       pr.URL,
+		Title:     pr.Title,
+		State:     strings.ToLower(pr.State),
+		Head:      pr.HeadRefName,
+		Base:      pr.BaseRefName,
+		CreatedAt: pr.CreatedAt,
+	}
+}
@@ -0,0 +1,222 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PrefetchQuery is a repository and the branches to look up in advance.
+type PrefetchQuery struct {
+	Repository string
+	Branches   []string
+}
+
+// Prefetcher is implemented by the providers able to look up many
+// repositories at once. The engine calls it before processing the batch, and
+// the provider answers the later calls from what it found.
+type Prefetcher interface {
+	Prefetch(ctx context.Context, owner string, queries []PrefetchQuery) error
+}
+
+// _prefetchBatchSize is the number of repositories looked up per query, within
+// the node limits of the GraphQL API.
+const _prefetchBatchSize = 50
+
+// Prefetch looks up the node ID, the push permission, the default branch, the
+// given branches and the open pull requests from them of the repositories,
+// with one aliased GraphQL query per 50 repositories. Missing repositories are
+// left to the regular calls to report.
+func (p *GitHubGraphQL) Prefetch(ctx context.Context, owner string, queries []PrefetchQuery) error {
+	for start := 0; start < len(queries); start += _prefetchBatchSize {
+		end := start + _prefetchBatchSize
+		if end > len(queries) {
+			end = len(queries)
+		}
+
+		if err := p.prefetch(ctx, owner, queries[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type prefetchedRepository struct {
+	ID               string `json:"id"`
+	ViewerPermission string `json:"viewerPermission"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+}
+
+type prefetchedRef struct {
+	Target struct {
+		OID string `json:"oid"`
+	} `json:"target"`
+	AssociatedPullRequests struct {
+		TotalCount int                  `json:"totalCount"`
+		Nodes      []graphQLPullRequest `json:"nodes"`
+	} `json:"associatedPullRequests"`
+}
+
+func (p *GitHubGraphQL) prefetch(ctx context.Context, owner string, queries []PrefetchQuery) error {
+	// repositories are aliased r<i> and their branches b<j>, as the aliases of
+	// the response are decoded separately.
+	var b strings.Builder
+	b.WriteString("query {\n")
+	for i, q := range queries {
+		fmt.Fprintf(&b, "  r%d: repository(owner: %s, name: %s) {\n    id viewerPermission defaultBranchRef { name }\n", i, quote(owner), quote(q.Repository))
+		for j, branch := range q.Branches {
+			fmt.Fprintf(&b, "    b%d: ref(qualifiedName: %s) { target { oid } associatedPullRequests(states: OPEN, first: 10) { totalCount nodes { %s } } }\n",
+				j, quote("refs/heads/"+branch), _graphQLPullRequestFields)
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}")
+
+	var out map[string]map[string]json.RawMessage
+	if _, err := p.send(ctx, b.String(), nil, &out); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, q := range queries {
+		fields, ok := out[fmt.Sprintf("r%d", i)]
+		if !ok || fields == nil {
+			continue
+		}
+
+		var repo prefetchedRepository
+		if err := remarshal(fields, &repo); err != nil {
+			return err
+		}
+
+		key := owner + "/" + q.Repository
+		p.ids[key] = repo.ID
+		switch repo.ViewerPermission {
+		case "ADMIN", "MAINTAIN", "WRITE":
+			p.canPush[key] = true
+		default:
+			p.canPush[key] = false
+		}
+		if repo.DefaultBranchRef != nil {
+			p.defaults[key] = repo.DefaultBranchRef.Name
+		}
+
+		for j, branch := range q.Branches {
+			raw, ok := fields[fmt.Sprintf("b%d", j)]
+			if !ok {
+				continue
+			}
+
+			var ref *prefetchedRef
+			if err := json.Unmarshal(raw, &ref); err != nil {
+				return err
+			}
+
+			if ref == nil {
+				p.refs[key+"/"+branch] = ""
+				continue
+			}
+			p.refs[key+"/"+branch] = ref.Target.OID
+
+			// truncated lists are left to ListPullRequests, which pages.
+			if ref.AssociatedPullRequests.TotalCount > len(ref.AssociatedPullRequests.Nodes) {
+				continue
+			}
+
+			var pulls []PullRequest
+			for _, v := range ref.AssociatedPullRequests.Nodes {
+				if v.HeadRefName == branch {
+					pulls = append(pulls, v.pullRequest())
+				}
+			}
+			p.openPulls[key+"/"+branch] = pulls
+		}
+	}
+
+	return nil
+}
+
+// CanPush answers from the prefetched permissions when available.
+func (p *GitHubGraphQL) CanPush(ctx context.Context, owner, repo string) (bool, error) {
+	p.mu.Lock()
+	ok, found := p.canPush[owner+"/"+repo]
+	p.mu.Unlock()
+	if found {
+		return ok, nil
+	}
+
+	return p.GitHub.CanPush(ctx, owner, repo)
+}
+
+// DefaultBranch returns the default branch of the repository, looking it up
+// unless it was prefetched.
+func (p *GitHubGraphQL) DefaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	p.mu.Lock()
+	branch, found := p.defaults[owner+"/"+repo]
+	p.mu.Unlock()
+	if found {
+		return branch, nil
+	}
+
+	var out struct {
+		Repository struct {
+			DefaultBranchRef *struct {
+				Name string `json:"name"`
+			} `json:"defaultBranchRef"`
+		} `json:"repository"`
+	}
+	if err := p.query(ctx, `query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { defaultBranchRef { name } } }`,
+		map[string]interface{}{"owner": owner, "name": repo}, &out); err != nil {
+		return "", err
+	}
+
+	if out.Repository.DefaultBranchRef == nil {
+		return "", fmt.Errorf("%s/%s has no default branch", owner, repo)
+	}
+
+	return out.Repository.DefaultBranchRef.Name, nil
+}
+
+// ListPullRequests answers the lookups of the open pull requests from a
+// prefetched head branch from the cache.
+func (p *GitHubGraphQL) ListPullRequests(ctx context.Context, owner, repo string, opt ListOptions) ([]PullRequest, error) {
+	if opt.Head != "" && opt.state() == StateOpen {
+		p.mu.Lock()
+		cached, found := p.openPulls[owner+"/"+repo+"/"+opt.Head]
+		p.mu.Unlock()
+		if found {
+			var pulls []PullRequest
+			for _, v := range cached {
+				if opt.Matches(v) {
+					pulls = append(pulls, v)
+				}
+			}
+			return pulls, nil
+		}
+	}
+
+	return p.GitHub.ListPullRequests(ctx, owner, repo, opt)
+}
+
+// quote returns s as a GraphQL string literal.
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// remarshal decodes the fields of a JSON object into out.
+func remarshal(fields map[string]json.RawMessage, out interface{}) error {
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, out)
+}
+
+var _ Prefetcher = (*GitHubGraphQL)(nil)
@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitLab creates merge requests through the GitLab REST API. Owners
+// are GitLab groups or users, and repositories are their projects.
+type GitLab struct {
+	rest restClient
+}
+
+// NewGitLab returns a provider for the GitLab instance at baseURL,
+// gitlab.com when empty. The token is sent as a bearer token by tc.
+func NewGitLab(tc *http.Client, baseURL string) (*GitLab, error) {
+	if baseURL == "" {
+		baseURL = "https://gitlab.com"
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(u.Path, "/api/v4") {
+		u.Path += "/api/v4"
+	}
+
+	return &GitLab{rest: restClient{client: tc, baseURL: u.String()}}, nil
+}
+
+// project returns the escaped path of the project, used as its ID.
+func (p *GitLab) project(owner, repo string) string {
+	return "projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+func (p *GitLab) CurrentUser(ctx context.Context) (User, error) {
+	var u struct {
+		Name        string `json:"name"`
+		Email       string `json:"email"`
+		PublicEmail string `json:"public_email"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, "user", nil, &u); err != nil {
+		return User{}, err
+	}
+
+	email := u.Email
+	if email == "" {
+		email = u.PublicEmail
+	}
+
+	return User{Name: u.Name, Email: email}, nil
+}
+
+// gitLabDeveloper is the minimum access level allowed to push by default.
+const gitLabDeveloper = 30
+
+func (p *GitLab) CanPush(ctx context.Context, owner, repo string) (bool, error) {
+	type access struct {
+		AccessLevel int `json:"access_level"`
+	}
+	var project struct {
+		Permissions struct {
+			ProjectAccess *access `json:"project_access"`
+			GroupAccess   *access `json:"group_access"`
+		} `json:"permissions"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, p.project(owner, repo), nil, &project); err != nil {
+		return false, err
+	}
+
+	for _, v := range []*access{project.Permissions.ProjectAccess, project.Permissions.GroupAccess} {
+		if v != nil && v.AccessLevel >= gitLabDeveloper {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (p *GitLab) GetRef(ctx context.Context, owner, repo, branch string) (string, error) {
+	var b struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	err := p.rest.do(ctx, http.MethodGet, p.project(owner, repo)+"/repository/branches/"+url.PathEscape(branch), nil, &b)
+	return b.Commit.ID, classify(err, ErrBranchNotFound, http.StatusNotFound, "branch not found")
+}
+
+func (p *GitLab) CreateRef(ctx context.Context, owner, repo, branch, sha string) error {
+	query := url.Values{"branch": {branch}, "ref": {sha}}
+	err := p.rest.do(ctx, http.MethodPost, p.project(owner, repo)+"/repository/branches?"+query.Encode(), nil, nil)
+	return classify(err, ErrBranchExists, http.StatusBadRequest, "already exists")
+}
+
+// CreateCommit commits on the head of the branch, GitLab does not take the parent
+// commit, creating or updating each file depending on whether it exists.
+func (p *GitLab) CreateCommit(ctx context.Context, owner, repo string, c Commit) (string, error) {
+	type action struct {
+		Action   string `json:"action"`
+		FilePath string `json:"file_path"`
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+
+	actions := make([]action, 0, len(c.Files))
+	for _, v := range c.Files {
+		exists, err := p.fileExists(ctx, owner, repo, c.Branch, v.Path)
+		if err != nil {
+			return "", err
+		}
+
+		content, err := v.Bytes()
+		if err != nil {
+			return "", err
+		}
+
+		a := action{Action: "create", FilePath: v.Path, Content: base64.StdEncoding.EncodeToString(content), Encoding: "base64"}
+		if exists {
+			a.Action = "update"
+		}
+		actions = append(actions, a)
+	}
+
+	in := map[string]interface{}{
+		"branch":         c.Branch,
+		"commit_message": c.Message,
+		"author_name":    c.Author.Name,
+		"author_email":   c.Author.Email,
+		"actions":        actions,
+	}
+
+	var out struct {
+		ID string `json:"id"`
+	}
+	err := p.rest.do(ctx, http.MethodPost, p.project(owner, repo)+"/repository/commits", in, &out)
+	return out.ID, err
+}
+
+func (p *GitLab) fileExists(ctx context.Context, owner, repo, branch, path string) (bool, error) {
+	query := url.Values{"ref": {branch}}
+	err := p.rest.do(ctx, http.MethodHead, p.project(owner, repo)+"/repository/files/"+url.PathEscape(path)+"?"+query.Encode(), nil, nil)
+	switch {
+	case err == nil:
+		return true, nil
+	case isStatus(err, http.StatusNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (p *GitLab) CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (PullRequest, error) {
+	in := map[string]interface{}{
+		"source_branch": pr.Head,
+		"target_branch": pr.Base,
+		"title":         pr.Title,
+		"description":   pr.Body,
+	}
+
+	var out gitLabMergeRequest
+	if err := p.rest.do(ctx, http.MethodPost, p.project(owner, repo)+"/merge_requests", in, &out); err != nil {
+		return PullRequest{}, classify(err, ErrPullRequestExists, http.StatusConflict, "")
+	}
+
+	return out.pullRequest(), nil
+}
+
+// _gitLabStates maps the pull request states to the merge request ones.
+var _gitLabStates = map[string]string{
+	StateOpen:   "opened",
+	StateClosed: "closed",
+	StateMerged: "merged",
+	StateAll:    "all",
+}
+
+func (p *GitLab) ListPullRequests(ctx context.Context, owner, repo string, opt ListOptions) ([]PullRequest, error) {
+	query := url.Values{"state": {_gitLabStates[opt.state()]}, "per_page": {"100"}}
+	if opt.Head != "" {
+		query.Set("source_branch", opt.Head)
+	}
+
+	if opt.Base != "" {
+		query.Set("target_branch", opt.Base)
+	}
+
+	var pulls []PullRequest
+	for next := p.project(owner, repo) + "/merge_requests?" + query.Encode(); next != ""; {
+		var out []gitLabMergeRequest
+		var err error
+		if next, err = p.rest.page(ctx, next, &out); err != nil {
+			return nil, err
+		}
+
+		for _, v := range out {
+			pulls = append(pulls, v.pullRequest())
+		}
+	}
+
+	return pulls, nil
+}
+
+// MergePullRequest merges with the merge method of the project, squashing
+// the commits for MergeSquash. Rebasing is configured per project.
+func (p *GitLab) MergePullRequest(ctx context.Context, owner, repo string, number int, method MergeMethod) error {
+	in := map[string]interface{}{"squash": method == MergeSquash}
+	return p.rest.do(ctx, http.MethodPut, p.project(owner, repo)+"/merge_requests/"+strconv.Itoa(number)+"/merge", in, nil)
+}
+
+type gitLabMergeRequest struct {
+	IID          int       `json:"iid"`
+	WebURL       string    `json:"web_url"`
+	Title        string    `json:"title"`
+	State        string    `json:"state"`
+	SourceBranch string    `json:"source_branch"`
+	TargetBranch string    `json:"target_branch"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (mr gitLabMergeRequest) pullRequest() PullRequest {
+	state := mr.State
+	switch state {
+	// a merge request is locked while being merged, still open until then.
+	case "opened", "locked":
+		state = StateOpen
+	}
+
+	return PullRequest{
+		Number:    mr.IID,
+		URL:       mr.WebURL,
+		Title:     mr.Title,
+		State:     state,
+		Head:      mr.SourceBranch,
+		Base:      mr.TargetBranch,
+		CreatedAt: mr.CreatedAt,
+	}
+}
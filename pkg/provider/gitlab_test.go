@@ -0,0 +1,16 @@
+package provider
+
+import "testing"
+
+func TestGitLabMergeRequestState(t *testing.T) {
+	for state, want := range map[string]string{
+		"opened": StateOpen,
+		"locked": StateOpen,
+		"closed": StateClosed,
+		"merged": StateMerged,
+	} {
+		if got := (gitLabMergeRequest{State: state}).pullRequest().State; got != want {
+			t.Errorf("%s: got state %q, want %q", state, got, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Labeler is implemented by the providers able to label pull requests.
+// Labels missing in the repository are created by the hosts that allow it.
+type Labeler interface {
+	AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error
+}
+
+func (p *GitHub) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	_, _, err := p.client.Issues.AddLabelsToIssue(ctx, owner, repo, number, labels)
+	return classifyGitHub(err, nil)
+}
+
+func (p *GitLab) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	in := map[string]string{"add_labels": strings.Join(labels, ",")}
+	return p.rest.do(ctx, http.MethodPut, p.project(owner, repo)+"/merge_requests/"+strconv.Itoa(number), in, nil)
+}
+
+// AddLabels labels the pull request by name, which requires Gitea 1.19 or
+// later, the labels having to exist.
+func (p *Gitea) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	in := map[string][]string{"labels": labels}
+	return p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/issues/"+strconv.Itoa(number)+"/labels", in, nil)
+}
+
+func (p *Azure) AddLabels(ctx context.Context, owner, repo string, number int, labels []string) error {
+	for _, v := range labels {
+		in := map[string]string{"name": v}
+		if err := p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/pullrequests/"+strconv.Itoa(number)+"/labels"+p.query(nil), in, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var (
+	_ Labeler = (*GitHub)(nil)
+	_ Labeler = (*GitLab)(nil)
+	_ Labeler = (*Gitea)(nil)
+	_ Labeler = (*Azure)(nil)
+)
@@ -0,0 +1,217 @@
+// Package provider defines the operations the batch pull request engine needs
+// from a hosting service, and implements them for GitHub, GitLab, Bitbucket,
+// Azure Repos and Gitea.
+//
+// Additional hosts implement Provider and are made available to config files
+// by name with Register.
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Provider is a hosting service pull requests are created on. Owners are the
+// users, organizations, groups, workspaces or projects holding the
+// repositories, depending on the host.
+type Provider interface {
+	// CurrentUser returns the authenticated user, author of the commits.
+	CurrentUser(ctx context.Context) (User, error)
+
+	// CanPush reports whether the authenticated user can push to the repository.
+	CanPush(ctx context.Context, owner, repo string) (bool, error)
+
+	// GetRef returns the SHA of the commit the branch points to, an error
+	// wrapping ErrBranchNotFound when it does not exist.
+	GetRef(ctx context.Context, owner, repo, branch string) (string, error)
+
+	// CreateRef creates a branch pointing to the commit sha.
+	CreateRef(ctx context.Context, owner, repo, branch, sha string) error
+
+	// CreateCommit commits the files on top of the parent commit and moves the
+	// branch to the new commit, whose SHA is returned.
+	CreateCommit(ctx context.Context, owner, repo string, c Commit) (string, error)
+
+	// CreatePullRequest creates the pull request.
+	CreatePullRequest(ctx context.Context, owner, repo string, pr NewPullRequest) (PullRequest, error)
+
+	// ListPullRequests returns the pull requests matching the options.
+	ListPullRequests(ctx context.Context, owner, repo string, opt ListOptions) ([]PullRequest, error)
+
+	// MergePullRequest merges the pull request with the given method.
+	MergePullRequest(ctx context.Context, owner, repo string, number int, method MergeMethod) error
+}
+
+// User is the authenticated user.
+type User struct {
+	Name  string
+	Email string
+
+	// Scopes granted to the token, nil when the provider does not report them.
+	Scopes []string
+}
+
+// Commit holds what is committed on a branch.
+type Commit struct {
+	Branch  string
+	Parent  string // SHA of the parent commit, the head of Branch.
+	Message string
+	Author  User
+	Files   []File
+}
+
+// File is the content of a file at its path in the repository.
+type File struct {
+	Path    string
+	Content []byte
+
+	// Open streams the content when Content is nil, so large files are not
+	// held in memory, and Size is its length.
+	Open func() (io.ReadCloser, error)
+	Size int64
+}
+
+// Bytes returns the content of the file, reading it when streamed.
+func (f File) Bytes() ([]byte, error) {
+	if f.Content != nil || f.Open == nil {
+		return f.Content, nil
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+// IsText reports whether content is valid UTF-8 without NUL bytes, the
+// content that can be sent as a JSON string without being mangled. Other
+// content is binary and must be base64 encoded.
+func IsText(content []byte) bool {
+	return utf8.Valid(content) && bytes.IndexByte(content, 0) < 0
+}
+
+// Len returns the length of the content.
+func (f File) Len() int64 {
+	if f.Content != nil || f.Open == nil {
+		return int64(len(f.Content))
+	}
+
+	return f.Size
+}
+
+// NewPullRequest holds the pull request to create.
+type NewPullRequest struct {
+	Title string
+	Body  string
+	Head  string
+	Base  string
+}
+
+// Pull request states, as normalized by the providers.
+const (
+	StateOpen   = "open"
+	StateClosed = "closed" // closed without being merged.
+	StateMerged = "merged"
+	StateAll    = "all" // only valid in ListOptions.
+)
+
+// PullRequest is a pull request, or merge request, of a repository.
+type PullRequest struct {
+	Number    int
+	URL       string
+	Title     string
+	State     string // StateOpen, StateClosed or StateMerged.
+	Head      string
+	Base      string
+	CreatedAt time.Time
+}
+
+// ListOptions filters the pull requests returned by ListPullRequests. Empty
+// fields do not filter.
+type ListOptions struct {
+	State string // StateOpen by default.
+	Head  string
+	Base  string
+}
+
+// MergeMethod is the way the commits of a pull request are merged. Providers
+// merge with their default method when it is empty.
+type MergeMethod string
+
+// Merge methods supported by the providers.
+const (
+	MergeCommit MergeMethod = "merge"
+	MergeSquash MergeMethod = "squash"
+	MergeRebase MergeMethod = "rebase"
+)
+
+// Factory returns the provider of the instance at baseURL, its default
+// instance when empty, sending requests with tc.
+type Factory func(tc *http.Client, baseURL string) (Provider, error)
+
+var (
+	_mu        sync.RWMutex
+	_factories = make(map[string]Factory)
+)
+
+// Register makes a provider available by name to the engine. It panics if a
+// provider is registered twice under the same name.
+func Register(name string, f Factory) {
+	_mu.Lock()
+	defer _mu.Unlock()
+	if _, ok := _factories[name]; ok {
+		panic(fmt.Sprintf("provider %s registered twice", name))
+	}
+	_factories[name] = f
+}
+
+// Lookup returns the factory registered by name.
+func Lookup(name string) (Factory, bool) {
+	_mu.RLock()
+	defer _mu.RUnlock()
+	f, ok := _factories[name]
+	return f, ok
+}
+
+// Matches reports whether pr satisfies the options, for providers that cannot
+// filter on their side.
+func (o ListOptions) Matches(pr PullRequest) bool {
+	switch {
+	case o.Head != "" && o.Head != pr.Head:
+		return false
+	case o.Base != "" && o.Base != pr.Base:
+		return false
+	case o.state() != StateAll && o.state() != pr.State:
+		return false
+	default:
+		return true
+	}
+}
+
+func (o ListOptions) state() string {
+	if o.State == "" {
+		return StateOpen
+	}
+
+	return o.State
+}
+
+var (
+	_ Provider = (*GitHub)(nil)
+	_ Provider = (*GitHubGraphQL)(nil)
+	_ Provider = (*GitLab)(nil)
+	_ Provider = (*BitbucketCloud)(nil)
+	_ Provider = (*BitbucketServer)(nil)
+	_ Provider = (*Azure)(nil)
+	_ Provider = (*Gitea)(nil)
+)
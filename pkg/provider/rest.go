@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/sorfino/go-toolkit-cmd/internal/linkheader"
+)
+
+// restClient sends JSON requests to the REST API of the providers without a
+// dedicated client library.
+type restClient struct {
+	client  *http.Client
+	baseURL string      // without trailing slash.
+	header  http.Header // added to every request.
+}
+
+// StatusError is returned by the REST based providers for unexpected
+// response statuses.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, e.Body)
+}
+
+// Is reports too many requests responses as ErrRateLimited.
+func (e *StatusError) Is(target error) bool {
+	return target == ErrRateLimited && e.StatusCode == http.StatusTooManyRequests
+}
+
+// formField is a field of a multipart form, sent as a file part when File is
+// set.
+type formField struct {
+	Name  string
+	Value []byte
+	File  bool
+}
+
+// do sends in as JSON, when not nil, and decodes the response into out, when
+// not nil.
+func (c *restClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	contentType := ""
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body, contentType = bytes.NewReader(b), "application/json"
+	}
+
+	_, err := c.send(ctx, method, path, body, contentType, out)
+	return err
+}
+
+// page gets a page of a listing into out and returns the URL of the next one,
+// given by the Link header, empty on the last page.
+func (c *restClient) page(ctx context.Context, path string, out interface{}) (string, error) {
+	header, err := c.send(ctx, http.MethodGet, path, nil, "", out)
+	if err != nil {
+		return "", err
+	}
+
+	return linkheader.Next(header), nil
+}
+
+// form sends the fields as a multipart form and decodes the response into
+// out, when not nil.
+func (c *restClient) form(ctx context.Context, method, path string, fields []formField, out interface{}) error {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	for _, v := range fields {
+		var part io.Writer
+		var err error
+		if v.File {
+			part, err = w.CreateFormFile(v.Name, v.Name)
+		} else {
+			part, err = w.CreateFormField(v.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := part.Write(v.Value); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	_, err := c.send(ctx, method, path, &b, w.FormDataContentType(), out)
+	return err
+}
+
+// send sends the request and decodes the response into out, when not nil,
+// returning the response headers.
+func (c *restClient) send(ctx context.Context, method, path string, body io.Reader, contentType string, out interface{}) (http.Header, error) {
+	// absolute URLs, such as the next page links, are used as is.
+	url := path
+	if !strings.HasPrefix(path, "https://") && !strings.HasPrefix(path, "http://") {
+		url = c.baseURL + "/" + strings.TrimPrefix(path, "/")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &StatusError{Method: method, URL: url, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return resp.Header, nil
+	}
+
+	return resp.Header, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// isStatus reports whether err is a *StatusError with the given status code.
+func isStatus(err error, code int) bool {
+	var e *StatusError
+	return errors.As(err, &e) && e.StatusCode == code
+}
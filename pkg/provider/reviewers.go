@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// ReviewRequester is implemented by the providers able to request reviews of
+// pull requests. Reviewers are user names or, for teams, "org/team".
+type ReviewRequester interface {
+	RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers []string) error
+}
+
+func (p *GitHub) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	users, teams := splitReviewers(reviewers)
+	_, _, err := p.client.PullRequests.RequestReviewers(ctx, owner, repo, number, github.ReviewersRequest{Reviewers: users, TeamReviewers: teams})
+	return classifyGitHub(err, nil)
+}
+
+func (p *Gitea) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers []string) error {
+	users, teams := splitReviewers(reviewers)
+	in := map[string][]string{"reviewers": users, "team_reviewers": teams}
+	return p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/pulls/"+strconv.Itoa(number)+"/requested_reviewers", in, nil)
+}
+
+// splitReviewers returns the users and the team names of the reviewers, the
+// teams being given as "org/team".
+func splitReviewers(reviewers []string) (users, teams []string) {
+	for _, v := range reviewers {
+		if i := strings.Index(v, "/"); i >= 0 {
+			teams = append(teams, v[i+1:])
+			continue
+		}
+		users = append(users, v)
+	}
+
+	return users, teams
+}
+
+var (
+	_ ReviewRequester = (*GitHub)(nil)
+	_ ReviewRequester = (*GitHubGraphQL)(nil)
+	_ ReviewRequester = (*Gitea)(nil)
+)
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/go-github/github"
+)
+
+// Review states.
+const (
+	ReviewApproved         = "approved"
+	ReviewChangesRequested = "changes_requested"
+	ReviewCommented        = "commented"
+	ReviewDismissed        = "dismissed"
+)
+
+// Review is a review submitted on a pull request.
+type Review struct {
+	Reviewer string
+	State    string
+}
+
+// ReviewLister is implemented by the providers able to list the reviews of
+// pull requests, in the order they were submitted.
+type ReviewLister interface {
+	ListReviews(ctx context.Context, owner, repo string, number int) ([]Review, error)
+}
+
+// _gitHubReviewStates maps the GitHub review states, the pending ones being
+// left out.
+var _gitHubReviewStates = map[string]string{
+	"APPROVED":          ReviewApproved,
+	"CHANGES_REQUESTED": ReviewChangesRequested,
+	"COMMENTED":         ReviewCommented,
+	"DISMISSED":         ReviewDismissed,
+}
+
+func (p *GitHub) ListReviews(ctx context.Context, owner, repo string, number int) ([]Review, error) {
+	listOptions := &github.ListOptions{PerPage: 100}
+	var reviews []Review
+	for {
+		page, resp, err := p.client.PullRequests.ListReviews(ctx, owner, repo, number, listOptions)
+		if err != nil {
+			return nil, classifyGitHub(err, nil)
+		}
+
+		for _, v := range page {
+			if state, ok := _gitHubReviewStates[v.GetState()]; ok {
+				reviews = append(reviews, Review{Reviewer: v.GetUser().GetLogin(), State: state})
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return reviews, nil
+		}
+		listOptions.Page = resp.NextPage
+	}
+}
+
+// _giteaReviewStates maps the Gitea review states, the pending ones and the
+// review requests being left out.
+var _giteaReviewStates = map[string]string{
+	"APPROVED":        ReviewApproved,
+	"REQUEST_CHANGES": ReviewChangesRequested,
+	"COMMENT":         ReviewCommented,
+}
+
+func (p *Gitea) ListReviews(ctx context.Context, owner, repo string, number int) ([]Review, error) {
+	query := url.Values{"limit": {"50"}}
+	var reviews []Review
+	for next := p.repository(owner, repo) + "/pulls/" + strconv.Itoa(number) + "/reviews?" + query.Encode(); next != ""; {
+		var out []struct {
+			State     string `json:"state"`
+			Dismissed bool   `json:"dismissed"`
+			User      struct {
+				Login string `json:"login"`
+			} `json:"user"`
+		}
+		var err error
+		if next, err = p.rest.page(ctx, next, &out); err != nil {
+			return nil, err
+		}
+
+		for _, v := range out {
+			state, ok := _giteaReviewStates[v.State]
+			if !ok {
+				continue
+			}
+			if v.Dismissed {
+				state = ReviewDismissed
+			}
+			reviews = append(reviews, Review{Reviewer: v.User.Login, State: state})
+		}
+	}
+
+	return reviews, nil
+}
+
+// ListReviews returns the approvals of the merge request, GitLab having no
+// review requesting changes.
+func (p *GitLab) ListReviews(ctx context.Context, owner, repo string, number int) ([]Review, error) {
+	var out struct {
+		ApprovedBy []struct {
+			User struct {
+				Username string `json:"username"`
+			} `json:"user"`
+		} `json:"approved_by"`
+	}
+	if err := p.rest.do(ctx, http.MethodGet, p.project(owner, repo)+"/merge_requests/"+strconv.Itoa(number)+"/approvals", nil, &out); err != nil {
+		return nil, err
+	}
+
+	reviews := make([]Review, 0, len(out.ApprovedBy))
+	for _, v := range out.ApprovedBy {
+		reviews = append(reviews, Review{Reviewer: v.User.Username, State: ReviewApproved})
+	}
+
+	return reviews, nil
+}
+
+var (
+	_ ReviewLister = (*GitHub)(nil)
+	_ ReviewLister = (*GitHubGraphQL)(nil)
+	_ ReviewLister = (*Gitea)(nil)
+	_ ReviewLister = (*GitLab)(nil)
+)
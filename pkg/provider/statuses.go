@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/google/go-github/github"
+)
+
+// CommitStatus is a status reported on a commit, shown by the hosts next to
+// the CI results.
+type CommitStatus struct {
+	State       string // StatusSuccess, StatusPending or StatusFailure.
+	Context     string // identifies the status among the others of the commit.
+	Description string
+	TargetURL   string
+}
+
+// States of a CommitStatus.
+const (
+	StatusSuccess = "success"
+	StatusPending = "pending"
+	StatusFailure = "failure"
+)
+
+// StatusSetter is implemented by the providers able to set commit statuses.
+type StatusSetter interface {
+	SetCommitStatus(ctx context.Context, owner, repo, sha string, s CommitStatus) error
+}
+
+func (p *GitHub) SetCommitStatus(ctx context.Context, owner, repo, sha string, s CommitStatus) error {
+	_, _, err := p.client.Repositories.CreateStatus(ctx, owner, repo, sha, &github.RepoStatus{
+		State:       github.String(s.State),
+		Context:     github.String(s.Context),
+		Description: github.String(s.Description),
+		TargetURL:   github.String(s.TargetURL),
+	})
+	return classifyGitHub(err, nil)
+}
+
+// _gitLabStatuses maps the states to the ones of GitLab.
+var _gitLabStatuses = map[string]string{StatusSuccess: "success", StatusPending: "pending", StatusFailure: "failed"}
+
+func (p *GitLab) SetCommitStatus(ctx context.Context, owner, repo, sha string, s CommitStatus) error {
+	query := url.Values{"state": {_gitLabStatuses[s.State]}, "name": {s.Context}, "description": {s.Description}}
+	if s.TargetURL != "" {
+		query.Set("target_url", s.TargetURL)
+	}
+
+	return p.rest.do(ctx, http.MethodPost, p.project(owner, repo)+"/statuses/"+sha+"?"+query.Encode(), nil, nil)
+}
+
+func (p *Gitea) SetCommitStatus(ctx context.Context, owner, repo, sha string, s CommitStatus) error {
+	in := map[string]string{"state": s.State, "context": s.Context, "description": s.Description, "target_url": s.TargetURL}
+	return p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/statuses/"+sha, in, nil)
+}
+
+// _bitbucketStatuses maps the states to the ones of Bitbucket Cloud.
+var _bitbucketStatuses = map[string]string{StatusSuccess: "SUCCESSFUL", StatusPending: "INPROGRESS", StatusFailure: "FAILED"}
+
+// SetCommitStatus sets a build status, Bitbucket requiring its URL.
+func (p *BitbucketCloud) SetCommitStatus(ctx context.Context, owner, repo, sha string, s CommitStatus) error {
+	in := map[string]string{"key": s.Context, "state": _bitbucketStatuses[s.State], "name": s.Context, "description": s.Description, "url": s.TargetURL}
+	return p.rest.do(ctx, http.MethodPost, p.repository(owner, repo)+"/commit/"+sha+"/statuses/build", in, nil)
+}
+
+var (
+	_ StatusSetter = (*GitHub)(nil)
+	_ StatusSetter = (*GitLab)(nil)
+	_ StatusSetter = (*Gitea)(nil)
+	_ StatusSetter = (*BitbucketCloud)(nil)
+)
@@ -0,0 +1,31 @@
+package provider
+
+import "context"
+
+// Verification is the signature verification of a commit.
+type Verification struct {
+	Verified bool
+	Reason   string // given by the host, for instance, "unsigned".
+}
+
+// CommitVerifier is implemented by the providers reporting whether commits
+// are signature verified, which repositories requiring signed commits
+// enforce before merging.
+type CommitVerifier interface {
+	VerifyCommit(ctx context.Context, owner, repo, sha string) (Verification, error)
+}
+
+func (p *GitHub) VerifyCommit(ctx context.Context, owner, repo, sha string) (Verification, error) {
+	commit, _, err := p.client.Git.GetCommit(ctx, owner, repo, sha)
+	if err != nil {
+		return Verification{}, classifyGitHub(err, nil)
+	}
+
+	if commit.Verification == nil {
+		return Verification{Reason: "unknown"}, nil
+	}
+
+	return Verification{Verified: commit.Verification.GetVerified(), Reason: commit.Verification.GetReason()}, nil
+}
+
+var _ CommitVerifier = (*GitHub)(nil)